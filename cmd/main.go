@@ -1,16 +1,31 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/Businge931/expense-tracker/internal/cli"
+	"github.com/Businge931/expense-tracker/internal/models"
 	"github.com/Businge931/expense-tracker/internal/repository"
 	"github.com/Businge931/expense-tracker/internal/service"
 )
 
 func main() {
+	// Parse global options that precede the command
+	defaultCurrency := os.Getenv("EXPENSE_TRACKER_BASE_CURRENCY")
+	if defaultCurrency == "" {
+		defaultCurrency = models.DefaultCurrency
+	}
+	baseCurrency := flag.String("base-currency", defaultCurrency, "Currency to report summaries and budgets in")
+	ratesFile := flag.String("rates", "", "Exchange rate file (defaults to data/rates.xml or data/rates.csv)")
+	store := flag.String("store", os.Getenv("EXPENSE_TRACKER_STORE"), "Expense store as json:DIRECTORY, sqlite:FILE or events:DIRECTORY (defaults to json:data)")
+	output := cli.OutputTable
+	flag.Var(&output, "output", "Output format: table, json, csv or yaml")
+	flag.Parse()
+
 	// Set up the data directory in the project
 	execDir, err := os.Getwd()
 	if err != nil {
@@ -20,24 +35,98 @@ func main() {
 
 	dataDir := filepath.Join(execDir, "data")
 
-	// Initialize repository
-	repo, err := repository.NewJSONFileRepository(dataDir)
+	// Initialize repositories
+	if *store == "" {
+		*store = "json:" + dataDir
+	}
+	repo, err := repository.OpenExpenseRepository(*store)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing repository: %v\n", err)
 		os.Exit(1)
 	}
+	if closer, ok := repo.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	budgetRepo, err := repository.NewJSONBudgetRepository(dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing budget repository: %v\n", err)
+		os.Exit(1)
+	}
+
+	journalRepo, err := repository.NewJSONJournalRepository(dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing journal: %v\n", err)
+		os.Exit(1)
+	}
+
+	recurringRepo, err := repository.NewJSONRecurringRepository(dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing recurring expense repository: %v\n", err)
+		os.Exit(1)
+	}
+
+	categoryRepo, err := repository.NewJSONCategoryRepository(dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing category repository: %v\n", err)
+		os.Exit(1)
+	}
+
+	ruleRepo, err := repository.NewJSONCategoryRuleRepository(dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing category rule repository: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Load exchange rates, if any are available
+	rates, err := loadExchangeRates(*ratesFile, dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading exchange rates: %v\n", err)
+		os.Exit(1)
+	}
+
+	converter, err := service.NewCurrencyConverter(*baseCurrency, rates)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Initialize services
-	expenseService := service.NewExpenseService(repo)
-	budgetService := service.NewBudgetService(expenseService)
+	expenseService := service.NewExpenseService(repo, converter, journalRepo, categoryRepo, ruleRepo)
+	budgetService := service.NewBudgetService(budgetRepo, expenseService, journalRepo)
 	exportService := service.NewExportService(expenseService)
+	importService := service.NewImportService(expenseService)
+	undoService := service.NewUndoService(journalRepo, repo, budgetRepo, categoryRepo)
+	recurringService := service.NewRecurringService(recurringRepo, expenseService)
+	categoryService := service.NewCategoryService(categoryRepo, expenseService, budgetRepo, journalRepo)
+	ruleService := service.NewCategoryRuleService(ruleRepo, expenseService, journalRepo)
 
 	// Initialize CLI
-	cli := cli.NewCLI(expenseService, budgetService, exportService)
+	cli := cli.NewCLI(expenseService, budgetService, exportService, importService, undoService, recurringService, categoryService, ruleService, output)
 
 	// Run CLI with command-line arguments
-	if err := cli.Run(os.Args[1:]); err != nil {
+	if err := cli.Run(flag.Args()); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if closer, ok := repo.(io.Closer); ok {
+			closer.Close()
+		}
 		os.Exit(1)
 	}
 }
+
+// loadExchangeRates loads the given rate file, or the default one in the data
+// directory when no file is given. A missing default file yields no rates.
+func loadExchangeRates(ratesFile string, dataDir string) ([]models.ExchangeRate, error) {
+	if ratesFile != "" {
+		return repository.LoadExchangeRates(ratesFile)
+	}
+
+	for _, name := range []string{"rates.xml", "rates.csv"} {
+		path := filepath.Join(dataDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return repository.LoadExchangeRates(path)
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,498 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// Amount is a monetary amount in a request body. Unlike models.Money, which
+// also reads the JSON numbers of older data files, it only accepts a decimal
+// string with at most two decimal places, so amounts are never rounded.
+type Amount models.Money
+
+// UnmarshalJSON decodes a decimal string such as "12.50"
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("amount must be a decimal string such as \"12.50\", got %s", data)
+	}
+
+	amount, err := models.ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*a = Amount(amount)
+	return nil
+}
+
+// MarshalJSON encodes the amount as a decimal string
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return models.Money(a).MarshalJSON()
+}
+
+// ExpenseInput is the body of a request adding an expense
+type ExpenseInput struct {
+	Description string   `json:"description"`
+	Amount      Amount   `json:"amount"`
+	Currency    string   `json:"currency,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Merchant    string   `json:"merchant,omitempty"`
+	Date        string   `json:"date,omitempty"` // YYYY-MM-DD or RFC 3339, defaults to now
+	AllowFuture bool     `json:"allowFuture,omitempty"`
+}
+
+// ExpenseUpdateInput is the body of a request changing an expense. Omitted
+// fields are left unchanged.
+type ExpenseUpdateInput struct {
+	Description *string   `json:"description,omitempty"`
+	Amount      *Amount   `json:"amount,omitempty"`
+	Currency    *string   `json:"currency,omitempty"`
+	Category    *string   `json:"category,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+	Merchant    *string   `json:"merchant,omitempty"`
+	Date        *string   `json:"date,omitempty"`
+	AllowFuture bool      `json:"allowFuture,omitempty"`
+}
+
+// BudgetInput is the body of a request setting a budget
+type BudgetInput struct {
+	Month    int    `json:"month"`
+	Year     int    `json:"year,omitempty"`     // Defaults to the current year
+	Category string `json:"category,omitempty"` // Empty for the overall monthly cap
+	Amount   Amount `json:"amount"`
+}
+
+// expenseQueryParams document the filters of the expense list and export
+var expenseQueryParams = []param{
+	{name: "from", in: "query", kind: "string", format: "date", description: "Only expenses on or after this date"},
+	{name: "to", in: "query", kind: "string", format: "date", description: "Only expenses on or before this date"},
+	{name: "category", in: "query", kind: "string", description: "Only expenses in this category or its subcategories"},
+	{name: "tag", in: "query", kind: "string", description: "Only expenses with this tag; may be repeated to require several", repeated: true},
+	{name: "min", in: "query", kind: "string", description: "Only expenses of at least this amount, in their own currency"},
+	{name: "max", in: "query", kind: "string", description: "Only expenses of at most this amount, in their own currency"},
+	{name: "search", in: "query", kind: "string", description: "Only expenses whose description contains this text"},
+	{name: "sort", in: "query", kind: "string", description: "Field to sort by: id, date, amount, description or category"},
+	{name: "desc", in: "query", kind: "boolean", description: "Sort in descending order"},
+	{name: "limit", in: "query", kind: "integer", description: "Maximum number of expenses"},
+	{name: "offset", in: "query", kind: "integer", description: "Number of expenses to skip"},
+}
+
+// idParam documents the expense ID in paths
+var idParam = param{name: "id", in: "path", kind: "integer", description: "ID of the expense", required: true}
+
+// periodParams document the month and year of summaries and budgets
+var periodParams = []param{
+	{name: "month", in: "query", kind: "integer", description: "Month from 1 to 12"},
+	{name: "year", in: "query", kind: "integer", description: "Year, defaults to the current year"},
+}
+
+// budgetParams identify a budget in the query string
+var budgetParams = []param{
+	{name: "month", in: "query", kind: "integer", description: "Month from 1 to 12", required: true},
+	{name: "year", in: "query", kind: "integer", description: "Year, defaults to the current year"},
+	{name: "category", in: "query", kind: "string", description: "Category of the budget, empty for the overall monthly cap"},
+}
+
+// endpoints lists the routes of the API
+func (s *Server) endpoints() []route {
+	badRequest := []int{http.StatusBadRequest}
+	notFound := []int{http.StatusBadRequest, http.StatusNotFound}
+	// Requests that change data may also be refused for a body that is too
+	// large or not JSON
+	create := []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType}
+	change := []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType}
+	remove := []int{http.StatusBadRequest, http.StatusNotFound}
+
+	return []route{
+		{method: "GET", path: "/api/expenses", summary: "List expenses, filtered, sorted and paged",
+			params: expenseQueryParams, response: []models.Expense{}, status: http.StatusOK, errors: badRequest, handle: s.listExpenses},
+		{method: "POST", path: "/api/expenses", summary: "Add an expense; without a category, category rules may set one",
+			body: ExpenseInput{}, response: models.Expense{}, status: http.StatusCreated, errors: create, handle: s.addExpense},
+		{method: "GET", path: "/api/expenses/{id}", summary: "Get an expense",
+			params: []param{idParam}, response: models.Expense{}, status: http.StatusOK, errors: notFound, handle: s.getExpense},
+		{method: "PATCH", path: "/api/expenses/{id}", summary: "Change the given fields of an expense",
+			params: []param{idParam}, body: ExpenseUpdateInput{}, response: models.Expense{}, status: http.StatusOK, errors: change, handle: s.updateExpense},
+		{method: "DELETE", path: "/api/expenses/{id}", summary: "Move an expense to the trash",
+			params: []param{idParam}, status: http.StatusNoContent, errors: remove, handle: s.deleteExpense},
+		{method: "GET", path: "/api/summary", summary: "Summarize all expenses, or those of a month, in the base currency",
+			params: periodParams, response: models.ExpenseSummary{}, status: http.StatusOK, errors: badRequest, handle: s.getSummary},
+		{method: "GET", path: "/api/budgets", summary: "List all budgets",
+			response: []models.Budget{}, status: http.StatusOK, handle: s.listBudgets},
+		{method: "PUT", path: "/api/budgets", summary: "Set a monthly budget, replacing any existing one",
+			body: BudgetInput{}, response: models.Budget{}, status: http.StatusOK, errors: create, handle: s.setBudget},
+		{method: "DELETE", path: "/api/budgets", summary: "Delete a monthly budget",
+			params: budgetParams, status: http.StatusNoContent, errors: remove, handle: s.deleteBudget},
+		{method: "GET", path: "/api/budgets/report", summary: "Compare the spending of a month with its budgets",
+			params: budgetParams[:2], response: models.BudgetReport{}, status: http.StatusOK, errors: notFound, handle: s.getBudgetReport},
+		{method: "GET", path: "/api/export", summary: "Export expenses as CSV in the format read by import",
+			params: expenseQueryParams, status: http.StatusOK, contentType: "text/csv", errors: badRequest, handle: s.exportExpenses},
+		{method: "GET", path: "/api/openapi.json", summary: "This OpenAPI document",
+			status: http.StatusOK, handle: s.getOpenAPI},
+	}
+}
+
+// listExpenses handles GET /api/expenses
+func (s *Server) listExpenses(w http.ResponseWriter, r *http.Request) error {
+	query, err := expenseQuery(r)
+	if err != nil {
+		return err
+	}
+
+	expenses, err := s.expenseService.FindExpenses(query)
+	if err != nil {
+		return err
+	}
+	if expenses == nil {
+		expenses = []models.Expense{}
+	}
+
+	writeJSON(w, http.StatusOK, expenses)
+	return nil
+}
+
+// addExpense handles POST /api/expenses
+func (s *Server) addExpense(w http.ResponseWriter, r *http.Request) error {
+	var body ExpenseInput
+	if err := decodeBody(w, r, &body); err != nil {
+		return err
+	}
+
+	input := service.NewExpense{
+		Description: body.Description,
+		Amount:      models.Money(body.Amount),
+		Currency:    body.Currency,
+		Category:    body.Category,
+		Tags:        body.Tags,
+		Merchant:    body.Merchant,
+		AllowFuture: body.AllowFuture,
+	}
+	if body.Date != "" {
+		date, err := parseDate(body.Date)
+		if err != nil {
+			return err
+		}
+		input.Date = date
+	}
+
+	added, err := s.expenseService.AddExpense(input)
+	if err != nil {
+		return err
+	}
+
+	expense, err := s.expenseService.GetExpenseByID(added.ID)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/expenses/%d", expense.ID))
+	writeJSON(w, http.StatusCreated, expense)
+	return nil
+}
+
+// getExpense handles GET /api/expenses/{id}
+func (s *Server) getExpense(w http.ResponseWriter, r *http.Request) error {
+	id, err := expenseID(r)
+	if err != nil {
+		return err
+	}
+
+	expense, err := s.expenseService.GetExpenseByID(id)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, expense)
+	return nil
+}
+
+// updateExpense handles PATCH /api/expenses/{id}
+func (s *Server) updateExpense(w http.ResponseWriter, r *http.Request) error {
+	id, err := expenseID(r)
+	if err != nil {
+		return err
+	}
+
+	var body ExpenseUpdateInput
+	if err := decodeBody(w, r, &body); err != nil {
+		return err
+	}
+
+	update := service.ExpenseUpdate{
+		Description: body.Description,
+		Amount:      (*models.Money)(body.Amount),
+		Currency:    body.Currency,
+		Category:    body.Category,
+		Tags:        body.Tags,
+		Merchant:    body.Merchant,
+		AllowFuture: body.AllowFuture,
+	}
+	if body.Date != nil {
+		date, err := parseDate(*body.Date)
+		if err != nil {
+			return err
+		}
+		update.Date = &date
+	}
+	if update.Description == nil && update.Amount == nil && update.Currency == nil && update.Category == nil &&
+		update.Tags == nil && update.Merchant == nil && update.Date == nil {
+		return badRequest("at least one field to update is required")
+	}
+
+	expense, err := s.expenseService.UpdateExpense(id, update)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, expense)
+	return nil
+}
+
+// deleteExpense handles DELETE /api/expenses/{id}
+func (s *Server) deleteExpense(w http.ResponseWriter, r *http.Request) error {
+	id, err := expenseID(r)
+	if err != nil {
+		return err
+	}
+
+	if err := s.expenseService.DeleteExpense(id); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// getSummary handles GET /api/summary
+func (s *Server) getSummary(w http.ResponseWriter, r *http.Request) error {
+	month, year, err := period(r, false)
+	if err != nil {
+		return err
+	}
+
+	var summary models.ExpenseSummary
+	if month == 0 {
+		summary, err = s.expenseService.GetExpenseSummary()
+	} else {
+		summary, err = s.expenseService.GetMonthlySummary(month, year)
+	}
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+	return nil
+}
+
+// listBudgets handles GET /api/budgets
+func (s *Server) listBudgets(w http.ResponseWriter, r *http.Request) error {
+	budgets, err := s.budgetService.GetAllBudgets()
+	if err != nil {
+		return err
+	}
+	if budgets == nil {
+		budgets = []models.Budget{}
+	}
+
+	writeJSON(w, http.StatusOK, budgets)
+	return nil
+}
+
+// setBudget handles PUT /api/budgets
+func (s *Server) setBudget(w http.ResponseWriter, r *http.Request) error {
+	var body BudgetInput
+	if err := decodeBody(w, r, &body); err != nil {
+		return err
+	}
+	if body.Year < 0 {
+		return badRequest("year must be a positive number")
+	}
+
+	if err := s.budgetService.SetBudget(body.Month, body.Year, body.Category, models.Money(body.Amount)); err != nil {
+		return err
+	}
+
+	budget, err := s.budgetService.GetBudget(body.Month, body.Year, body.Category)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, budget)
+	return nil
+}
+
+// deleteBudget handles DELETE /api/budgets
+func (s *Server) deleteBudget(w http.ResponseWriter, r *http.Request) error {
+	month, year, err := period(r, true)
+	if err != nil {
+		return err
+	}
+
+	if err := s.budgetService.DeleteBudget(month, year, r.URL.Query().Get("category")); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// getBudgetReport handles GET /api/budgets/report
+func (s *Server) getBudgetReport(w http.ResponseWriter, r *http.Request) error {
+	month, year, err := period(r, true)
+	if err != nil {
+		return err
+	}
+
+	report, err := s.budgetService.CheckBudget(month, year)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, report)
+	return nil
+}
+
+// exportExpenses handles GET /api/export
+func (s *Server) exportExpenses(w http.ResponseWriter, r *http.Request) error {
+	query, err := expenseQuery(r)
+	if err != nil {
+		return err
+	}
+
+	// Render first so that errors can still be reported as JSON
+	var body strings.Builder
+	if err := s.exportService.ExportCSV(&body, query); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="expenses.csv"`)
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write([]byte(body.String()))
+	return err
+}
+
+// expenseQuery reads the expense filters from the query string
+func expenseQuery(r *http.Request) (repository.Query, error) {
+	values := r.URL.Query()
+	query := repository.Query{
+		Category: values.Get("category"),
+		Tags:     values["tag"],
+		Search:   values.Get("search"),
+		SortBy:   repository.SortField(strings.ToLower(values.Get("sort"))),
+	}
+
+	if value := values.Get("from"); value != "" {
+		date, err := parseDay(value)
+		if err != nil {
+			return repository.Query{}, err
+		}
+		query.From = date
+	}
+	if value := values.Get("to"); value != "" {
+		date, err := parseDay(value)
+		if err != nil {
+			return repository.Query{}, err
+		}
+		// Include the whole of the last day
+		query.To = date.AddDate(0, 0, 1)
+	}
+
+	for name, target := range map[string]**models.Money{"min": &query.Min, "max": &query.Max} {
+		if value := values.Get(name); value != "" {
+			amount, err := models.ParseMoney(value)
+			if err != nil {
+				return repository.Query{}, badRequest("invalid %s %q, expected an amount such as 12.50", name, value)
+			}
+			*target = &amount
+		}
+	}
+
+	for name, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		if value := values.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return repository.Query{}, badRequest("invalid %s %q, expected a whole number", name, value)
+			}
+			*target = n
+		}
+	}
+
+	if value := values.Get("desc"); value != "" {
+		desc, err := strconv.ParseBool(value)
+		if err != nil {
+			return repository.Query{}, badRequest("invalid desc %q, expected true or false", value)
+		}
+		query.Desc = desc
+	}
+
+	return query, nil
+}
+
+// expenseID reads the expense ID from the path
+func expenseID(r *http.Request) (int, error) {
+	value := r.PathValue("id")
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, badRequest("invalid expense ID %q", value)
+	}
+	return id, nil
+}
+
+// period reads the month and year from the query string. Without a month,
+// the month is zero unless required is set.
+func period(r *http.Request, required bool) (int, int, error) {
+	values := r.URL.Query()
+
+	var month, year int
+	if value := values.Get("month"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, badRequest("invalid month %q, expected a number from 1 to 12", value)
+		}
+		month = n
+	} else if required {
+		return 0, 0, badRequest("month is required")
+	}
+
+	if value := values.Get("year"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return 0, 0, badRequest("invalid year %q", value)
+		}
+		if month == 0 {
+			return 0, 0, badRequest("year requires a month")
+		}
+		year = n
+	}
+
+	return month, year, nil
+}
+
+// parseDay parses a YYYY-MM-DD date in the local time zone
+func parseDay(value string) (time.Time, error) {
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, badRequest("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+// parseDate parses a YYYY-MM-DD date in the local time zone or an RFC 3339 time
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	return time.Time{}, badRequest("invalid date %q, expected YYYY-MM-DD or an RFC 3339 time", value)
+}
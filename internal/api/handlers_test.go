@@ -0,0 +1,246 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// newTestServer returns a server on JSON repositories in a temporary
+// directory holding expense 1, "Coffee" in Food on March 4, 2025, and a Food
+// budget for March 2025
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	dir := t.TempDir()
+
+	expenseRepo, err := repository.NewJSONFileRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	budgetRepo, err := repository.NewJSONBudgetRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converter, err := service.NewCurrencyConverter(models.DefaultCurrency, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expenseService := service.NewExpenseService(expenseRepo, converter, nil, nil, nil)
+	budgetService := service.NewBudgetService(budgetRepo, expenseService, nil)
+
+	if _, err := expenseService.AddExpense(service.NewExpense{
+		Description: "Coffee",
+		Amount:      models.NewMoney(4, 50),
+		Category:    "Food",
+		Date:        time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := budgetService.SetBudget(3, 2025, "Food", models.NewMoney(100, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewServer(expenseService, budgetService, service.NewExportService(expenseService))
+}
+
+func TestHandlers(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		contentType string // Defaults to application/json when there is a body
+		origin      string
+		host        string // Defaults to localhost:8080
+		wantStatus  int
+		wantBody    string // Text the response body must contain
+	}{
+		{name: "list expenses", method: "GET", path: "/api/expenses", wantStatus: http.StatusOK, wantBody: `"Coffee"`},
+		{name: "list expenses filtered out", method: "GET", path: "/api/expenses?from=2025-04-01", wantStatus: http.StatusOK, wantBody: `[]`},
+		{name: "list expenses with invalid amount", method: "GET", path: "/api/expenses?min=abc", wantStatus: http.StatusBadRequest},
+		{name: "list expenses with invalid date", method: "GET", path: "/api/expenses?from=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "get expense", method: "GET", path: "/api/expenses/1", wantStatus: http.StatusOK, wantBody: `"amount": "4.50"`},
+		{name: "get missing expense", method: "GET", path: "/api/expenses/99", wantStatus: http.StatusNotFound},
+		{name: "get expense with invalid ID", method: "GET", path: "/api/expenses/abc", wantStatus: http.StatusBadRequest},
+
+		{name: "add expense", method: "POST", path: "/api/expenses", body: `{"description": "Lunch", "amount": "12.30", "date": "2025-03-05"}`,
+			wantStatus: http.StatusCreated, wantBody: `"id": 2`},
+		{name: "add expense from the same origin", method: "POST", path: "/api/expenses", body: `{"description": "Lunch", "amount": "12.30"}`,
+			origin: "http://localhost:8080", wantStatus: http.StatusCreated},
+		{name: "add expense from another origin", method: "POST", path: "/api/expenses", body: `{"description": "Lunch", "amount": "12.30"}`,
+			origin: "http://evil.example", wantStatus: http.StatusForbidden},
+		{name: "add expense as a form", method: "POST", path: "/api/expenses", body: `description=Lunch&amount=12.30`,
+			contentType: "application/x-www-form-urlencoded", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "add expense as text", method: "POST", path: "/api/expenses", body: `{"description": "Lunch", "amount": "12.30"}`,
+			contentType: "text/plain", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "add expense with charset", method: "POST", path: "/api/expenses", body: `{"description": "Lunch", "amount": "12.30"}`,
+			contentType: "application/json; charset=utf-8", wantStatus: http.StatusCreated},
+		{name: "add expense with numeric amount", method: "POST", path: "/api/expenses", body: `{"description": "Lunch", "amount": 12.3}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "add expense with three decimals", method: "POST", path: "/api/expenses", body: `{"description": "Lunch", "amount": "12.345"}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "add expense without amount", method: "POST", path: "/api/expenses", body: `{"description": "Lunch"}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "add expense with unknown field", method: "POST", path: "/api/expenses", body: `{"description": "Lunch", "amount": "1", "price": "1"}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "add expense with trailing data", method: "POST", path: "/api/expenses", body: `{"description": "Lunch", "amount": "1"} {}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "add expense without body", method: "POST", path: "/api/expenses", contentType: "application/json",
+			wantStatus: http.StatusBadRequest},
+		{name: "add expense with a body too large", method: "POST", path: "/api/expenses",
+			body:       `{"description": "` + strings.Repeat("a", maxBodySize) + `", "amount": "1"}`,
+			wantStatus: http.StatusRequestEntityTooLarge},
+
+		{name: "update expense", method: "PATCH", path: "/api/expenses/1", body: `{"amount": "7.00", "category": "Drinks"}`,
+			wantStatus: http.StatusOK, wantBody: `"category": "Drinks"`},
+		{name: "update missing expense", method: "PATCH", path: "/api/expenses/99", body: `{"amount": "7.00"}`,
+			wantStatus: http.StatusNotFound},
+		{name: "update expense with numeric amount", method: "PATCH", path: "/api/expenses/1", body: `{"amount": 7}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "update expense from another origin", method: "PATCH", path: "/api/expenses/1", body: `{"amount": "7.00"}`,
+			origin: "null", wantStatus: http.StatusForbidden},
+		{name: "delete expense", method: "DELETE", path: "/api/expenses/1", wantStatus: http.StatusNoContent},
+		{name: "delete missing expense", method: "DELETE", path: "/api/expenses/99", wantStatus: http.StatusNotFound},
+		{name: "delete expense from another origin", method: "DELETE", path: "/api/expenses/1", origin: "https://localhost.evil.example",
+			wantStatus: http.StatusForbidden},
+
+		{name: "summary", method: "GET", path: "/api/summary", wantStatus: http.StatusOK, wantBody: `"totalAmount": "4.50"`},
+		{name: "monthly summary", method: "GET", path: "/api/summary?month=3&year=2025", wantStatus: http.StatusOK, wantBody: `"expenseCount": 1`},
+		{name: "summary of invalid month", method: "GET", path: "/api/summary?month=13", wantStatus: http.StatusBadRequest},
+
+		{name: "list budgets", method: "GET", path: "/api/budgets", wantStatus: http.StatusOK, wantBody: `"category": "Food"`},
+		{name: "set budget", method: "PUT", path: "/api/budgets", body: `{"month": 4, "year": 2025, "amount": "250"}`,
+			wantStatus: http.StatusOK, wantBody: `"amount": "250.00"`},
+		{name: "set budget of invalid month", method: "PUT", path: "/api/budgets", body: `{"month": 13, "year": 2025, "amount": "250"}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "set budget with numeric amount", method: "PUT", path: "/api/budgets", body: `{"month": 4, "year": 2025, "amount": 250}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "delete budget", method: "DELETE", path: "/api/budgets?month=3&year=2025&category=Food", wantStatus: http.StatusNoContent},
+		{name: "delete missing budget", method: "DELETE", path: "/api/budgets?month=4&year=2025", wantStatus: http.StatusNotFound},
+		{name: "delete budget without month", method: "DELETE", path: "/api/budgets", wantStatus: http.StatusBadRequest},
+		{name: "budget report", method: "GET", path: "/api/budgets/report?month=3&year=2025", wantStatus: http.StatusOK, wantBody: `"Food"`},
+
+		{name: "export", method: "GET", path: "/api/export", wantStatus: http.StatusOK, wantBody: "Coffee"},
+		{name: "OpenAPI document", method: "GET", path: "/api/openapi.json", wantStatus: http.StatusOK, wantBody: `"/api/expenses/{id}"`},
+
+		{name: "loopback address", method: "GET", path: "/api/expenses", host: "127.0.0.1:8080", wantStatus: http.StatusOK},
+		{name: "IPv6 loopback address", method: "GET", path: "/api/expenses", host: "[::1]:8080", wantStatus: http.StatusOK},
+		{name: "localhost in capitals", method: "GET", path: "/api/expenses", host: "LOCALHOST:8080", wantStatus: http.StatusOK},
+		{name: "read expenses through a rebound host", method: "GET", path: "/api/expenses", host: "evil.example:8080",
+			wantStatus: http.StatusForbidden},
+		{name: "add expense through a rebound host", method: "POST", path: "/api/expenses", body: `{"description": "Lunch", "amount": "12.30"}`,
+			origin: "http://evil.example:8080", host: "evil.example:8080", wantStatus: http.StatusForbidden},
+		{name: "web interface through a rebound host", method: "GET", path: "/", host: "evil.example:8080", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			contentType := tt.contentType
+			if contentType == "" && tt.body != "" {
+				contentType = "application/json"
+			}
+			if contentType != "" {
+				request.Header.Set("Content-Type", contentType)
+			}
+			if tt.origin != "" {
+				request.Header.Set("Origin", tt.origin)
+			}
+			request.Host = tt.host
+			if request.Host == "" {
+				request.Host = "localhost:8080"
+			}
+
+			recorder := httptest.NewRecorder()
+			server.Handler().ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantBody) {
+				t.Errorf("body does not contain %s: %s", tt.wantBody, recorder.Body)
+			}
+			if tt.wantStatus >= 400 && !strings.Contains(recorder.Body.String(), `"error"`) {
+				t.Errorf("error response has no error message: %s", recorder.Body)
+			}
+		})
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: badRequest("bad"), want: http.StatusBadRequest},
+		{err: fmt.Errorf("expense %w", repository.ErrNotFound), want: http.StatusNotFound},
+		{err: fmt.Errorf("failed to add: %w", service.ErrInvalidInput), want: http.StatusBadRequest},
+		{err: fmt.Errorf("failed to read: %w", repository.ErrBusy), want: http.StatusServiceUnavailable},
+		{err: &http.MaxBytesError{Limit: maxBodySize}, want: http.StatusRequestEntityTooLarge},
+		{err: errors.New("disk on fire"), want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := statusOf(tt.err); got != tt.want {
+			t.Errorf("statusOf(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		addr    string
+		host    string
+		wantErr bool
+	}{
+		{addr: "127.0.0.1:8080", host: "localhost:8080"},
+		{addr: "127.0.0.1:8080", host: "localhost"},
+		{addr: "127.0.0.1:8080", host: "[::1]:8080"},
+		{addr: "127.0.0.1:8080", host: "evil.example:8080", wantErr: true},
+		{addr: "127.0.0.1:8080", host: "192.168.1.5:8080", wantErr: true},
+		{addr: "127.0.0.1:8080", host: "", wantErr: true},
+		{addr: "192.168.1.5:8080", host: "192.168.1.5:8080"},
+		{addr: "192.168.1.5:8080", host: "192.168.1.6:8080", wantErr: true},
+		{addr: "[fe80::1]:8080", host: "[FE80::1]:8080"},
+		{addr: "0.0.0.0:8080", host: "192.168.1.5:8080"},
+		{addr: "[::]:8080", host: "[fe80::1]:8080"},
+		{addr: "0.0.0.0:8080", host: "tracker.example:8080", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		server := &Server{addr: tt.addr}
+		request := httptest.NewRequest("GET", "/api/expenses", nil)
+		request.Host = tt.host
+		if err := server.checkHost(request); (err != nil) != tt.wantErr {
+			t.Errorf("checkHost of %q on %s = %v, want error %v", tt.host, tt.addr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestServeReportsBusyDataFiles(t *testing.T) {
+	server := &Server{}
+	handler := server.serve(route{handle: func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("failed to read expenses: %w", repository.ErrBusy)
+	}})
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("GET", "/api/expenses", nil))
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+	if got := recorder.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want \"1\"", got)
+	}
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// object is a JSON object of the OpenAPI document
+type object = map[string]any
+
+var (
+	timeType   = reflect.TypeFor[time.Time]()
+	moneyType  = reflect.TypeFor[models.Money]()
+	amountType = reflect.TypeFor[Amount]()
+	monthType  = reflect.TypeFor[time.Month]()
+)
+
+// getOpenAPI handles GET /api/openapi.json
+func (s *Server) getOpenAPI(w http.ResponseWriter, r *http.Request) error {
+	writeJSON(w, http.StatusOK, s.OpenAPI())
+	return nil
+}
+
+// OpenAPI returns an OpenAPI 3 document describing the endpoints, with the
+// schemas of request and response bodies derived from their Go types
+func (s *Server) OpenAPI() map[string]any {
+	schemas := object{}
+	paths := object{}
+
+	for _, rt := range s.routes {
+		operation := object{
+			"summary":     rt.summary,
+			"operationId": operationID(rt),
+		}
+
+		if len(rt.params) > 0 {
+			params := make([]object, 0, len(rt.params))
+			for _, p := range rt.params {
+				params = append(params, paramSchema(p))
+			}
+			operation["parameters"] = params
+		}
+
+		if rt.body != nil {
+			operation["requestBody"] = object{
+				"required": true,
+				"content":  object{"application/json": object{"schema": schemaOf(reflect.TypeOf(rt.body), schemas)}},
+			}
+		}
+
+		responses := object{}
+		success := object{"description": http.StatusText(rt.status)}
+		switch {
+		case rt.contentType != "":
+			success["content"] = object{rt.contentType: object{"schema": object{"type": "string"}}}
+		case rt.response != nil:
+			success["content"] = object{"application/json": object{"schema": schemaOf(reflect.TypeOf(rt.response), schemas)}}
+		case rt.status != http.StatusNoContent:
+			success["content"] = object{"application/json": object{"schema": object{"type": "object"}}}
+		}
+		responses[strconv.Itoa(rt.status)] = success
+
+		errorSchema := schemaOf(reflect.TypeFor[ErrorResponse](), schemas)
+		for _, status := range append(slices.Clip(rt.errors), http.StatusForbidden, http.StatusInternalServerError, http.StatusServiceUnavailable) {
+			responses[strconv.Itoa(status)] = object{
+				"description": http.StatusText(status),
+				"content":     object{"application/json": object{"schema": errorSchema}},
+			}
+		}
+		operation["responses"] = responses
+
+		item, _ := paths[rt.path].(object)
+		if item == nil {
+			item = object{}
+			paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = operation
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "Expense Tracker API",
+			"version":     "1.0.0",
+			"description": "Errors are returned as an ErrorResponse with a 4xx or 5xx status. Amounts are decimal strings.",
+		},
+		"paths":      paths,
+		"components": object{"schemas": schemas},
+	}
+}
+
+// operationID names an operation after its method and path, such as
+// getExpensesId for GET /api/expenses/{id}
+func operationID(rt route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(rt.method))
+	for _, segment := range strings.Split(strings.TrimPrefix(rt.path, "/api/"), "/") {
+		segment = strings.Trim(segment, "{}")
+		segment = strings.TrimSuffix(segment, ".json")
+		if segment == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+	}
+	return b.String()
+}
+
+// paramSchema describes a path or query parameter
+func paramSchema(p param) object {
+	schema := object{"type": p.kind}
+	if p.format != "" {
+		schema["format"] = p.format
+	}
+	if p.repeated {
+		schema = object{"type": "array", "items": schema}
+	}
+
+	return object{
+		"name":        p.name,
+		"in":          p.in,
+		"description": p.description,
+		"required":    p.required,
+		"schema":      schema,
+	}
+}
+
+// schemaOf returns the JSON schema of a type. Named structs are added to
+// schemas and referenced.
+func schemaOf(t reflect.Type, schemas object) object {
+	switch t {
+	case timeType:
+		return object{"type": "string", "format": "date-time"}
+	case moneyType, amountType:
+		return object{"type": "string", "format": "decimal", "example": "12.50"}
+	case monthType:
+		return object{"type": "integer", "minimum": 1, "maximum": 12}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), schemas)
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = object{} // Placeholder for recursive types
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return object{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return object{}
+	}
+}
+
+// structSchema describes the JSON object a struct is encoded as. Fields
+// without omitempty are required.
+func structSchema(t reflect.Type, schemas object) object {
+	properties := object{}
+	var required []string
+
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				addFields(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			properties[name] = schemaOf(field.Type, schemas)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
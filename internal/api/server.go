@@ -0,0 +1,264 @@
+// Package api serves the expense tracker services as a JSON REST API and a
+// web front-end built on it
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/repository"
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// maxBodySize limits the size of request bodies
+const maxBodySize = 1 << 20
+
+// Server handles API requests with the expense, budget and export services
+type Server struct {
+	expenseService *service.ExpenseService
+	budgetService  *service.BudgetService
+	exportService  *service.ExportService
+	routes         []route
+	mux            *http.ServeMux
+	addr           string // Address the server listens on, set by Serve
+}
+
+// route describes an endpoint: how it is served and how it is documented in
+// the OpenAPI document
+type route struct {
+	method      string
+	path        string
+	summary     string
+	params      []param
+	body        any    // Zero value of the request body type, nil for none
+	response    any    // Zero value of the response body type, nil for none
+	status      int    // Status of a successful response
+	contentType string // Content type of a successful response, defaults to JSON
+	errors      []int  // Error statuses the endpoint may return besides 403, 500 and 503
+	handle      func(w http.ResponseWriter, r *http.Request) error
+}
+
+// param describes a path or query parameter
+type param struct {
+	name        string
+	in          string // "path" or "query"
+	kind        string // OpenAPI type: string, integer, number or boolean
+	format      string
+	description string
+	required    bool
+	repeated    bool
+}
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// httpError is an error with the status it is reported with
+type httpError struct {
+	status  int
+	message string
+}
+
+// Error returns the message of the error
+func (e *httpError) Error() string {
+	return e.message
+}
+
+// badRequest returns an error reported with status 400
+func badRequest(format string, args ...any) error {
+	return &httpError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+// NewServer creates a new API server
+func NewServer(expenseService *service.ExpenseService, budgetService *service.BudgetService, exportService *service.ExportService) *Server {
+	s := &Server{
+		expenseService: expenseService,
+		budgetService:  budgetService,
+		exportService:  exportService,
+		mux:            http.NewServeMux(),
+	}
+
+	s.routes = s.endpoints()
+	for _, rt := range s.routes {
+		s.mux.HandleFunc(rt.method+" "+rt.path, s.serve(rt))
+	}
+	s.mux.Handle("GET /", webHandler())
+
+	return s
+}
+
+// Handler returns the HTTP handler serving all endpoints and the web front-end
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := s.checkHost(r); err != nil {
+			writeJSON(w, statusOf(err), ErrorResponse{Error: err.Error()})
+			return
+		}
+		s.mux.ServeHTTP(w, r)
+	})
+}
+
+// Serve serves the API on the listener until ctx is cancelled, then waits
+// for requests in progress to finish
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	s.addr = listener.Addr().String()
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		done <- server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve API: %w", err)
+	}
+
+	return <-done
+}
+
+// serve wraps the handler of a route, reporting its errors as JSON
+func (s *Server) serve(rt route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := checkOrigin(r)
+		if err == nil {
+			err = rt.handle(w, r)
+		}
+		if err != nil {
+			status := statusOf(err)
+			message := err.Error()
+			if status == http.StatusServiceUnavailable {
+				// The data files are locked by another process for a moment
+				w.Header().Set("Retry-After", "1")
+			}
+			if status == http.StatusInternalServerError {
+				log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+				message = "internal server error"
+			}
+			writeJSON(w, status, ErrorResponse{Error: message})
+		}
+	}
+}
+
+// statusOf maps an error to the status it is reported with
+func statusOf(err error) int {
+	var httpErr *httpError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr.status
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrBusy):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// checkHost rejects requests whose Host header names neither the listen
+// address nor a loopback name. A page of another site whose name is made to
+// resolve to this machine (DNS rebinding) sends its own name as the Host, so
+// it cannot reach the API or the web front-end. When the server listens on
+// all interfaces, any IP address is allowed as the Host, since only host
+// names can be rebound.
+func (s *Server) checkHost(r *http.Request) error {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+
+	switch host {
+	case "localhost", "127.0.0.1", "::1":
+		return nil
+	}
+	if listenHost, _, err := net.SplitHostPort(s.addr); err == nil {
+		if strings.EqualFold(host, listenHost) {
+			return nil
+		}
+		if ip := net.ParseIP(listenHost); ip != nil && ip.IsUnspecified() && net.ParseIP(host) != nil {
+			return nil
+		}
+	}
+	return &httpError{status: http.StatusForbidden, message: fmt.Sprintf("requests for host %s are not allowed", r.Host)}
+}
+
+// checkOrigin rejects requests that change data when they are sent by a
+// browser from a page of another site, so that other sites cannot use the
+// API of a server on the user's machine. Requests without an Origin header
+// do not come from a browser page and are allowed.
+func checkOrigin(r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host != "" && u.Host == r.Host {
+		return nil
+	}
+	return &httpError{status: http.StatusForbidden, message: fmt.Sprintf("cross-origin request from %s is not allowed", origin)}
+}
+
+// writeJSON writes value as the JSON body of a response
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
+
+// decodeBody decodes a JSON request body into value, rejecting unknown fields
+// and bodies of other content types
+func decodeBody(w http.ResponseWriter, r *http.Request, value any) error {
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return &httpError{status: http.StatusUnsupportedMediaType, message: "request body must be application/json"}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(value); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		if errors.Is(err, io.EOF) {
+			return badRequest("request body is required")
+		}
+		return badRequest("invalid request body: %v", err)
+	}
+	if decoder.More() {
+		return badRequest("invalid request body: unexpected data after the JSON value")
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// webFiles holds the web front-end, served from the root of the server
+//
+//go:embed web
+var webFiles embed.FS
+
+// webHandler serves the web front-end. It only talks to the API of the same
+// server, so it works without network access.
+func webHandler() http.Handler {
+	files, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err) // The directory is embedded at build time
+	}
+	return http.FileServerFS(files)
+}
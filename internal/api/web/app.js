@@ -0,0 +1,289 @@
+// Web front-end of the expense tracker. It only uses the JSON API of the
+// server it is loaded from, so it works offline.
+"use strict";
+
+const state = {
+  month: new Date().getMonth() + 1,
+  year: new Date().getFullYear(),
+  expenses: [],
+  editing: null, // Expense being edited, null when adding
+  currency: "",
+};
+
+const $ = (id) => document.getElementById(id);
+
+// api sends a request to the API and returns the decoded JSON body, throwing
+// the error message of failed requests
+async function api(method, path, body) {
+  const options = { method, headers: {} };
+  if (body !== undefined) {
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+
+  const response = await fetch(path, options);
+  if (response.status === 204) {
+    return null;
+  }
+  const data = await response.json().catch(() => null);
+  if (!response.ok) {
+    const error = new Error(data && data.error ? data.error : response.statusText);
+    error.status = response.status;
+    throw error;
+  }
+  return data;
+}
+
+function showError(error) {
+  $("error").textContent = error ? error.message : "";
+  $("error").hidden = !error;
+}
+
+function pad(n) {
+  return String(n).padStart(2, "0");
+}
+
+// monthRange returns the first and last day of the selected month
+function monthRange() {
+  const last = new Date(state.year, state.month, 0).getDate();
+  const prefix = `${state.year}-${pad(state.month)}`;
+  return { from: `${prefix}-01`, to: `${prefix}-${pad(last)}` };
+}
+
+function formatMoney(amount, currency) {
+  return currency ? `${amount} ${currency}` : amount;
+}
+
+function element(tag, attributes, ...children) {
+  const node = document.createElement(tag);
+  Object.assign(node, attributes);
+  node.append(...children);
+  return node;
+}
+
+// bar renders a labelled progress bar; value and max are decimal strings
+function bar(label, detail, value, max, className) {
+  const ratio = Number(max) > 0 ? Number(value) / Number(max) : 0;
+  const fill = element("div", { className: className || "" });
+  fill.style.width = `${Math.min(ratio, 1) * 100}%`;
+  return element("div", { className: "bar-row" },
+    element("div", { className: "bar-label" }, element("span", {}, label), element("span", { className: "muted" }, detail)),
+    element("div", { className: "bar" }, fill));
+}
+
+async function loadSummary() {
+  const summary = await api("GET", `/api/summary?month=${state.month}&year=${state.year}`);
+  state.currency = summary.currency || "";
+  $("total").textContent = formatMoney(summary.totalAmount, state.currency);
+  $("expense-count").textContent = `${summary.expenseCount} expense${summary.expenseCount === 1 ? "" : "s"}`;
+
+  const totals = Object.entries(summary.categoryTotals || {}).sort((a, b) => Number(b[1]) - Number(a[1]));
+  const chart = $("chart");
+  chart.replaceChildren();
+  if (totals.length === 0) {
+    chart.append(element("p", { className: "muted" }, "No expenses this month"));
+    return;
+  }
+  const largest = totals[0][1];
+  for (const [category, amount] of totals) {
+    const share = Number(summary.totalAmount) > 0 ? Math.round((Number(amount) / Number(summary.totalAmount)) * 100) : 0;
+    chart.append(bar(category || "(uncategorized)", `${formatMoney(amount, state.currency)} · ${share}%`, amount, largest));
+  }
+}
+
+async function loadBudgets() {
+  const container = $("budgets");
+  container.replaceChildren();
+
+  let report;
+  try {
+    report = await api("GET", `/api/budgets/report?month=${state.month}&year=${state.year}`);
+  } catch (error) {
+    if (error.status !== 404) {
+      throw error;
+    }
+    container.append(element("p", { className: "muted" }, "No budgets set for this month"));
+    return;
+  }
+
+  const statuses = [];
+  if (report.overall) {
+    statuses.push(["Overall", report.overall]);
+  }
+  for (const status of report.categories || []) {
+    statuses.push([status.category, status]);
+  }
+  for (const [label, status] of statuses) {
+    const ratio = Number(status.spent) / Number(status.budget);
+    const className = status.exceeded ? "exceeded" : ratio >= 0.8 ? "warning" : "";
+    const detail = status.exceeded
+      ? `${formatMoney(status.spent, state.currency)} of ${status.budget}, over by ${String(status.remaining).replace("-", "")}`
+      : `${formatMoney(status.spent, state.currency)} of ${status.budget}, ${status.remaining} left`;
+    container.append(bar(label, detail, status.spent, status.budget, className));
+  }
+}
+
+async function loadCategories() {
+  const summary = await api("GET", "/api/summary");
+  const list = $("categories");
+  list.replaceChildren(...Object.keys(summary.categoryTotals || {}).sort().map((name) => element("option", { value: name })));
+}
+
+async function loadExpenses() {
+  const { from, to } = monthRange();
+  const params = new URLSearchParams({ from, to, sort: "date", desc: "true" });
+  const search = $("filter").value.trim();
+  if (search) {
+    params.set("search", search);
+  }
+  state.expenses = await api("GET", `/api/expenses?${params}`);
+  $("export").href = `/api/export?${params}`;
+  renderExpenses();
+}
+
+function renderExpenses() {
+  const rows = $("expense-rows");
+  rows.replaceChildren();
+  if (state.expenses.length === 0) {
+    rows.append(element("tr", {}, element("td", { colSpan: 6, className: "muted" }, "No expenses")));
+    return;
+  }
+
+  for (const expense of state.expenses) {
+    const edit = element("button", { type: "button", title: "Edit" }, "Edit");
+    edit.addEventListener("click", () => startEditing(expense));
+    const remove = element("button", { type: "button", title: "Move to the trash" }, "Delete");
+    remove.addEventListener("click", () => deleteExpense(expense));
+
+    const row = element("tr", { className: state.editing && state.editing.id === expense.id ? "editing" : "" },
+      element("td", {}, expense.date.slice(0, 10)),
+      element("td", {}, expense.description),
+      element("td", {}, expense.category || ""),
+      element("td", {}, (expense.tags || []).join(", ")),
+      element("td", { className: "amount" }, formatMoney(expense.amount, expense.currency)),
+      element("td", { className: "row-actions" }, edit, " ", remove));
+    rows.append(row);
+  }
+}
+
+async function refresh() {
+  $("month-label").textContent = new Date(state.year, state.month - 1, 1)
+    .toLocaleDateString(undefined, { month: "long", year: "numeric" });
+  try {
+    await Promise.all([loadSummary(), loadBudgets(), loadExpenses(), loadCategories()]);
+    showError(null);
+  } catch (error) {
+    showError(error);
+  }
+}
+
+function switchMonth(delta) {
+  const date = new Date(state.year, state.month - 1 + delta, 1);
+  state.month = date.getMonth() + 1;
+  state.year = date.getFullYear();
+  refresh();
+}
+
+function startEditing(expense) {
+  state.editing = expense;
+  const form = $("expense-form");
+  form.description.value = expense.description;
+  form.amount.value = expense.amount;
+  form.currency.value = expense.currency || "";
+  form.category.value = expense.category || "";
+  form.tags.value = (expense.tags || []).join(", ");
+  form.merchant.value = expense.merchant || "";
+  form.date.value = expense.date.slice(0, 10);
+  $("form-title").textContent = `Edit expense ${expense.id}`;
+  $("submit").textContent = "Save";
+  $("cancel").hidden = false;
+  renderExpenses();
+  form.description.focus();
+}
+
+function stopEditing() {
+  state.editing = null;
+  const form = $("expense-form");
+  form.reset();
+  $("form-title").textContent = "Add expense";
+  $("submit").textContent = "Add";
+  $("cancel").hidden = true;
+  renderExpenses();
+}
+
+// readForm returns the expense fields entered in the form
+function readForm(form) {
+  const fields = {
+    description: form.description.value.trim(),
+    amount: form.amount.value.trim().replace(",", "."),
+    currency: form.currency.value.trim().toUpperCase(),
+    category: form.category.value.trim(),
+    tags: form.tags.value.split(",").map((tag) => tag.trim()).filter((tag) => tag !== ""),
+    merchant: form.merchant.value.trim(),
+  };
+  if (form.date.value) {
+    fields.date = form.date.value;
+  }
+  return fields;
+}
+
+async function submitForm(event) {
+  event.preventDefault();
+  const fields = readForm(event.target);
+  if (!fields.currency) {
+    delete fields.currency;
+  }
+
+  try {
+    if (state.editing) {
+      // Keep the time of day unless the date was changed
+      if (fields.date === state.editing.date.slice(0, 10)) {
+        delete fields.date;
+      }
+      await api("PATCH", `/api/expenses/${state.editing.id}`, fields);
+      stopEditing();
+    } else {
+      await api("POST", "/api/expenses", fields);
+      event.target.reset();
+    }
+  } catch (error) {
+    showError(error);
+    return;
+  }
+  refresh();
+}
+
+async function deleteExpense(expense) {
+  if (!confirm(`Move "${expense.description}" to the trash?`)) {
+    return;
+  }
+  try {
+    await api("DELETE", `/api/expenses/${expense.id}`);
+    if (state.editing && state.editing.id === expense.id) {
+      stopEditing();
+    }
+  } catch (error) {
+    showError(error);
+    return;
+  }
+  refresh();
+}
+
+let filterTimer;
+
+$("prev-month").addEventListener("click", () => switchMonth(-1));
+$("next-month").addEventListener("click", () => switchMonth(1));
+$("this-month").addEventListener("click", () => {
+  const now = new Date();
+  state.month = now.getMonth() + 1;
+  state.year = now.getFullYear();
+  refresh();
+});
+$("expense-form").addEventListener("submit", submitForm);
+$("cancel").addEventListener("click", stopEditing);
+$("filter").addEventListener("input", () => {
+  clearTimeout(filterTimer);
+  filterTimer = setTimeout(() => loadExpenses().catch(showError), 200);
+});
+
+refresh();
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// handleCategoryCommand handles the 'category' command
+func (c *CLI) handleCategoryCommand(args []string) error {
+	if len(args) == 0 || args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker category list")
+		fmt.Println("       expense-tracker category add --name CATEGORY")
+		fmt.Println("       expense-tracker category rename --from CATEGORY --to CATEGORY")
+		fmt.Println("       expense-tracker category merge --into CATEGORY --from CATEGORY [--from CATEGORY ...]")
+		fmt.Println("       expense-tracker category delete --name CATEGORY")
+		fmt.Println("       expense-tracker category strict [on|off]")
+		fmt.Println("\nSubcategories are written as paths such as food/groceries. Categories are matched")
+		fmt.Println("ignoring case. In strict mode new expenses, budgets and recurring expenses must use a")
+		fmt.Println("category from the catalog.")
+		return nil
+	}
+
+	switch args[0] {
+	case "list":
+		return c.listCategories(args[1:])
+	case "add":
+		return c.addCategory(args[1:])
+	case "rename":
+		return c.renameCategory(args[1:])
+	case "merge":
+		return c.mergeCategories(args[1:])
+	case "delete":
+		return c.deleteCategory(args[1:])
+	case "strict":
+		return c.strictCategories(args[1:])
+	default:
+		return fmt.Errorf("unknown category command: %s", args[0])
+	}
+}
+
+// listCategories prints the category tree with the number of expenses in each
+func (c *CLI) listCategories(args []string) error {
+	listCmd := flag.NewFlagSet("category list", flag.ExitOnError)
+	c.outputFlag(listCmd)
+
+	if err := listCmd.Parse(args); err != nil {
+		return err
+	}
+
+	categories, err := c.categoryService.GetCategories()
+	if err != nil {
+		return err
+	}
+
+	if c.structured() {
+		rows := make([][]string, 0, len(categories))
+		for _, category := range categories {
+			rows = append(rows, []string{category.Name, strconv.Itoa(category.Expenses), strconv.FormatBool(category.InCatalog)})
+		}
+		return c.render(categories, []string{"Category", "Expenses", "InCatalog"}, rows)
+	}
+
+	if len(categories) == 0 {
+		fmt.Println("No categories found")
+		return nil
+	}
+
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "Category\tExpenses\t")
+	for _, category := range categories {
+		note := ""
+		if !category.InCatalog {
+			note = "(not in catalog)"
+		}
+		fmt.Fprintf(table, "%s\t%d\t%s\n", indentCategory(category.Name), category.Expenses, note)
+	}
+
+	return table.Flush()
+}
+
+// indentCategory returns the last level of a category path, indented by its depth
+func indentCategory(name string) string {
+	return strings.Repeat("  ", models.CategoryDepth(name)) + name[strings.LastIndex(name, models.CategorySeparator)+1:]
+}
+
+// addCategory adds a category to the catalog
+func (c *CLI) addCategory(args []string) error {
+	addCmd := flag.NewFlagSet("category add", flag.ExitOnError)
+	name := addCmd.String("name", "", "Category to add, e.g. food/groceries")
+
+	if err := addCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *name == "" {
+		return fmt.Errorf("category name is required")
+	}
+
+	added, err := c.categoryService.AddCategory(*name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Category %s added\n", added)
+	return nil
+}
+
+// renameCategory renames a category on every expense and budget using it
+func (c *CLI) renameCategory(args []string) error {
+	renameCmd := flag.NewFlagSet("category rename", flag.ExitOnError)
+	from := renameCmd.String("from", "", "Category to rename")
+	to := renameCmd.String("to", "", "New name of the category")
+
+	if err := renameCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *from == "" || *to == "" {
+		return fmt.Errorf("both --from and --to are required")
+	}
+
+	changed, err := c.categoryService.RenameCategory(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Renamed category on %d expenses\n", changed)
+	return nil
+}
+
+// mergeCategories moves several categories into one
+func (c *CLI) mergeCategories(args []string) error {
+	mergeCmd := flag.NewFlagSet("category merge", flag.ExitOnError)
+	into := mergeCmd.String("into", "", "Category to keep")
+	var from stringList
+	mergeCmd.Var(&from, "from", "Category to merge into the kept one; may be repeated")
+
+	if err := mergeCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *into == "" || len(from) == 0 {
+		return fmt.Errorf("--into and at least one --from are required")
+	}
+
+	changed, err := c.categoryService.MergeCategories(*into, from)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Merged categories on %d expenses\n", changed)
+	return nil
+}
+
+// deleteCategory removes an unused category from the catalog
+func (c *CLI) deleteCategory(args []string) error {
+	deleteCmd := flag.NewFlagSet("category delete", flag.ExitOnError)
+	name := deleteCmd.String("name", "", "Category to delete, with its subcategories")
+
+	if err := deleteCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *name == "" {
+		return fmt.Errorf("category name is required")
+	}
+
+	if err := c.categoryService.DeleteCategory(*name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Category %s deleted\n", *name)
+	return nil
+}
+
+// strictCategories shows or changes whether unknown categories are rejected
+func (c *CLI) strictCategories(args []string) error {
+	if len(args) == 0 {
+		catalog, err := c.categoryService.GetCatalog()
+		if err != nil {
+			return err
+		}
+		if catalog.Strict {
+			fmt.Println("Strict categories are on: categories must be in the catalog")
+		} else {
+			fmt.Println("Strict categories are off: any category is accepted")
+		}
+		return nil
+	}
+
+	var strict bool
+	switch args[0] {
+	case "on":
+		strict = true
+	case "off":
+		strict = false
+	default:
+		return fmt.Errorf("expected on or off, got %q", args[0])
+	}
+
+	if err := c.categoryService.SetStrict(strict); err != nil {
+		return err
+	}
+
+	if !strict {
+		fmt.Println("Strict categories turned off")
+		return nil
+	}
+	fmt.Println("Strict categories turned on")
+
+	// Existing expenses are kept as they are, but point out the ones that
+	// could not be entered now
+	categories, err := c.categoryService.GetCategories()
+	if err != nil {
+		return err
+	}
+	var unknown []string
+	for _, category := range categories {
+		if !category.InCatalog && category.Expenses > 0 {
+			unknown = append(unknown, category.Name)
+		}
+	}
+	if len(unknown) > 0 {
+		fmt.Printf("Note: existing expenses use categories that are not in the catalog: %s\n", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// printCategoryTotals prints the total spent per category as a tree, with
+// parent categories including their subcategories
+func printCategoryTotals(summary models.ExpenseSummary) error {
+	if len(summary.CategoryTotals) == 0 {
+		return nil
+	}
+
+	categories := slices.Collect(maps.Keys(summary.CategoryTotals))
+	for parent := range summary.CategoryRollups {
+		if _, ok := summary.CategoryTotals[parent]; !ok {
+			categories = append(categories, parent)
+		}
+	}
+	slices.SortFunc(categories, models.CompareCategories)
+
+	fmt.Println()
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "Category\tAmount")
+	for _, category := range categories {
+		amount, ok := summary.CategoryRollups[category]
+		if !ok {
+			amount = summary.CategoryTotals[category]
+		}
+		fmt.Fprintf(table, "%s\t%s\n", indentCategory(category), amount.Format(summary.Currency))
+	}
+
+	return table.Flush()
+}
@@ -1,27 +1,46 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
 	"github.com/Businge931/expense-tracker/internal/service"
 )
 
 // CLI represents the command-line interface for the expense tracker
 type CLI struct {
-	expenseService *service.ExpenseService
-	budgetService  *service.BudgetService
-	exportService  *service.ExportService
+	expenseService   *service.ExpenseService
+	budgetService    *service.BudgetService
+	exportService    *service.ExportService
+	importService    *service.ImportService
+	undoService      *service.UndoService
+	recurringService *service.RecurringService
+	categoryService  *service.CategoryService
+	ruleService      *service.CategoryRuleService
+	output           OutputFormat
 }
 
-// NewCLI creates a new CLI instance
-func NewCLI(expenseService *service.ExpenseService, budgetService *service.BudgetService, exportService *service.ExportService) *CLI {
+// NewCLI creates a new CLI instance that prints results in the given format
+func NewCLI(expenseService *service.ExpenseService, budgetService *service.BudgetService, exportService *service.ExportService, importService *service.ImportService, undoService *service.UndoService, recurringService *service.RecurringService, categoryService *service.CategoryService, ruleService *service.CategoryRuleService, output OutputFormat) *CLI {
 	return &CLI{
-		expenseService: expenseService,
-		budgetService:  budgetService,
-		exportService:  exportService,
+		expenseService:   expenseService,
+		budgetService:    budgetService,
+		exportService:    exportService,
+		importService:    importService,
+		undoService:      undoService,
+		recurringService: recurringService,
+		categoryService:  categoryService,
+		ruleService:      ruleService,
+		output:           output,
 	}
 }
 
@@ -34,11 +53,22 @@ func (c *CLI) Run(args []string) error {
 
 	command := args[0]
 
+	// Create recurring expenses that fell due since the last run, so that
+	// every command, including serve and tui when they start, sees them. Undo
+	// and redo must act on the user's last change, so they do not trigger it.
+	switch command {
+	case "help", "undo", "redo", "recurring":
+	default:
+		c.runRecurring(true)
+	}
+
 	switch command {
 	case "add":
 		return c.handleAddCommand(args[1:])
 	case "list":
 		return c.handleListCommand(args[1:])
+	case "update":
+		return c.handleUpdateCommand(args[1:])
 	case "delete":
 		return c.handleDeleteCommand(args[1:])
 	case "summary":
@@ -47,6 +77,30 @@ func (c *CLI) Run(args []string) error {
 		return c.handleBudgetCommand(args[1:])
 	case "export":
 		return c.handleExportCommand(args[1:])
+	case "import":
+		return c.handleImportCommand(args[1:])
+	case "migrate":
+		return c.handleMigrateCommand(args[1:])
+	case "category":
+		return c.handleCategoryCommand(args[1:])
+	case "rules":
+		return c.handleRulesCommand(args[1:])
+	case "suggest":
+		return c.handleSuggestCommand(args[1:])
+	case "serve":
+		return c.handleServeCommand(args[1:])
+	case "tui":
+		return c.handleTUICommand(args[1:])
+	case "tag":
+		return c.handleTagCommand(args[1:])
+	case "trash":
+		return c.handleTrashCommand(args[1:])
+	case "recurring":
+		return c.handleRecurringCommand(args[1:])
+	case "undo":
+		return c.handleUndoCommand(args[1:])
+	case "redo":
+		return c.handleRedoCommand(args[1:])
 	case "help":
 		c.printUsage()
 		return nil
@@ -59,15 +113,37 @@ func (c *CLI) Run(args []string) error {
 func (c *CLI) printUsage() {
 	fmt.Println("Expense Tracker - A simple tool to track your expenses")
 	fmt.Println("\nUsage:")
-	fmt.Println("  expense-tracker [command] [options]")
+	fmt.Println("  expense-tracker [global options] [command] [options]")
 	fmt.Println("\nCommands:")
+	fmt.Println("  tui         Browse and edit expenses in a full-screen terminal interface")
 	fmt.Println("  add         Add a new expense")
-	fmt.Println("  list        List all expenses")
-	fmt.Println("  delete      Delete an expense")
+	fmt.Println("  list        List, filter and sort expenses")
+	fmt.Println("  update      Update an existing expense")
+	fmt.Println("  delete      Move an expense to the trash")
+	fmt.Println("  category    Manage the category catalog")
+	fmt.Println("  rules       Manage rules that categorize expenses automatically")
+	fmt.Println("  suggest     Suggest a category learned from past expenses")
+	fmt.Println("  tag         List, rename or merge tags")
+	fmt.Println("  trash       List, restore or permanently remove deleted expenses")
+	fmt.Println("  recurring   Manage recurring expenses such as rent and subscriptions")
 	fmt.Println("  summary     Show a summary of expenses")
-	fmt.Println("  budget      Set or check budget for a month")
+	fmt.Println("  budget      Set, list or delete monthly budgets")
 	fmt.Println("  export      Export expenses to a CSV file")
+	fmt.Println("  import      Import expenses from a CSV file")
+	fmt.Println("  migrate     Copy all expenses from one store to another")
+	fmt.Println("  serve       Serve a JSON API and web interface for expenses and budgets")
+	fmt.Println("  undo        Undo the last changes, or list what can be undone")
+	fmt.Println("  redo        Redo changes that were undone")
 	fmt.Println("  help        Show this help message")
+	fmt.Println("\nGlobal options:")
+	fmt.Println("  --base-currency CODE  Currency to report summaries and budgets in (default USD,")
+	fmt.Println("                        or $EXPENSE_TRACKER_BASE_CURRENCY)")
+	fmt.Println("  --rates FILE          Exchange rate file in ECB XML or Date,Currency,Rate CSV format")
+	fmt.Println("                        (defaults to data/rates.xml or data/rates.csv)")
+	fmt.Println("  --store STORE         Expense store: json:DIRECTORY (default json:data), sqlite:FILE or")
+	fmt.Println("                        events:DIRECTORY, or $EXPENSE_TRACKER_STORE")
+	fmt.Println("  --output FORMAT       Output format for add, list, summary and budget: table (default),")
+	fmt.Println("                        json, csv or yaml. May also be given after the command")
 	fmt.Println("\nOptions:")
 	fmt.Println("  Run 'expense-tracker [command] --help' for command-specific help")
 }
@@ -75,14 +151,29 @@ func (c *CLI) printUsage() {
 // handleAddCommand handles the 'add' command
 func (c *CLI) handleAddCommand(args []string) error {
 	if len(args) > 0 && args[0] == "--help" {
-		fmt.Println("Usage: expense-tracker add --description DESCRIPTION --amount AMOUNT [--category CATEGORY]")
+		fmt.Println("Usage: expense-tracker add --description DESCRIPTION --amount AMOUNT [--currency CODE] [--category CATEGORY]")
+		fmt.Println("                           [--tag TAG ...] [--merchant MERCHANT] [--date DATE] [--allow-future]")
+		fmt.Println("                           [--min-confidence P | --no-suggest]")
+		fmt.Println("\nDATE is YYYY-MM-DD, today, yesterday or a relative offset such as -3d or -2w (defaults to now)")
+		fmt.Println("\nWithout --category, the category is set by the first matching category rule or, if none")
+		fmt.Println("matches, by the category suggested from past expenses when its confidence is at least P.")
 		return nil
 	}
 
 	addCmd := flag.NewFlagSet("add", flag.ExitOnError)
 	description := addCmd.String("description", "", "Description of the expense")
-	amount := addCmd.Float64("amount", 0, "Amount spent")
+	var amount models.Money
+	addCmd.Var(&amount, "amount", "Amount spent")
+	currency := addCmd.String("currency", "", "Currency of the amount, e.g. EUR (defaults to the base currency)")
 	category := addCmd.String("category", "", "Category of the expense (optional)")
+	var tags stringList
+	addCmd.Var(&tags, "tag", "Tag for the expense (optional); may be repeated")
+	merchant := addCmd.String("merchant", "", "Who was paid (optional)")
+	date := addCmd.String("date", "", "Date of the expense (optional, defaults to now)")
+	allowFuture := addCmd.Bool("allow-future", false, "Allow a date after today")
+	minConfidence := addCmd.Float64("min-confidence", service.DefaultMinConfidence, "Confidence a suggested category needs to be used, between 0 and 1")
+	noSuggest := addCmd.Bool("no-suggest", false, "Never fill in the category from suggestions")
+	c.outputFlag(addCmd)
 
 	if err := addCmd.Parse(args); err != nil {
 		return err
@@ -91,48 +182,283 @@ func (c *CLI) handleAddCommand(args []string) error {
 	if *description == "" {
 		return fmt.Errorf("description is required")
 	}
-	if *amount <= 0 {
+	if amount <= 0 {
 		return fmt.Errorf("amount must be greater than zero")
 	}
 
-	id, err := c.expenseService.AddExpense(*description, *amount, *category)
+	input := service.NewExpense{
+		Description: *description,
+		Amount:      amount,
+		Currency:    *currency,
+		Category:    *category,
+		Tags:        tags,
+		Merchant:    *merchant,
+		AllowFuture: *allowFuture,
+	}
+	if !*noSuggest {
+		input.SuggestConfidence = minConfidence
+	}
+	if *date != "" {
+		parsed, err := parseDate(*date)
+		if err != nil {
+			return err
+		}
+		input.Date = parsed
+	}
+
+	added, err := c.expenseService.AddExpense(input)
 	if err != nil {
 		return err
 	}
+	id := added.ID
+
+	if c.structured() {
+		result := struct {
+			ID int `json:"id"`
+		}{ID: id}
+		return c.render(result, []string{"ID"}, [][]string{{strconv.Itoa(id)}})
+	}
 
 	fmt.Printf("Expense added successfully (ID: %d)\n", id)
+	switch {
+	case added.Rule != nil:
+		fmt.Printf("Categorized as %s by rule %d (%s)\n", added.Rule.Category, added.Rule.ID, added.Rule.Conditions())
+	case added.Suggestion != nil:
+		fmt.Printf("Categorized as %s from past expenses (%s confidence)\n", added.Suggestion.Category, confidenceLabel(added.Suggestion.Confidence))
+	}
 	return nil
 }
 
 // handleListCommand handles the 'list' command
 func (c *CLI) handleListCommand(args []string) error {
 	if len(args) > 0 && args[0] == "--help" {
-		fmt.Println("Usage: expense-tracker list")
+		fmt.Println("Usage: expense-tracker list [--from DATE] [--to DATE] [--category CATEGORY] [--tag TAG ...] [--min AMOUNT] [--max AMOUNT]")
+		fmt.Println("                            [--search TEXT] [--sort id|date|amount|description|category] [--desc]")
+		fmt.Println("                            [--limit N] [--offset N] [--as-of DATE]")
+		fmt.Println("\n--as-of shows the expenses as they were recorded at the end of DATE, or at an exact")
+		fmt.Println("RFC 3339 time such as 2025-05-31T18:00:00Z, including ones deleted or changed since.")
+		fmt.Println("It needs an events store (--store events:DIRECTORY).")
 		return nil
 	}
 
-	expenses, err := c.expenseService.GetAllExpenses()
-	if err != nil {
+	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
+	from := listCmd.String("from", "", "Only expenses on or after this date")
+	to := listCmd.String("to", "", "Only expenses on or before this date")
+	category := listCmd.String("category", "", "Only expenses in this category")
+	var tags stringList
+	listCmd.Var(&tags, "tag", "Only expenses with this tag; may be repeated to require several")
+	var minAmount, maxAmount models.Money
+	listCmd.Var(&minAmount, "min", "Only expenses of at least this amount")
+	listCmd.Var(&maxAmount, "max", "Only expenses of at most this amount")
+	search := listCmd.String("search", "", "Only expenses whose description contains this text")
+	sortBy := listCmd.String("sort", "id", "Field to sort by: id, date, amount, description or category")
+	desc := listCmd.Bool("desc", false, "Sort in descending order")
+	limit := listCmd.Int("limit", 0, "Maximum number of expenses to show")
+	offset := listCmd.Int("offset", 0, "Number of expenses to skip")
+	asOf := listCmd.String("as-of", "", "Show the expenses as they stood at the end of this date")
+	c.outputFlag(listCmd)
+
+	if err := listCmd.Parse(args); err != nil {
 		return err
 	}
 
+	query := repository.Query{
+		Category: *category,
+		Tags:     tags,
+		Search:   *search,
+		SortBy:   repository.SortField(strings.ToLower(*sortBy)),
+		Desc:     *desc,
+		Limit:    *limit,
+		Offset:   *offset,
+	}
+	listCmd.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "min":
+			query.Min = &minAmount
+		case "max":
+			query.Max = &maxAmount
+		}
+	})
+	if *from != "" {
+		date, err := parseDate(*from)
+		if err != nil {
+			return err
+		}
+		query.From = date
+	}
+	if *to != "" {
+		date, err := parseDate(*to)
+		if err != nil {
+			return err
+		}
+		// Include the whole of the last day
+		query.To = date.AddDate(0, 0, 1)
+	}
+
+	var expenses []models.Expense
+	if *asOf != "" {
+		// A full timestamp selects an exact moment; a date includes every
+		// change made during that day
+		asOfTime, err := time.Parse(time.RFC3339, *asOf)
+		if err != nil {
+			date, dateErr := parseDate(*asOf)
+			if dateErr != nil {
+				return dateErr
+			}
+			asOfTime = date.AddDate(0, 0, 1)
+		}
+		if expenses, err = c.expenseService.FindExpensesAsOf(asOfTime, query); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if expenses, err = c.expenseService.FindExpenses(query); err != nil {
+			return err
+		}
+	}
+
+	if c.structured() {
+		if expenses == nil {
+			expenses = []models.Expense{}
+		}
+		return c.render(expenses, service.ExpenseCSVHeader, service.ExpenseCSVRecords(expenses))
+	}
+
 	if len(expenses) == 0 {
 		fmt.Println("No expenses found")
 		return nil
 	}
 
-	fmt.Println("ID\tDate\t\tDescription\tAmount")
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "ID\tDate\tDescription\tCategory\tAmount\tTags")
 	for _, expense := range expenses {
-		fmt.Printf("%d\t%s\t%s\t$%.2f\n",
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\n",
 			expense.ID,
 			expense.Date.Format("2006-01-02"),
 			expense.Description,
-			expense.Amount)
+			expense.Category,
+			expense.Amount.Format(expense.CurrencyCode()),
+			strings.Join(expense.Tags, ", "))
+	}
+
+	return table.Flush()
+}
+
+// handleUpdateCommand handles the 'update' command
+func (c *CLI) handleUpdateCommand(args []string) error {
+	if len(args) > 0 && args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker update --id ID [--description DESCRIPTION] [--amount AMOUNT] [--currency CODE] [--category CATEGORY]")
+		fmt.Println("                              [--tag TAG ... | --clear-tags] [--merchant MERCHANT] [--date DATE] [--allow-future]")
+		fmt.Println("\n--tag replaces all tags of the expense with the ones given")
+		return nil
+	}
+
+	updateCmd := flag.NewFlagSet("update", flag.ExitOnError)
+	id := updateCmd.Int("id", 0, "ID of the expense to update")
+	description := updateCmd.String("description", "", "New description of the expense")
+	var amount models.Money
+	updateCmd.Var(&amount, "amount", "New amount spent")
+	currency := updateCmd.String("currency", "", "New currency of the amount")
+	category := updateCmd.String("category", "", "New category of the expense")
+	var tags stringList
+	updateCmd.Var(&tags, "tag", "New tag of the expense, replacing the old ones; may be repeated")
+	clearTags := updateCmd.Bool("clear-tags", false, "Remove all tags from the expense")
+	merchant := updateCmd.String("merchant", "", "New merchant of the expense")
+	date := updateCmd.String("date", "", "New date of the expense")
+	allowFuture := updateCmd.Bool("allow-future", false, "Allow a date after today")
+
+	if err := updateCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *id <= 0 {
+		return fmt.Errorf("valid expense ID is required")
+	}
+
+	// Only change the fields that were supplied on the command line
+	var update service.ExpenseUpdate
+	var dateErr error
+	updateCmd.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "description":
+			update.Description = description
+		case "amount":
+			update.Amount = &amount
+		case "currency":
+			update.Currency = currency
+		case "category":
+			update.Category = category
+		case "merchant":
+			update.Merchant = merchant
+		case "date":
+			parsed, err := parseDate(*date)
+			if err != nil {
+				dateErr = err
+				return
+			}
+			update.Date = &parsed
+		}
+	})
+	if dateErr != nil {
+		return dateErr
+	}
+	if *clearTags && len(tags) > 0 {
+		return fmt.Errorf("--tag and --clear-tags cannot be used together")
+	}
+	if *clearTags || len(tags) > 0 {
+		newTags := []string(tags)
+		update.Tags = &newTags
+	}
+
+	if update.Description == nil && update.Amount == nil && update.Currency == nil && update.Category == nil && update.Tags == nil && update.Merchant == nil && update.Date == nil {
+		return fmt.Errorf("at least one field to update is required")
+	}
+	update.AllowFuture = *allowFuture
+
+	if _, err := c.expenseService.UpdateExpense(*id, update); err != nil {
+		return err
 	}
 
+	fmt.Println("Expense updated successfully")
 	return nil
 }
 
+// parseDate parses a date in the local time zone. It accepts YYYY-MM-DD,
+// "today", "yesterday", "tomorrow" and relative offsets such as -3d, -2w or -1m.
+func parseDate(value string) (time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	if len(value) > 1 && (value[0] == '-' || value[0] == '+') {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil {
+			switch value[len(value)-1] {
+			case 'd':
+				return today.AddDate(0, 0, n), nil
+			case 'w':
+				return today.AddDate(0, 0, 7*n), nil
+			case 'm':
+				return today.AddDate(0, n, 0), nil
+			}
+		}
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD, today, yesterday or an offset like -3d", value)
+	}
+	return date, nil
+}
+
 // handleDeleteCommand handles the 'delete' command
 func (c *CLI) handleDeleteCommand(args []string) error {
 	if len(args) > 0 && args[0] == "--help" {
@@ -155,90 +481,299 @@ func (c *CLI) handleDeleteCommand(args []string) error {
 		return err
 	}
 
-	fmt.Println("Expense deleted successfully")
+	fmt.Printf("Expense moved to trash (restore it with 'trash restore --id %d')\n", *id)
 	return nil
 }
 
 // handleSummaryCommand handles the 'summary' command
 func (c *CLI) handleSummaryCommand(args []string) error {
 	if len(args) > 0 && args[0] == "--help" {
-		fmt.Println("Usage: expense-tracker summary [--month MONTH]")
+		fmt.Println("Usage: expense-tracker summary [--month MONTH [--year YEAR] | --period YYYY-MM]")
 		return nil
 	}
 
 	summaryCmd := flag.NewFlagSet("summary", flag.ExitOnError)
 	month := summaryCmd.Int("month", 0, "Month to show summary for (1-12)")
+	year := summaryCmd.Int("year", 0, "Year of the month (defaults to the current year)")
+	period := summaryCmd.String("period", "", "Month to show summary for as YYYY-MM")
+	c.outputFlag(summaryCmd)
 
 	if err := summaryCmd.Parse(args); err != nil {
 		return err
 	}
 
-	var summary interface{}
-	var err error
+	if *month > 0 || *year > 0 || *period != "" {
+		m, y, err := resolvePeriod(*month, *year, *period)
+		if err != nil {
+			return err
+		}
 
-	if *month > 0 {
-		// Check if there's a budget for this month
-		budget, budgetErr := c.budgetService.GetBudget(*month, 0)
-		monthlySummary, err := c.expenseService.GetMonthlySummary(*month)
+		monthlySummary, err := c.expenseService.GetMonthlySummary(m, y)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Total expenses for %s: $%.2f\n", time.Month(*month).String(), monthlySummary.TotalAmount)
+		// Include budget information if any budgets are set for this month
+		var budget *models.BudgetReport
+		report, err := c.budgetService.CheckBudget(m, y)
+		switch {
+		case err == nil:
+			budget = &report
+		case !errors.Is(err, repository.ErrNotFound):
+			return err
+		}
 
-		// Show budget information if available
-		if budgetErr == nil {
-			remaining := budget.Amount - monthlySummary.TotalAmount
-			fmt.Printf("Budget: $%.2f\n", budget.Amount)
-			fmt.Printf("Remaining: $%.2f\n", remaining)
+		if c.structured() {
+			return c.renderSummary(monthlySummary, budget)
+		}
 
-			if remaining < 0 {
-				fmt.Printf("Warning: You've exceeded your budget by $%.2f\n", -remaining)
-			}
+		fmt.Printf("Total expenses for %s: %s\n", monthLabel(m, y), monthlySummary.TotalAmount.Format(monthlySummary.Currency))
+		if err := printCategoryTotals(monthlySummary); err != nil {
+			return err
+		}
+		if err := printTagTotals(monthlySummary); err != nil {
+			return err
+		}
+		if budget != nil {
+			return printBudgetReport(*budget, monthlySummary.Currency)
 		}
 
 		return nil
-	} else {
-		summary, err = c.expenseService.GetExpenseSummary()
+	}
+
+	summary, err := c.expenseService.GetExpenseSummary()
+	if err != nil {
+		return err
+	}
+
+	if c.structured() {
+		return c.renderSummary(summary, nil)
+	}
+
+	fmt.Printf("Total expenses: %s\n", summary.TotalAmount.Format(summary.Currency))
+	if err := printCategoryTotals(summary); err != nil {
+		return err
+	}
+	return printTagTotals(summary)
+}
+
+// renderSummary prints a summary, and the month's budget report if any, in a
+// machine-readable format. The budget report is added under a "budget" key so
+// that the summary keeps the models.ExpenseSummary schema.
+func (c *CLI) renderSummary(summary models.ExpenseSummary, budget *models.BudgetReport) error {
+	result := struct {
+		models.ExpenseSummary
+		Budget *models.BudgetReport `json:"budget,omitempty"`
+	}{summary, budget}
+
+	categories := slices.Sorted(maps.Keys(summary.CategoryTotals))
+	rows := make([][]string, 0, len(categories)+1)
+	for _, category := range categories {
+		rows = append(rows, []string{category, summary.CategoryTotals[category].String(), summary.Currency})
+	}
+	rows = append(rows, []string{"Total", summary.TotalAmount.String(), summary.Currency})
+
+	return c.render(result, []string{"Category", "Amount", "Currency"}, rows)
+}
+
+// resolvePeriod combines the --month, --year and --period flags into a month and year.
+// A missing year defaults to the current year.
+func resolvePeriod(month int, year int, period string) (int, int, error) {
+	if period != "" {
+		if month != 0 || year != 0 {
+			return 0, 0, fmt.Errorf("--period cannot be combined with --month or --year")
+		}
+		parsed, err := time.Parse("2006-01", period)
 		if err != nil {
-			return err
+			return 0, 0, fmt.Errorf("invalid period %q, expected YYYY-MM", period)
+		}
+		return int(parsed.Month()), parsed.Year(), nil
+	}
+
+	if month < 1 || month > 12 {
+		return 0, 0, fmt.Errorf("month must be between 1 and 12")
+	}
+	if year < 0 {
+		return 0, 0, fmt.Errorf("year must be a positive number")
+	}
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	return month, year, nil
+}
+
+// monthLabel names a month, adding the year when it is not the current one
+func monthLabel(month int, year int) string {
+	if year == time.Now().Year() {
+		return time.Month(month).String()
+	}
+	return fmt.Sprintf("%s %d", time.Month(month).String(), year)
+}
+
+// printBudgetReport prints the overall and per-category budget status for a month
+func printBudgetReport(report models.BudgetReport, currency string) error {
+	if overall := report.Overall; overall != nil {
+		fmt.Printf("Budget: %s\n", overall.Budget.Format(currency))
+		fmt.Printf("Remaining: %s\n", overall.Remaining.Format(currency))
+
+		if overall.Exceeded {
+			fmt.Printf("Warning: You've exceeded your budget by %s\n", (-overall.Remaining).Format(currency))
 		}
+	}
 
-		fmt.Printf("Total expenses: $%.2f\n", summary.(models.ExpenseSummary).TotalAmount)
+	if len(report.Categories) == 0 {
 		return nil
 	}
+
+	fmt.Println()
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "Category\tBudget\tSpent\tRemaining")
+	for _, status := range report.Categories {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", status.Category, status.Budget.Format(currency), status.Spent.Format(currency), status.Remaining.Format(currency))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	for _, status := range report.Categories {
+		if status.Exceeded {
+			fmt.Printf("Warning: You've exceeded your %s budget by %s\n", status.Category, (-status.Remaining).Format(currency))
+		}
+	}
+
+	return nil
 }
 
 // handleBudgetCommand handles the 'budget' command
 func (c *CLI) handleBudgetCommand(args []string) error {
 	if len(args) > 0 && args[0] == "--help" {
-		fmt.Println("Usage: expense-tracker budget --month MONTH --amount AMOUNT")
+		fmt.Println("Usage: expense-tracker budget --month MONTH [--year YEAR] --amount AMOUNT [--category CATEGORY]")
+		fmt.Println("       expense-tracker budget --month MONTH [--year YEAR] --delete [--category CATEGORY]")
+		fmt.Println("       expense-tracker budget --list")
+		fmt.Println("\nUse --period YYYY-MM instead of --month and --year as a shorthand")
 		return nil
 	}
 
 	budgetCmd := flag.NewFlagSet("budget", flag.ExitOnError)
 	month := budgetCmd.Int("month", 0, "Month to set budget for (1-12)")
-	amount := budgetCmd.Float64("amount", 0, "Budget amount")
+	year := budgetCmd.Int("year", 0, "Year of the month (defaults to the current year)")
+	period := budgetCmd.String("period", "", "Month to set budget for as YYYY-MM")
+	var amount models.Money
+	budgetCmd.Var(&amount, "amount", "Budget amount")
+	category := budgetCmd.String("category", "", "Category to budget (optional, defaults to the overall cap)")
+	list := budgetCmd.Bool("list", false, "List all budgets")
+	del := budgetCmd.Bool("delete", false, "Delete the budget for the month")
+	c.outputFlag(budgetCmd)
 
 	if err := budgetCmd.Parse(args); err != nil {
 		return err
 	}
 
-	if *month < 1 || *month > 12 {
-		return fmt.Errorf("month must be between 1 and 12")
+	if *list {
+		return c.listBudgets()
 	}
-	if *amount <= 0 {
+
+	m, y, err := resolvePeriod(*month, *year, *period)
+	if err != nil {
+		return err
+	}
+
+	if *del {
+		budget, err := c.budgetService.GetBudget(m, y, *category)
+		if err != nil {
+			return err
+		}
+		if err := c.budgetService.DeleteBudget(m, y, *category); err != nil {
+			return err
+		}
+
+		if c.structured() {
+			return c.render(budget, budgetCSVHeader, budgetCSVRecords(budget))
+		}
+
+		fmt.Printf("Budget deleted for %s\n", budgetLabel(m, y, *category))
+		return nil
+	}
+
+	if amount <= 0 {
 		return fmt.Errorf("amount must be greater than zero")
 	}
 
-	if err := c.budgetService.SetBudget(*month, 0, *amount); err != nil {
+	if err := c.budgetService.SetBudget(m, y, *category, amount); err != nil {
 		return err
 	}
 
-	fmt.Printf("Budget of $%.2f set for %s\n", *amount, time.Month(*month).String())
+	if c.structured() {
+		budget, err := c.budgetService.GetBudget(m, y, *category)
+		if err != nil {
+			return err
+		}
+		return c.render(budget, budgetCSVHeader, budgetCSVRecords(budget))
+	}
+
+	fmt.Printf("Budget of %s set for %s\n", amount.Format(c.expenseService.BaseCurrency()), budgetLabel(m, y, *category))
 	return nil
 }
 
+// budgetLabel describes the month and optional category a budget applies to
+func budgetLabel(month int, year int, category string) string {
+	if category == "" {
+		return monthLabel(month, year)
+	}
+	return fmt.Sprintf("%s in %s", category, monthLabel(month, year))
+}
+
+// listBudgets prints all stored budgets
+func (c *CLI) listBudgets() error {
+	budgets, err := c.budgetService.GetAllBudgets()
+	if err != nil {
+		return err
+	}
+
+	if c.structured() {
+		if budgets == nil {
+			budgets = []models.Budget{}
+		}
+		return c.render(budgets, budgetCSVHeader, budgetCSVRecords(budgets...))
+	}
+
+	if len(budgets) == 0 {
+		fmt.Println("No budgets found")
+		return nil
+	}
+
+	currency := c.expenseService.BaseCurrency()
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "Month\tCategory\tAmount")
+	for _, budget := range budgets {
+		category := budget.Category
+		if category == "" {
+			category = "(overall)"
+		}
+		fmt.Fprintf(table, "%s %d\t%s\t%s\n", budget.Month.String(), budget.Year, category, budget.Amount.Format(currency))
+	}
+
+	return table.Flush()
+}
+
+// budgetCSVHeader is the header row of budgets printed as CSV
+var budgetCSVHeader = []string{"Month", "Year", "Category", "Amount"}
+
+// budgetCSVRecords converts budgets into CSV records matching budgetCSVHeader
+func budgetCSVRecords(budgets ...models.Budget) [][]string {
+	records := make([][]string, 0, len(budgets))
+	for _, budget := range budgets {
+		records = append(records, []string{
+			strconv.Itoa(int(budget.Month)),
+			strconv.Itoa(budget.Year),
+			budget.Category,
+			budget.Amount.String(),
+		})
+	}
+	return records
+}
+
 // handleExportCommand handles the 'export' command
 func (c *CLI) handleExportCommand(args []string) error {
 	if len(args) > 0 && args[0] == "--help" {
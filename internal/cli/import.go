@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// handleImportCommand handles the 'import' command
+func (c *CLI) handleImportCommand(args []string) error {
+	if len(args) > 0 && args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker import --file FILEPATH [--profile PROFILE.json] [--dry-run]")
+		fmt.Println("\nWithout a profile the file is read in the format written by 'export'.")
+		fmt.Println("A profile is a JSON file describing a bank's CSV format, for example:")
+		fmt.Println(`  {"delimiter": ";", "dateColumn": "Booking date", "dateFormat": "02.01.2006",`)
+		fmt.Println(`   "descriptionColumn": "Payee", "amountColumn": "Amount", "currency": "EUR",`)
+		fmt.Println(`   "decimalSeparator": ",", "thousandsSeparator": ".", "sign": "negative"}`)
+		return nil
+	}
+
+	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+	file := importCmd.String("file", "", "Path to the CSV file to import")
+	profilePath := importCmd.String("profile", "", "Path to a JSON column-mapping profile (optional)")
+	dryRun := importCmd.Bool("dry-run", false, "Preview the import without saving anything")
+
+	if err := importCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *file == "" {
+		return fmt.Errorf("file path is required")
+	}
+
+	profile := service.DefaultImportProfile
+	if *profilePath != "" {
+		var err error
+		if profile, err = service.LoadImportProfile(*profilePath); err != nil {
+			return err
+		}
+	}
+
+	result, err := c.importService.ImportCSV(*file, profile, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		if err := c.printImportPreview(result); err != nil {
+			return err
+		}
+	}
+
+	for _, rowErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", rowErr)
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Printf("Skipped %d non-expense rows (lines %v)\n", len(result.Skipped), result.Skipped)
+	}
+
+	switch {
+	case len(result.Errors) > 0 && *dryRun:
+		return fmt.Errorf("%d of %d rows have errors and would abort the import", len(result.Errors), len(result.Errors)+len(result.Rows))
+	case len(result.Errors) > 0:
+		return fmt.Errorf("import aborted: %d of %d rows have errors, nothing was imported", len(result.Errors), len(result.Errors)+len(result.Rows))
+	case *dryRun:
+		fmt.Printf("Dry run: %d expenses would be imported\n", len(result.Rows))
+	case result.Committed:
+		if err := printImportCategorizations(result); err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d expenses (IDs %d-%d)\n", len(result.IDs), result.IDs[0], result.IDs[len(result.IDs)-1])
+	default:
+		fmt.Println("No expenses found to import")
+	}
+
+	return nil
+}
+
+// printImportPreview prints the expenses an import would add
+func (c *CLI) printImportPreview(result service.ImportResult) error {
+	if len(result.Rows) == 0 {
+		return nil
+	}
+
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "Line\tDate\tDescription\tCategory\tAmount\tRule")
+	for _, row := range result.Rows {
+		currency := row.Expense.Currency
+		if currency == "" {
+			currency = c.expenseService.BaseCurrency()
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			row.Line,
+			row.Expense.Date.Format("2006-01-02"),
+			row.Expense.Description,
+			row.Expense.Category,
+			row.Expense.Amount.Format(currency),
+			ruleLabel(row.Rule))
+	}
+
+	return table.Flush()
+}
+
+// printImportCategorizations prints the imported expenses that were
+// categorized by a rule
+func printImportCategorizations(result service.ImportResult) error {
+	table := newTable(os.Stdout)
+	header := false
+	for i, row := range result.Rows {
+		if row.Rule == nil {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(table, "ID\tDescription\tCategory\tRule")
+			header = true
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%d\n", result.IDs[i], row.Expense.Description, row.Expense.Category, row.Rule.ID)
+	}
+
+	return table.Flush()
+}
+
+// ruleLabel returns the ID of the category rule that fired, or an empty
+// string when none did
+func ruleLabel(rule *models.CategoryRule) string {
+	if rule == nil {
+		return ""
+	}
+	return strconv.Itoa(rule.ID)
+}
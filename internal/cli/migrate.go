@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// handleMigrateCommand handles the 'migrate' command
+func (c *CLI) handleMigrateCommand(args []string) error {
+	if len(args) > 0 && args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker migrate --from STORE --to STORE")
+		fmt.Println("\nCopies every expense, keeping its ID, from one store into another, empty, store")
+		fmt.Println("and verifies that both hold the same number of expenses with the same totals.")
+		fmt.Println("Stores are given as json:DIRECTORY, sqlite:FILE or events:DIRECTORY, for example:")
+		fmt.Println("  expense-tracker migrate --from json:data --to sqlite:data/expenses.sqlite")
+		return nil
+	}
+
+	migrateCmd := flag.NewFlagSet("migrate", flag.ExitOnError)
+	from := migrateCmd.String("from", "", "Store to copy expenses from")
+	to := migrateCmd.String("to", "", "Empty store to copy expenses into")
+
+	if err := migrateCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *from == "" || *to == "" {
+		return fmt.Errorf("both --from and --to are required")
+	}
+
+	report, err := service.MigrateStore(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated %d expenses and %d in the trash from %s to %s\n", report.ExpenseCount, report.TrashCount, report.From, report.To)
+	for _, currency := range slices.Sorted(maps.Keys(report.CurrencyTotals)) {
+		fmt.Printf("  Verified total: %s\n", report.CurrencyTotals[currency].Format(currency))
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat selects how command results are printed
+type OutputFormat string
+
+const (
+	OutputTable OutputFormat = "table"
+	OutputJSON  OutputFormat = "json"
+	OutputCSV   OutputFormat = "csv"
+	OutputYAML  OutputFormat = "yaml"
+)
+
+// ParseOutputFormat validates an output format name
+func ParseOutputFormat(value string) (OutputFormat, error) {
+	switch format := OutputFormat(strings.ToLower(strings.TrimSpace(value))); format {
+	case OutputTable, OutputJSON, OutputCSV, OutputYAML:
+		return format, nil
+	case "":
+		return OutputTable, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q, expected table, json, csv or yaml", value)
+	}
+}
+
+// String returns the format name so that *OutputFormat can be used as a flag.Value
+func (f OutputFormat) String() string {
+	return string(f)
+}
+
+// Set parses the format from a string so that *OutputFormat can be used as a flag.Value
+func (f *OutputFormat) Set(value string) error {
+	format, err := ParseOutputFormat(value)
+	if err != nil {
+		return err
+	}
+	*f = format
+	return nil
+}
+
+// outputFlag registers the --output option on a command's flag set so that
+// the global option can also be given after the command
+func (c *CLI) outputFlag(fs *flag.FlagSet) {
+	fs.Var(&c.output, "output", "Output format: table, json, csv or yaml")
+}
+
+// structured reports whether results should be printed in a machine-readable format
+func (c *CLI) structured() bool {
+	return c.output != OutputTable
+}
+
+// newTable returns a writer that aligns tab-separated columns
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+// render prints a value in the selected machine-readable format. For CSV,
+// header and rows are used instead of value.
+func (c *CLI) render(value any, header []string, rows [][]string) error {
+	switch c.output {
+	case OutputJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case OutputYAML:
+		return writeYAML(os.Stdout, value)
+	case OutputCSV:
+		writer := csv.NewWriter(os.Stdout)
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	default:
+		return fmt.Errorf("output format %q cannot render structured data", c.output)
+	}
+}
+
+// writeYAML encodes a value as YAML using its JSON field names and ordering,
+// so that both formats share the same schema
+func writeYAML(w io.Writer, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	// JSON is valid YAML; decode it into a node tree to keep key order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// resetStyle switches a node tree parsed from JSON to block style YAML
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// handleRecurringCommand handles the 'recurring' command
+func (c *CLI) handleRecurringCommand(args []string) error {
+	if len(args) == 0 || args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker recurring add --description DESCRIPTION --amount AMOUNT --frequency daily|weekly|monthly|yearly")
+		fmt.Println("                                     [--interval N] [--start DATE] [--until DATE] [--count N]")
+		fmt.Println("                                     [--currency CODE] [--category CATEGORY]")
+		fmt.Println("       expense-tracker recurring list")
+		fmt.Println("       expense-tracker recurring delete --id ID")
+		fmt.Println("       expense-tracker recurring pause --id ID")
+		fmt.Println("       expense-tracker recurring resume --id ID")
+		fmt.Println("       expense-tracker recurring run")
+		fmt.Println("\nDue recurring expenses are created automatically whenever expense-tracker runs,")
+		fmt.Println("including any that fell due while it was not used. 'run' does this on demand.")
+		return nil
+	}
+
+	switch args[0] {
+	case "add":
+		return c.addRecurringRule(args[1:])
+	case "list":
+		return c.listRecurringRules(args[1:])
+	case "delete", "pause", "resume":
+		return c.changeRecurringRule(args[0], args[1:])
+	case "run":
+		return c.runRecurring(false)
+	default:
+		return fmt.Errorf("unknown recurring command: %s", args[0])
+	}
+}
+
+// addRecurringRule adds a recurring rule and creates any expenses already due
+func (c *CLI) addRecurringRule(args []string) error {
+	addCmd := flag.NewFlagSet("recurring add", flag.ExitOnError)
+	description := addCmd.String("description", "", "Description of the expense")
+	var amount models.Money
+	addCmd.Var(&amount, "amount", "Amount of each expense")
+	currency := addCmd.String("currency", "", "Currency of the amount (defaults to the base currency)")
+	category := addCmd.String("category", "", "Category of the expense (optional)")
+	frequency := addCmd.String("frequency", "", "How often the expense repeats: daily, weekly, monthly or yearly")
+	interval := addCmd.Int("interval", 1, "Repeat every N days, weeks, months or years")
+	start := addCmd.String("start", "", "Date of the first expense (defaults to today)")
+	until := addCmd.String("until", "", "Last date an expense may fall on (optional)")
+	count := addCmd.Int("count", 0, "Number of expenses to create in total (optional)")
+
+	if err := addCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *description == "" {
+		return fmt.Errorf("description is required")
+	}
+	if *frequency == "" {
+		return fmt.Errorf("frequency is required")
+	}
+
+	input := service.NewRecurringRule{
+		Description: *description,
+		Amount:      amount,
+		Currency:    *currency,
+		Category:    *category,
+		Frequency:   models.Frequency(*frequency),
+		Interval:    *interval,
+		Count:       *count,
+	}
+	if *start != "" {
+		date, err := parseDate(*start)
+		if err != nil {
+			return err
+		}
+		input.Start = date
+	}
+	if *until != "" {
+		date, err := parseDate(*until)
+		if err != nil {
+			return err
+		}
+		input.Until = &date
+	}
+
+	id, err := c.recurringService.AddRule(input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Recurring expense added successfully (ID: %d)\n", id)
+	return c.runRecurring(false)
+}
+
+// listRecurringRules prints all recurring rules
+func (c *CLI) listRecurringRules(args []string) error {
+	listCmd := flag.NewFlagSet("recurring list", flag.ExitOnError)
+	c.outputFlag(listCmd)
+
+	if err := listCmd.Parse(args); err != nil {
+		return err
+	}
+
+	rules, err := c.recurringService.GetRules()
+	if err != nil {
+		return err
+	}
+
+	if c.structured() {
+		if rules == nil {
+			rules = []models.RecurringRule{}
+		}
+		rows := make([][]string, 0, len(rules))
+		for _, rule := range rules {
+			rows = append(rows, []string{
+				strconv.Itoa(rule.ID),
+				rule.Description,
+				rule.Amount.String(),
+				rule.Currency,
+				rule.Category,
+				scheduleLabel(rule),
+				nextLabel(rule),
+				ruleStatus(rule),
+			})
+		}
+		return c.render(rules, []string{"ID", "Description", "Amount", "Currency", "Category", "Schedule", "Next", "Status"}, rows)
+	}
+
+	if len(rules) == 0 {
+		fmt.Println("No recurring expenses found")
+		return nil
+	}
+
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "ID\tDescription\tCategory\tAmount\tSchedule\tNext\tStatus")
+	for _, rule := range rules {
+		currency := rule.Currency
+		if currency == "" {
+			currency = c.expenseService.BaseCurrency()
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			rule.ID,
+			rule.Description,
+			rule.Category,
+			rule.Amount.Format(currency),
+			scheduleLabel(rule),
+			nextLabel(rule),
+			ruleStatus(rule))
+	}
+
+	return table.Flush()
+}
+
+// scheduleLabel describes how often a rule repeats, e.g. "monthly" or "every 2 weeks"
+func scheduleLabel(rule models.RecurringRule) string {
+	label := string(rule.Frequency)
+	if rule.Interval > 1 {
+		units := map[models.Frequency]string{
+			models.FrequencyDaily:   "days",
+			models.FrequencyWeekly:  "weeks",
+			models.FrequencyMonthly: "months",
+			models.FrequencyYearly:  "years",
+		}
+		label = fmt.Sprintf("every %d %s", rule.Interval, units[rule.Frequency])
+	}
+
+	if rule.Count > 0 {
+		label += fmt.Sprintf(", %d times", rule.Count)
+	}
+	if rule.Until != nil {
+		label += ", until " + rule.Until.Format("2006-01-02")
+	}
+	return label
+}
+
+// nextLabel returns the date of a rule's next expense, or "-" once it has ended
+func nextLabel(rule models.RecurringRule) string {
+	if date, ok := rule.Next(); ok {
+		return date.Format("2006-01-02")
+	}
+	return "-"
+}
+
+// ruleStatus reports whether a rule is active, paused or ended
+func ruleStatus(rule models.RecurringRule) string {
+	switch _, ok := rule.Next(); {
+	case !ok:
+		return "ended"
+	case rule.Paused:
+		return "paused"
+	default:
+		return "active"
+	}
+}
+
+// changeRecurringRule deletes, pauses or resumes a recurring rule
+func (c *CLI) changeRecurringRule(action string, args []string) error {
+	changeCmd := flag.NewFlagSet("recurring "+action, flag.ExitOnError)
+	id := changeCmd.Int("id", 0, "ID of the recurring expense")
+
+	if err := changeCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *id <= 0 {
+		return fmt.Errorf("valid recurring expense ID is required")
+	}
+
+	switch action {
+	case "delete":
+		if err := c.recurringService.DeleteRule(*id); err != nil {
+			return err
+		}
+		fmt.Println("Recurring expense deleted successfully; expenses it already created are kept")
+	case "pause":
+		if err := c.recurringService.PauseRule(*id); err != nil {
+			return err
+		}
+		fmt.Println("Recurring expense paused")
+	case "resume":
+		skipped, err := c.recurringService.ResumeRule(*id)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Recurring expense resumed (%d occurrences while paused were skipped)\n", skipped)
+		return c.runRecurring(false)
+	}
+
+	return nil
+}
+
+// runRecurring creates the recurring expenses that are due. When quiet, as
+// before other commands, the result is reported on stderr so it does not mix
+// with their output, and failures are only warnings.
+func (c *CLI) runRecurring(quiet bool) error {
+	ids, err := c.recurringService.Materialize()
+	if quiet && err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not create recurring expenses: %v\n", err)
+		return nil
+	}
+
+	out := os.Stdout
+	if quiet {
+		out = os.Stderr
+	}
+	switch {
+	case len(ids) > 0:
+		fmt.Fprintf(out, "Added %d recurring expenses (IDs %d-%d)\n", len(ids), ids[0], ids[len(ids)-1])
+	case !quiet && err == nil:
+		fmt.Fprintln(out, "No recurring expenses are due")
+	}
+
+	return err
+}
@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// handleRulesCommand handles the 'rules' command
+func (c *CLI) handleRulesCommand(args []string) error {
+	if len(args) == 0 || args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker rules add --category CATEGORY [--contains TEXT] [--pattern REGEXP] [--merchant TEXT]")
+		fmt.Println("                                 [--min AMOUNT] [--max AMOUNT]")
+		fmt.Println("       expense-tracker rules list")
+		fmt.Println("       expense-tracker rules delete --id ID")
+		fmt.Println("       expense-tracker rules test (--id EXPENSE_ID | --description TEXT [--merchant TEXT] [--amount AMOUNT])")
+		fmt.Println("       expense-tracker rules apply [--retroactive] [--dry-run]")
+		fmt.Println("\nExpenses added or imported without a category get the category of the first rule")
+		fmt.Println("that matches them. A rule matches when all of its conditions hold. Text is matched")
+		fmt.Println("ignoring case, and amounts are compared in the expense's own currency.")
+		fmt.Println("'apply' categorizes existing expenses that have no category; with --retroactive it")
+		fmt.Println("also recategorizes expenses that already have one.")
+		return nil
+	}
+
+	switch args[0] {
+	case "add":
+		return c.addCategoryRule(args[1:])
+	case "list":
+		return c.listCategoryRules(args[1:])
+	case "delete":
+		return c.deleteCategoryRule(args[1:])
+	case "test":
+		return c.testCategoryRules(args[1:])
+	case "apply":
+		return c.applyCategoryRules(args[1:])
+	default:
+		return fmt.Errorf("unknown rules command: %s", args[0])
+	}
+}
+
+// addCategoryRule adds a rule after the existing ones
+func (c *CLI) addCategoryRule(args []string) error {
+	addCmd := flag.NewFlagSet("rules add", flag.ExitOnError)
+	category := addCmd.String("category", "", "Category given to matching expenses")
+	contains := addCmd.String("contains", "", "Match descriptions containing this text")
+	pattern := addCmd.String("pattern", "", "Match descriptions matching this regular expression")
+	merchant := addCmd.String("merchant", "", "Match merchants containing this text")
+	var minAmount, maxAmount models.Money
+	addCmd.Var(&minAmount, "min", "Match amounts of at least this much")
+	addCmd.Var(&maxAmount, "max", "Match amounts of at most this much")
+
+	if err := addCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *category == "" {
+		return fmt.Errorf("category is required")
+	}
+
+	input := service.NewCategoryRule{
+		Category: *category,
+		Contains: *contains,
+		Pattern:  *pattern,
+		Merchant: *merchant,
+	}
+	addCmd.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "min":
+			input.Min = &minAmount
+		case "max":
+			input.Max = &maxAmount
+		}
+	})
+
+	id, err := c.ruleService.AddRule(input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Category rule added successfully (ID: %d)\n", id)
+	return nil
+}
+
+// listCategoryRules prints all rules in the order they are evaluated
+func (c *CLI) listCategoryRules(args []string) error {
+	listCmd := flag.NewFlagSet("rules list", flag.ExitOnError)
+	c.outputFlag(listCmd)
+
+	if err := listCmd.Parse(args); err != nil {
+		return err
+	}
+
+	rules, err := c.ruleService.GetRules()
+	if err != nil {
+		return err
+	}
+
+	if c.structured() {
+		if rules == nil {
+			rules = []models.CategoryRule{}
+		}
+		rows := make([][]string, 0, len(rules))
+		for _, rule := range rules {
+			rows = append(rows, []string{strconv.Itoa(rule.ID), rule.Category, rule.Conditions()})
+		}
+		return c.render(rules, []string{"ID", "Category", "Conditions"}, rows)
+	}
+
+	if len(rules) == 0 {
+		fmt.Println("No category rules found")
+		return nil
+	}
+
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "ID\tCategory\tConditions")
+	for _, rule := range rules {
+		fmt.Fprintf(table, "%d\t%s\t%s\n", rule.ID, rule.Category, rule.Conditions())
+	}
+
+	return table.Flush()
+}
+
+// deleteCategoryRule removes a rule
+func (c *CLI) deleteCategoryRule(args []string) error {
+	deleteCmd := flag.NewFlagSet("rules delete", flag.ExitOnError)
+	id := deleteCmd.Int("id", 0, "ID of the rule")
+
+	if err := deleteCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *id <= 0 {
+		return fmt.Errorf("valid rule ID is required")
+	}
+
+	if err := c.ruleService.DeleteRule(*id); err != nil {
+		return err
+	}
+
+	fmt.Println("Category rule deleted successfully")
+	return nil
+}
+
+// testCategoryRules shows which rules match an expense and which of them
+// would set its category
+func (c *CLI) testCategoryRules(args []string) error {
+	testCmd := flag.NewFlagSet("rules test", flag.ExitOnError)
+	id := testCmd.Int("id", 0, "ID of an existing expense to test")
+	description := testCmd.String("description", "", "Description to test")
+	merchant := testCmd.String("merchant", "", "Merchant to test")
+	var amount models.Money
+	testCmd.Var(&amount, "amount", "Amount to test")
+
+	if err := testCmd.Parse(args); err != nil {
+		return err
+	}
+
+	expense := models.Expense{Description: *description, Merchant: *merchant, Amount: amount}
+	switch {
+	case *id > 0:
+		var err error
+		if expense, err = c.expenseService.GetExpenseByID(*id); err != nil {
+			return err
+		}
+	case *description == "" && *merchant == "":
+		return fmt.Errorf("an expense ID or a description or merchant is required")
+	}
+
+	matched, err := c.ruleService.TestRules(expense)
+	if err != nil {
+		return err
+	}
+
+	if len(matched) == 0 {
+		fmt.Println("No rule matches")
+		return nil
+	}
+
+	fmt.Printf("Rule %d fires: category %s\n", matched[0].ID, matched[0].Category)
+	if len(matched) > 1 {
+		fmt.Println("\nAlso matching, but evaluated later:")
+		table := newTable(os.Stdout)
+		fmt.Fprintln(table, "ID\tCategory\tConditions")
+		for _, rule := range matched[1:] {
+			fmt.Fprintf(table, "%d\t%s\t%s\n", rule.ID, rule.Category, rule.Conditions())
+		}
+		return table.Flush()
+	}
+
+	return nil
+}
+
+// applyCategoryRules categorizes existing expenses and reports which rule
+// fired for each of them
+func (c *CLI) applyCategoryRules(args []string) error {
+	applyCmd := flag.NewFlagSet("rules apply", flag.ExitOnError)
+	retroactive := applyCmd.Bool("retroactive", false, "Also recategorize expenses that already have a category")
+	dryRun := applyCmd.Bool("dry-run", false, "Show the changes without saving them")
+	c.outputFlag(applyCmd)
+
+	if err := applyCmd.Parse(args); err != nil {
+		return err
+	}
+
+	changes, err := c.ruleService.ApplyRules(*retroactive, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if c.structured() {
+		if changes == nil {
+			changes = []service.Categorization{}
+		}
+		rows := make([][]string, 0, len(changes))
+		for _, change := range changes {
+			rows = append(rows, []string{
+				strconv.Itoa(change.Expense.ID),
+				change.Expense.Description,
+				change.Previous,
+				change.Expense.Category,
+				strconv.Itoa(change.Rule.ID),
+			})
+		}
+		return c.render(changes, []string{"ID", "Description", "Previous", "Category", "Rule"}, rows)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No expenses to categorize")
+		return nil
+	}
+
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "ID\tDescription\tPrevious\tCategory\tRule")
+	for _, change := range changes {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%d\n",
+			change.Expense.ID,
+			change.Expense.Description,
+			change.Previous,
+			change.Expense.Category,
+			change.Rule.ID)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Printf("Dry run: %d expenses would be categorized\n", len(changes))
+	} else {
+		fmt.Printf("Categorized %d expenses\n", len(changes))
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Businge931/expense-tracker/internal/api"
+)
+
+// handleServeCommand handles the 'serve' command
+func (c *CLI) handleServeCommand(args []string) error {
+	if len(args) > 0 && args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker serve [--addr ADDRESS]")
+		fmt.Println("\nServes expenses, summaries, budgets and exports as a JSON REST API, and a web interface")
+		fmt.Println("built on it at /, until interrupted.")
+		fmt.Println("The endpoints are described by the OpenAPI document at /api/openapi.json.")
+		fmt.Println("The API has no authentication, so it only listens on this machine unless --addr says otherwise.")
+		fmt.Println("Requests must be addressed to the listen address or to localhost, 127.0.0.1 or [::1].")
+		return nil
+	}
+
+	serveCmd := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := serveCmd.String("addr", "127.0.0.1:8080", "Address to listen on")
+
+	if err := serveCmd.Parse(args); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", *addr, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Serving the web interface on http://%s/ and the API under /api, press Ctrl+C to stop\n", listener.Addr())
+	server := api.NewServer(c.expenseService, c.budgetService, c.exportService)
+	if err := server.Serve(ctx, listener); err != nil {
+		return err
+	}
+
+	fmt.Println("Server stopped")
+	return nil
+}
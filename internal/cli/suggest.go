@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// handleSuggestCommand handles the 'suggest' command
+func (c *CLI) handleSuggestCommand(args []string) error {
+	if len(args) > 0 && args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker suggest --description DESCRIPTION [--limit N]")
+		fmt.Println("       expense-tracker suggest --evaluate [--min-confidence P]")
+		fmt.Println("\nCategories are suggested from the words of the descriptions of past categorized")
+		fmt.Println("expenses. --evaluate predicts the category of every existing expense from all the")
+		fmt.Println("others and reports how often the suggestion was right, overall and for suggestions")
+		fmt.Println("confident enough to fill in the category on 'add'.")
+		return nil
+	}
+
+	suggestCmd := flag.NewFlagSet("suggest", flag.ExitOnError)
+	description := suggestCmd.String("description", "", "Description to suggest a category for")
+	limit := suggestCmd.Int("limit", 3, "Maximum number of suggestions to show")
+	evaluate := suggestCmd.Bool("evaluate", false, "Report the accuracy of suggestions on the existing expenses")
+	minConfidence := suggestCmd.Float64("min-confidence", service.DefaultMinConfidence, "Confidence a suggestion needs to fill in a category, between 0 and 1")
+	c.outputFlag(suggestCmd)
+
+	if err := suggestCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *evaluate {
+		return c.evaluateSuggestions(*minConfidence)
+	}
+
+	if *description == "" {
+		return fmt.Errorf("description is required")
+	}
+	if *limit <= 0 {
+		return fmt.Errorf("limit must be greater than zero")
+	}
+
+	suggestions, err := c.expenseService.SuggestCategories(*description)
+	if err != nil {
+		return err
+	}
+	if len(suggestions) > *limit {
+		suggestions = suggestions[:*limit]
+	}
+
+	if c.structured() {
+		if suggestions == nil {
+			suggestions = []service.CategorySuggestion{}
+		}
+		rows := make([][]string, 0, len(suggestions))
+		for _, suggestion := range suggestions {
+			rows = append(rows, []string{suggestion.Category, strconv.FormatFloat(suggestion.Confidence, 'f', 4, 64)})
+		}
+		return c.render(suggestions, []string{"Category", "Confidence"}, rows)
+	}
+
+	if len(suggestions) == 0 {
+		fmt.Println("No suggestion: none of the words have been seen in a categorized expense")
+		return nil
+	}
+
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "Category\tConfidence")
+	for _, suggestion := range suggestions {
+		fmt.Fprintf(table, "%s\t%s\n", suggestion.Category, confidenceLabel(suggestion.Confidence))
+	}
+
+	return table.Flush()
+}
+
+// evaluateSuggestions prints how accurately suggestions predict the
+// categories of the existing expenses
+func (c *CLI) evaluateSuggestions(minConfidence float64) error {
+	evaluation, err := c.expenseService.EvaluateSuggestions(minConfidence)
+	if err != nil {
+		return err
+	}
+
+	if c.structured() {
+		rows := make([][]string, 0, len(evaluation.Categories))
+		for _, category := range evaluation.Categories {
+			rows = append(rows, []string{category.Category, strconv.Itoa(category.Expenses), strconv.Itoa(category.Correct)})
+		}
+		return c.render(evaluation, []string{"Category", "Expenses", "Correct"}, rows)
+	}
+
+	if evaluation.Expenses == 0 {
+		fmt.Println("No categorized expenses to evaluate")
+		return nil
+	}
+
+	fmt.Printf("Evaluated %d categorized expenses, each predicted from all the others\n", evaluation.Expenses)
+	fmt.Printf("Top suggestion correct: %d (%s)\n", evaluation.Correct, percentage(evaluation.Correct, evaluation.Expenses))
+	fmt.Printf("Confident enough to fill in (at least %s): %d (%s of expenses), of which %d correct (%s)\n",
+		confidenceLabel(evaluation.MinConfidence),
+		evaluation.Confident, percentage(evaluation.Confident, evaluation.Expenses),
+		evaluation.ConfidentCorrect, percentage(evaluation.ConfidentCorrect, evaluation.Confident))
+
+	fmt.Println()
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "Category\tExpenses\tCorrect\tAccuracy")
+	for _, category := range evaluation.Categories {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%s\n", category.Category, category.Expenses, category.Correct, percentage(category.Correct, category.Expenses))
+	}
+
+	return table.Flush()
+}
+
+// confidenceLabel formats a confidence between 0 and 1 as a percentage
+func confidenceLabel(confidence float64) string {
+	return fmt.Sprintf("%.0f%%", confidence*100)
+}
+
+// percentage formats part of total as a percentage, or "-" when total is zero
+func percentage(part, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return confidenceLabel(float64(part) / float64(total))
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// stringList collects the values of a flag that may be given more than once
+type stringList []string
+
+// String returns the values joined by commas so that *stringList can be used as a flag.Value
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds a value so that *stringList can be used as a flag.Value
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// handleTagCommand handles the 'tag' command
+func (c *CLI) handleTagCommand(args []string) error {
+	if len(args) == 0 || args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker tag list")
+		fmt.Println("       expense-tracker tag rename --from TAG --to TAG")
+		fmt.Println("       expense-tracker tag merge --into TAG --from TAG [--from TAG ...]")
+		fmt.Println("\nTags are added with 'add --tag' and 'update --tag'. Renaming and merging rewrite")
+		fmt.Println("every expense carrying the tags and can be undone. Expenses in the trash keep their tags.")
+		return nil
+	}
+
+	switch args[0] {
+	case "list":
+		return c.listTags(args[1:])
+	case "rename":
+		return c.renameTag(args[1:])
+	case "merge":
+		return c.mergeTags(args[1:])
+	default:
+		return fmt.Errorf("unknown tag command: %s", args[0])
+	}
+}
+
+// listTags prints every tag in use with the number of expenses carrying it
+func (c *CLI) listTags(args []string) error {
+	listCmd := flag.NewFlagSet("tag list", flag.ExitOnError)
+	c.outputFlag(listCmd)
+
+	if err := listCmd.Parse(args); err != nil {
+		return err
+	}
+
+	tags, err := c.expenseService.GetTags()
+	if err != nil {
+		return err
+	}
+
+	if c.structured() {
+		rows := make([][]string, 0, len(tags))
+		for _, usage := range tags {
+			rows = append(rows, []string{usage.Tag, strconv.Itoa(usage.Count)})
+		}
+		return c.render(tags, []string{"Tag", "Expenses"}, rows)
+	}
+
+	if len(tags) == 0 {
+		fmt.Println("No tags found")
+		return nil
+	}
+
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "Tag\tExpenses")
+	for _, usage := range tags {
+		fmt.Fprintf(table, "%s\t%d\n", usage.Tag, usage.Count)
+	}
+
+	return table.Flush()
+}
+
+// renameTag renames a tag on every expense carrying it
+func (c *CLI) renameTag(args []string) error {
+	renameCmd := flag.NewFlagSet("tag rename", flag.ExitOnError)
+	from := renameCmd.String("from", "", "Tag to rename")
+	to := renameCmd.String("to", "", "New name of the tag")
+
+	if err := renameCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *from == "" || *to == "" {
+		return fmt.Errorf("both --from and --to are required")
+	}
+
+	changed, err := c.expenseService.RenameTag(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Renamed tag on %d expenses\n", changed)
+	return nil
+}
+
+// mergeTags replaces several tags with one on every expense carrying them
+func (c *CLI) mergeTags(args []string) error {
+	mergeCmd := flag.NewFlagSet("tag merge", flag.ExitOnError)
+	into := mergeCmd.String("into", "", "Tag to keep")
+	var from stringList
+	mergeCmd.Var(&from, "from", "Tag to merge into the kept one; may be repeated")
+
+	if err := mergeCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *into == "" || len(from) == 0 {
+		return fmt.Errorf("--into and at least one --from are required")
+	}
+
+	changed, err := c.expenseService.MergeTags(*into, from)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Merged tags on %d expenses\n", changed)
+	return nil
+}
+
+// printTagTotals prints the total spent per tag, if any expense is tagged
+func printTagTotals(summary models.ExpenseSummary) error {
+	if len(summary.TagTotals) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "Tag\tAmount")
+	for _, tag := range slices.Sorted(maps.Keys(summary.TagTotals)) {
+		fmt.Fprintf(table, "%s\t%s\n", tag, summary.TagTotals[tag].Format(summary.Currency))
+	}
+
+	return table.Flush()
+}
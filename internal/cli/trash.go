@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// handleTrashCommand handles the 'trash' command
+func (c *CLI) handleTrashCommand(args []string) error {
+	if len(args) == 0 || args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker trash list")
+		fmt.Println("       expense-tracker trash restore --id ID")
+		fmt.Println("       expense-tracker trash empty [--older-than AGE]")
+		fmt.Println("\nDeleted expenses are kept in the trash until it is emptied. AGE is a number of")
+		fmt.Println("days or weeks such as 30d or 2w, or a duration such as 12h.")
+		return nil
+	}
+
+	switch args[0] {
+	case "list":
+		return c.listTrash(args[1:])
+	case "restore":
+		return c.restoreFromTrash(args[1:])
+	case "empty":
+		return c.emptyTrash(args[1:])
+	default:
+		return fmt.Errorf("unknown trash command: %s", args[0])
+	}
+}
+
+// listTrash prints the expenses in the trash
+func (c *CLI) listTrash(args []string) error {
+	listCmd := flag.NewFlagSet("trash list", flag.ExitOnError)
+	c.outputFlag(listCmd)
+
+	if err := listCmd.Parse(args); err != nil {
+		return err
+	}
+
+	expenses, err := c.expenseService.GetDeletedExpenses()
+	if err != nil {
+		return err
+	}
+
+	if c.structured() {
+		if expenses == nil {
+			expenses = []models.Expense{}
+		}
+		header := slices.Concat(service.ExpenseCSVHeader, []string{"Deleted"})
+		rows := service.ExpenseCSVRecords(expenses)
+		for i, expense := range expenses {
+			rows[i] = append(rows[i], expense.DeletedAt.Format(time.RFC3339))
+		}
+		return c.render(expenses, header, rows)
+	}
+
+	if len(expenses) == 0 {
+		fmt.Println("The trash is empty")
+		return nil
+	}
+
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "ID\tDate\tDescription\tCategory\tAmount\tDeleted")
+	for _, expense := range expenses {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			expense.ID,
+			expense.Date.Format("2006-01-02"),
+			expense.Description,
+			expense.Category,
+			expense.Amount.Format(expense.CurrencyCode()),
+			expense.DeletedAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	return table.Flush()
+}
+
+// restoreFromTrash takes an expense out of the trash
+func (c *CLI) restoreFromTrash(args []string) error {
+	restoreCmd := flag.NewFlagSet("trash restore", flag.ExitOnError)
+	id := restoreCmd.Int("id", 0, "ID of the expense to restore")
+
+	if err := restoreCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *id <= 0 {
+		return fmt.Errorf("valid expense ID is required")
+	}
+
+	expense, err := c.expenseService.RestoreExpense(*id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Expense %d (%s) restored from trash\n", expense.ID, expense.Description)
+	return nil
+}
+
+// emptyTrash permanently removes expenses from the trash
+func (c *CLI) emptyTrash(args []string) error {
+	emptyCmd := flag.NewFlagSet("trash empty", flag.ExitOnError)
+	olderThan := emptyCmd.String("older-than", "", "Only remove expenses deleted longer ago than this, e.g. 30d")
+
+	if err := emptyCmd.Parse(args); err != nil {
+		return err
+	}
+
+	var age time.Duration
+	if *olderThan != "" {
+		var err error
+		if age, err = parseAge(*olderThan); err != nil {
+			return err
+		}
+	}
+
+	purged, err := c.expenseService.EmptyTrash(age)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Permanently removed %d expenses from the trash\n", purged)
+	return nil
+}
+
+// parseAge parses a number of days or weeks such as 30d or 2w, or a Go
+// duration such as 12h
+func parseAge(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if len(value) > 1 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n >= 0 {
+			switch value[len(value)-1] {
+			case 'd':
+				return time.Duration(n) * 24 * time.Hour, nil
+			case 'w':
+				return time.Duration(n) * 7 * 24 * time.Hour, nil
+			}
+		}
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q, expected a number of days or weeks such as 30d or 2w", value)
+	}
+	return age, nil
+}
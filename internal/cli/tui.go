@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/Businge931/expense-tracker/internal/tui"
+)
+
+// handleTUICommand handles the 'tui' command
+func (c *CLI) handleTUICommand(args []string) error {
+	if len(args) > 0 && args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker tui")
+		fmt.Println("\nOpens a full-screen interface showing the expenses of one month with its total and")
+		fmt.Println("remaining budget. Keys:")
+		fmt.Println("  ←/→ or [/]   Previous or next month (t for the current month)")
+		fmt.Println("  ↑/↓, PgUp/PgDn  Select an expense")
+		fmt.Println("  /            Filter as you type by description, category, merchant, tag or amount")
+		fmt.Println("  a, e/Enter   Add an expense, or edit the selected one; Tab completes categories")
+		fmt.Println("  d/Delete     Move the selected expense to the trash")
+		fmt.Println("  u, r         Undo or redo the last change")
+		fmt.Println("  q            Quit")
+		return nil
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %v", args)
+	}
+
+	return tui.NewApp(c.expenseService, c.budgetService, c.undoService).Run()
+}
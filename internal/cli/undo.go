@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// handleUndoCommand handles the 'undo' command
+func (c *CLI) handleUndoCommand(args []string) error {
+	if len(args) > 0 && args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker undo [--steps N] | --list")
+		fmt.Println("\nUndoes the last N changes (adds, updates, deletes, imports and budget changes),")
+		fmt.Println("newest first. Use --list to see the changes that can be undone and redone.")
+		return nil
+	}
+
+	undoCmd := flag.NewFlagSet("undo", flag.ExitOnError)
+	steps := undoCmd.Int("steps", 1, "Number of changes to undo")
+	list := undoCmd.Bool("list", false, "List the changes that can be undone and redone")
+
+	if err := undoCmd.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		return c.listOperations()
+	}
+
+	ops, err := c.undoService.Undo(*steps)
+	for _, op := range ops {
+		fmt.Printf("Undone: %s\n", op.Description)
+	}
+	return err
+}
+
+// handleRedoCommand handles the 'redo' command
+func (c *CLI) handleRedoCommand(args []string) error {
+	if len(args) > 0 && args[0] == "--help" {
+		fmt.Println("Usage: expense-tracker redo [--steps N]")
+		fmt.Println("\nRedoes the last N undone changes. Making any other change clears what can be redone.")
+		return nil
+	}
+
+	redoCmd := flag.NewFlagSet("redo", flag.ExitOnError)
+	steps := redoCmd.Int("steps", 1, "Number of changes to redo")
+
+	if err := redoCmd.Parse(args); err != nil {
+		return err
+	}
+
+	ops, err := c.undoService.Redo(*steps)
+	for _, op := range ops {
+		fmt.Printf("Redone: %s\n", op.Description)
+	}
+	return err
+}
+
+// listOperations prints the changes that can be undone and redone
+func (c *CLI) listOperations() error {
+	done, undone, err := c.undoService.History()
+	if err != nil {
+		return err
+	}
+
+	if len(done) == 0 && len(undone) == 0 {
+		fmt.Println("No changes recorded")
+		return nil
+	}
+
+	table := newTable(os.Stdout)
+	fmt.Fprintln(table, "Step\tTime\tChange")
+	printOperations := func(label string, ops []models.Operation) {
+		for i, op := range ops {
+			fmt.Fprintf(table, "%s %d\t%s\t%s\n", label, i+1, op.Time.Local().Format("2006-01-02 15:04"), op.Description)
+		}
+	}
+	printOperations("redo", undone)
+	printOperations("undo", done)
+
+	return table.Flush()
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CategorySeparator separates the levels of a category path such as "Food/Groceries"
+const CategorySeparator = "/"
+
+// Category is an entry in the category catalog. Its name is the full path
+// from the top-level category, such as "Food/Groceries".
+type Category struct {
+	Name string `json:"name"`
+}
+
+// CategoryCatalog is the list of known categories. In strict mode new
+// expenses, budgets and recurring rules must use one of them.
+type CategoryCatalog struct {
+	Strict     bool       `json:"strict"`
+	Categories []Category `json:"categories"`
+}
+
+// NormalizeCategory trims the spaces around a category and each level of its
+// path. It returns an empty string for an empty category.
+func NormalizeCategory(name string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", nil
+	}
+
+	parts := strings.Split(name, CategorySeparator)
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+		if parts[i] == "" {
+			return "", fmt.Errorf("invalid category %q, levels cannot be empty", name)
+		}
+	}
+	return strings.Join(parts, CategorySeparator), nil
+}
+
+// CategoryParent returns the parent of a category, or an empty string for a
+// top-level category
+func CategoryParent(name string) string {
+	i := strings.LastIndex(name, CategorySeparator)
+	if i == -1 {
+		return ""
+	}
+	return name[:i]
+}
+
+// CategoryDepth returns the number of parents a category has
+func CategoryDepth(name string) int {
+	return strings.Count(name, CategorySeparator)
+}
+
+// WithinCategory reports whether name is ancestor or one of its
+// subcategories, ignoring case
+func WithinCategory(name, ancestor string) bool {
+	if len(name) == len(ancestor) {
+		return strings.EqualFold(name, ancestor)
+	}
+	prefix := ancestor + CategorySeparator
+	return len(name) > len(prefix) && strings.EqualFold(name[:len(prefix)], prefix)
+}
+
+// CompareCategories orders categories level by level ignoring case, so that
+// subcategories sort directly after their parent
+func CompareCategories(a, b string) int {
+	return slices.Compare(
+		strings.Split(strings.ToLower(a), CategorySeparator),
+		strings.Split(strings.ToLower(b), CategorySeparator))
+}
+
+// Lookup returns the catalog's spelling of a category, matched ignoring case
+func (c CategoryCatalog) Lookup(name string) (string, bool) {
+	for _, category := range c.Categories {
+		if strings.EqualFold(category.Name, name) {
+			return category.Name, true
+		}
+	}
+	return "", false
+}
+
+// Equal reports whether two catalogs hold the same categories and mode
+func (c CategoryCatalog) Equal(other CategoryCatalog) bool {
+	return c.Strict == other.Strict && slices.Equal(c.Categories, other.Categories)
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CategoryRule assigns a category to expenses that are entered without one.
+// A rule matches an expense when all of its conditions hold; it must have at
+// least one. Text conditions ignore case, and Min and Max are compared
+// against the amount in the expense's own currency.
+type CategoryRule struct {
+	ID       int    `json:"id"`
+	Category string `json:"category"`
+	Contains string `json:"contains,omitempty"` // Text the description contains
+	Pattern  string `json:"pattern,omitempty"`  // Regular expression matching the description
+	Merchant string `json:"merchant,omitempty"` // Text the merchant contains
+	Min      *Money `json:"min,omitempty"`
+	Max      *Money `json:"max,omitempty"`
+}
+
+// Validate checks that the rule has a category and at least one valid condition
+func (r CategoryRule) Validate() error {
+	if strings.TrimSpace(r.Category) == "" {
+		return errors.New("category cannot be empty")
+	}
+	if r.Contains == "" && r.Pattern == "" && r.Merchant == "" && r.Min == nil && r.Max == nil {
+		return errors.New("a rule needs at least one condition")
+	}
+	if _, err := compileRulePattern(r.Pattern); err != nil {
+		return err
+	}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return errors.New("minimum amount cannot be greater than the maximum")
+	}
+	return nil
+}
+
+// Conditions describes the conditions of the rule, such as
+// `description contains "coffee", amount at most 10.00`
+func (r CategoryRule) Conditions() string {
+	var conditions []string
+	if r.Contains != "" {
+		conditions = append(conditions, fmt.Sprintf("description contains %q", r.Contains))
+	}
+	if r.Pattern != "" {
+		conditions = append(conditions, fmt.Sprintf("description matches /%s/", r.Pattern))
+	}
+	if r.Merchant != "" {
+		conditions = append(conditions, fmt.Sprintf("merchant contains %q", r.Merchant))
+	}
+	switch {
+	case r.Min != nil && r.Max != nil:
+		conditions = append(conditions, fmt.Sprintf("amount %s to %s", *r.Min, *r.Max))
+	case r.Min != nil:
+		conditions = append(conditions, fmt.Sprintf("amount at least %s", *r.Min))
+	case r.Max != nil:
+		conditions = append(conditions, fmt.Sprintf("amount at most %s", *r.Max))
+	}
+	return strings.Join(conditions, ", ")
+}
+
+// compileRulePattern compiles the regular expression of a rule, matching
+// case-insensitively. An empty pattern yields nil.
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	compiled, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return compiled, nil
+}
+
+// CategoryRules evaluates a list of rules in order, with their patterns
+// compiled once
+type CategoryRules struct {
+	rules    []CategoryRule
+	patterns []*regexp.Regexp
+}
+
+// NewCategoryRules prepares rules for matching
+func NewCategoryRules(rules []CategoryRule) (CategoryRules, error) {
+	set := CategoryRules{rules: rules, patterns: make([]*regexp.Regexp, len(rules))}
+	for i, rule := range rules {
+		pattern, err := compileRulePattern(rule.Pattern)
+		if err != nil {
+			return CategoryRules{}, fmt.Errorf("rule %d: %w", rule.ID, err)
+		}
+		set.patterns[i] = pattern
+	}
+	return set, nil
+}
+
+// Match returns the first rule matching the expense
+func (s CategoryRules) Match(expense Expense) (CategoryRule, bool) {
+	for i := range s.rules {
+		if s.matches(i, expense) {
+			return s.rules[i], true
+		}
+	}
+	return CategoryRule{}, false
+}
+
+// MatchAll returns every rule matching the expense, in order
+func (s CategoryRules) MatchAll(expense Expense) []CategoryRule {
+	var matched []CategoryRule
+	for i := range s.rules {
+		if s.matches(i, expense) {
+			matched = append(matched, s.rules[i])
+		}
+	}
+	return matched
+}
+
+// matches reports whether the i-th rule matches the expense
+func (s CategoryRules) matches(i int, expense Expense) bool {
+	rule := s.rules[i]
+	if rule.Contains != "" && !containsFold(expense.Description, rule.Contains) {
+		return false
+	}
+	if s.patterns[i] != nil && !s.patterns[i].MatchString(expense.Description) {
+		return false
+	}
+	if rule.Merchant != "" && !containsFold(expense.Merchant, rule.Merchant) {
+		return false
+	}
+	if rule.Min != nil && expense.Amount < *rule.Min {
+		return false
+	}
+	if rule.Max != nil && expense.Amount > *rule.Max {
+		return false
+	}
+	return true
+}
+
+// containsFold reports whether substr is within s, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,107 @@
+package models
+
+import "testing"
+
+// money returns a pointer to the amount units.cents, for rule bounds
+func money(units, cents int64) *Money {
+	m := NewMoney(units, cents)
+	return &m
+}
+
+func TestCategoryRulesMatch(t *testing.T) {
+	rules := []CategoryRule{
+		{ID: 1, Category: "Transport", Merchant: "uber"},
+		{ID: 2, Category: "Food/Coffee", Contains: "coffee", Max: money(10, 0)},
+		{ID: 3, Category: "Food", Pattern: `^(lunch|dinner)\b`},
+		{ID: 4, Category: "Big", Min: money(1000, 0)},
+		{ID: 5, Category: "Catering", Contains: "coffee"},
+	}
+	set, err := NewCategoryRules(rules)
+	if err != nil {
+		t.Fatalf("NewCategoryRules failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expense  Expense
+		wantRule int // Zero when no rule matches
+		wantAll  []int
+	}{
+		{name: "merchant ignoring case", expense: Expense{Description: "Ride home", Merchant: "UBER *TRIP"}, wantRule: 1, wantAll: []int{1}},
+		{name: "merchant missing", expense: Expense{Description: "Ride home"}, wantRule: 0},
+		{name: "contains within the maximum", expense: Expense{Description: "Morning Coffee", Amount: NewMoney(4, 50)}, wantRule: 2, wantAll: []int{2, 5}},
+		{name: "contains at the maximum", expense: Expense{Description: "coffee", Amount: NewMoney(10, 0)}, wantRule: 2, wantAll: []int{2, 5}},
+		{name: "contains above the maximum", expense: Expense{Description: "Coffee beans", Amount: NewMoney(10, 1)}, wantRule: 5, wantAll: []int{5}},
+		{name: "pattern", expense: Expense{Description: "Dinner with Sam", Amount: NewMoney(30, 0)}, wantRule: 3, wantAll: []int{3}},
+		{name: "pattern needs a word boundary", expense: Expense{Description: "Lunchbox", Amount: NewMoney(8, 0)}, wantRule: 0},
+		{name: "pattern anchored at the start", expense: Expense{Description: "Team lunch", Amount: NewMoney(8, 0)}, wantRule: 0},
+		{name: "minimum", expense: Expense{Description: "Laptop", Amount: NewMoney(1000, 0)}, wantRule: 4, wantAll: []int{4}},
+		{name: "below the minimum", expense: Expense{Description: "Laptop", Amount: NewMoney(999, 99)}, wantRule: 0},
+		{name: "first rule wins", expense: Expense{Description: "Coffee", Merchant: "Uber Eats", Amount: NewMoney(5, 0)}, wantRule: 1, wantAll: []int{1, 2, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := set.Match(tt.expense)
+			if ok != (tt.wantRule != 0) || rule.ID != tt.wantRule {
+				t.Errorf("Match returned rule %d, %v, want rule %d", rule.ID, ok, tt.wantRule)
+			}
+
+			var all []int
+			for _, rule := range set.MatchAll(tt.expense) {
+				all = append(all, rule.ID)
+			}
+			if len(all) != len(tt.wantAll) {
+				t.Fatalf("MatchAll returned rules %v, want %v", all, tt.wantAll)
+			}
+			for i := range all {
+				if all[i] != tt.wantAll[i] {
+					t.Fatalf("MatchAll returned rules %v, want %v", all, tt.wantAll)
+				}
+			}
+		})
+	}
+}
+
+func TestCategoryRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    CategoryRule
+		wantErr bool
+	}{
+		{name: "contains", rule: CategoryRule{Category: "Food", Contains: "lunch"}},
+		{name: "amount range", rule: CategoryRule{Category: "Food", Min: money(1, 0), Max: money(1, 0)}},
+		{name: "pattern", rule: CategoryRule{Category: "Food", Pattern: `^lunch`}},
+		{name: "no category", rule: CategoryRule{Contains: "lunch"}, wantErr: true},
+		{name: "blank category", rule: CategoryRule{Category: "  ", Contains: "lunch"}, wantErr: true},
+		{name: "no condition", rule: CategoryRule{Category: "Food"}, wantErr: true},
+		{name: "invalid pattern", rule: CategoryRule{Category: "Food", Pattern: `(lunch`}, wantErr: true},
+		{name: "minimum above maximum", rule: CategoryRule{Category: "Food", Min: money(5, 0), Max: money(4, 99)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCategoryRuleConditions(t *testing.T) {
+	tests := []struct {
+		rule CategoryRule
+		want string
+	}{
+		{rule: CategoryRule{Contains: "coffee", Max: money(10, 0)}, want: `description contains "coffee", amount at most 10.00`},
+		{rule: CategoryRule{Pattern: `^lunch`, Merchant: "cafe"}, want: `description matches /^lunch/, merchant contains "cafe"`},
+		{rule: CategoryRule{Min: money(5, 0), Max: money(20, 50)}, want: "amount 5.00 to 20.50"},
+		{rule: CategoryRule{Min: money(100, 0)}, want: "amount at least 100.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rule.Conditions(); got != tt.want {
+			t.Errorf("Conditions() = %q, want %q", got, tt.want)
+		}
+	}
+}
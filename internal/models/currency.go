@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// DefaultCurrency is the currency of expenses recorded without one, which
+// older versions of the application always displayed in dollars
+const DefaultCurrency = "USD"
+
+// currencySymbols maps currency codes to the symbol printed before amounts
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+// NormalizeCurrency validates an ISO 4217 currency code and returns it in upper case.
+// An empty code yields DefaultCurrency.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency, nil
+	}
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency code %q, expected three letters such as USD", code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("invalid currency code %q, expected three letters such as USD", code)
+		}
+	}
+	return code, nil
+}
+
+// Format formats the amount in the given currency, e.g. "$12.50" or "UGX 12000.00"
+func (m Money) Format(currency string) string {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if symbol, ok := currencySymbols[currency]; ok {
+		if m < 0 {
+			return "-" + symbol + (-m).String()
+		}
+		return symbol + m.String()
+	}
+	return currency + " " + m.String()
+}
+
+// ExchangeRate is the value of one unit of the reference currency (EUR, as
+// published by the European Central Bank) in Currency on Date
+type ExchangeRate struct {
+	Date     time.Time
+	Currency string
+	Rate     *big.Rat
+}
@@ -6,33 +6,115 @@ import (
 )
 
 type Expense struct {
-	ID          int       `json:"id"`
-	Description string    `json:"description"`
-	Amount      float64   `json:"amount"`
-	Category    string    `json:"category,omitempty"` // Optional for basic functionality
-	Date        time.Time `json:"date"`
+	ID          int        `json:"id"`
+	Description string     `json:"description"`
+	Amount      Money      `json:"amount"`
+	Currency    string     `json:"currency,omitempty"` // Empty for DefaultCurrency
+	Category    string     `json:"category,omitempty"` // Optional for basic functionality
+	Tags        []string   `json:"tags,omitempty"`     // Lowercase, see NormalizeTag
+	Merchant    string     `json:"merchant,omitempty"` // Who was paid, such as the payee on a bank statement
+	Date        time.Time  `json:"date"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"` // Set while the expense is in the trash
+}
+
+// CurrencyCode returns the currency of the expense, falling back to DefaultCurrency
+func (e Expense) CurrencyCode() string {
+	if e.Currency == "" {
+		return DefaultCurrency
+	}
+	return e.Currency
 }
 
 // String returns a formatted string representation of the expense
 func (e Expense) String() string {
-	return fmt.Sprintf("%d\t%s\t%s\t$%.2f",
+	return fmt.Sprintf("%d\t%s\t%s\t%s",
 		e.ID,
 		e.Date.Format("2006-01-02"),
 		e.Description,
-		e.Amount)
+		e.Amount.Format(e.CurrencyCode()))
 }
 
+// ExpenseSummary aggregates expenses. TotalAmount, CategoryTotals,
+// CategoryRollups and TagTotals are in Currency, while CurrencyTotals holds
+// the unconverted totals per original currency. CategoryTotals holds the
+// amount booked directly on each category and CategoryRollups the total of
+// each parent category including its subcategories. An expense counts towards
+// the total of each of its tags, so tag totals can add up to more than TotalAmount.
 type ExpenseSummary struct {
-	TotalAmount    float64            `json:"totalAmount"`
-	CategoryTotals map[string]float64 `json:"categoryTotals,omitempty"`
-	ExpenseCount   int                `json:"expenseCount"`
-	Month          time.Month         `json:"month,omitempty"`
-	Year           int                `json:"year,omitempty"`
+	TotalAmount     Money            `json:"totalAmount"`
+	Currency        string           `json:"currency,omitempty"`
+	CategoryTotals  map[string]Money `json:"categoryTotals,omitempty"`
+	CategoryRollups map[string]Money `json:"categoryRollups,omitempty"`
+	CurrencyTotals  map[string]Money `json:"currencyTotals,omitempty"`
+	TagTotals       map[string]Money `json:"tagTotals,omitempty"`
+	ExpenseCount    int              `json:"expenseCount"`
+	Month           time.Month       `json:"month,omitempty"`
+	Year            int              `json:"year,omitempty"`
+}
+
+// CategoryTotal returns the total of a category including its
+// subcategories, matching names ignoring case
+func (s ExpenseSummary) CategoryTotal(category string) Money {
+	var total Money
+	for name, amount := range s.CategoryTotals {
+		if WithinCategory(name, category) {
+			total += amount
+		}
+	}
+	return total
 }
 
 func (s ExpenseSummary) String() string {
 	if s.Month > 0 {
-		return fmt.Sprintf("Total expenses for %s: $%.2f", s.Month.String(), s.TotalAmount)
+		return fmt.Sprintf("Total expenses for %s: %s", s.Month.String(), s.TotalAmount.Format(s.Currency))
+	}
+	return fmt.Sprintf("Total expenses: %s", s.TotalAmount.Format(s.Currency))
+}
+
+// Budget represents a monthly budget, either an overall cap or a limit for one category.
+// Amounts are in the base currency.
+type Budget struct {
+	Month    time.Month `json:"month"`
+	Year     int        `json:"year"`
+	Category string     `json:"category,omitempty"` // Empty for the overall monthly cap
+	Amount   Money      `json:"amount"`
+}
+
+// String returns a formatted string representation of the budget
+func (b Budget) String() string {
+	category := b.Category
+	if category == "" {
+		category = "(overall)"
+	}
+	return fmt.Sprintf("%s %d\t%s\t%s", b.Month.String(), b.Year, category, b.Amount)
+}
+
+// BudgetStatus reports spending against a single budget
+type BudgetStatus struct {
+	Category  string `json:"category,omitempty"` // Empty for the overall monthly cap
+	Budget    Money  `json:"budget"`
+	Spent     Money  `json:"spent"`
+	Remaining Money  `json:"remaining"`
+	Exceeded  bool   `json:"exceeded"`
+}
+
+// BudgetReport reports spending against all budgets set for a month
+type BudgetReport struct {
+	Month      time.Month     `json:"month"`
+	Year       int            `json:"year"`
+	Overall    *BudgetStatus  `json:"overall,omitempty"`
+	Categories []BudgetStatus `json:"categories,omitempty"`
+}
+
+// Exceeded reports whether the overall cap or any category budget was exceeded
+func (r BudgetReport) Exceeded() bool {
+	if r.Overall != nil && r.Overall.Exceeded {
+		return true
+	}
+	for _, status := range r.Categories {
+		if status.Exceeded {
+			return true
+		}
 	}
-	return fmt.Sprintf("Total expenses: $%.2f", s.TotalAmount)
+	return false
 }
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact monetary amount stored as an integer number of minor units (cents).
+// It is serialized to JSON as a decimal string such as "12.50" so that amounts
+// round-trip without floating point error.
+type Money int64
+
+// minorUnitsPerUnit is the number of minor units in one major unit
+const minorUnitsPerUnit = 100
+
+// NewMoney creates an amount from whole units and minor units, e.g. NewMoney(12, 50) is 12.50
+func NewMoney(units int64, cents int64) Money {
+	return Money(units*minorUnitsPerUnit + cents)
+}
+
+// MoneyFromFloat converts a floating point amount to Money, rounding to the nearest minor unit.
+// It exists for reading legacy data and should not be used for new input.
+func MoneyFromFloat(amount float64) Money {
+	return Money(math.Round(amount * minorUnitsPerUnit))
+}
+
+// ParseMoney parses a decimal amount such as "12", "12.5" or "-3.75" exactly.
+// At most two decimal places are accepted.
+func ParseMoney(value string) (Money, error) {
+	return parseMoney(value, false)
+}
+
+// parseMoney parses a decimal amount, rounding extra decimal places half away
+// from zero when round is set and rejecting them otherwise
+func parseMoney(value string, round bool) (Money, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return 0, errors.New("amount cannot be empty")
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	roundUp := false
+	if len(frac) > 2 {
+		if !round {
+			return 0, fmt.Errorf("invalid amount %q: at most two decimal places are allowed", value)
+		}
+		roundUp = frac[2] >= '5'
+		frac = frac[:2]
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/minorUnitsPerUnit-1 {
+		return 0, fmt.Errorf("amount %q is out of range", value)
+	}
+	cents, _ := strconv.ParseInt(frac, 10, 64)
+
+	m := NewMoney(units, cents)
+	if roundUp {
+		m++
+	}
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// isDigits reports whether s consists only of ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount with two decimal places, e.g. "12.50"
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/minorUnitsPerUnit, v%minorUnitsPerUnit)
+}
+
+// Float64 returns the amount as a floating point number of major units
+func (m Money) Float64() float64 {
+	return float64(m) / minorUnitsPerUnit
+}
+
+// Set parses the amount from a string so that *Money can be used as a flag.Value
+func (m *Money) Set(value string) error {
+	parsed, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MarshalJSON encodes the amount as a decimal string
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON decodes a decimal string, or a JSON number as written by older
+// versions of the application, without going through float64
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(s)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	// Legacy numeric amount; exponents only appear for values no one spends
+	text := string(data)
+	if strings.ContainsAny(text, "eE") {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("invalid amount %s", text)
+		}
+		*m = MoneyFromFloat(f)
+		return nil
+	}
+	parsed, err := parseMoney(text, true)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
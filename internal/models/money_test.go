@@ -0,0 +1,145 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Money
+		wantErr bool
+	}{
+		{input: "12", want: NewMoney(12, 0)},
+		{input: "12.5", want: NewMoney(12, 50)},
+		{input: "12.50", want: NewMoney(12, 50)},
+		{input: "0.01", want: 1},
+		{input: ".75", want: 75},
+		{input: "3.", want: NewMoney(3, 0)},
+		{input: "-3.75", want: -NewMoney(3, 75)},
+		{input: "+4.20", want: NewMoney(4, 20)},
+		{input: "  7.10 ", want: NewMoney(7, 10)},
+		{input: "0.1", want: 10},
+		{input: "", wantErr: true},
+		{input: ".", wantErr: true},
+		{input: "-", wantErr: true},
+		{input: "1.234", wantErr: true},
+		{input: "1,50", wantErr: true},
+		{input: "1e3", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "1.2.3", wantErr: true},
+		{input: "99999999999999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMoney(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMoney(%q) = %v, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: 0, want: "0.00"},
+		{money: 5, want: "0.05"},
+		{money: NewMoney(12, 50), want: "12.50"},
+		{money: -NewMoney(3, 7), want: "-3.07"},
+		{money: -5, want: "-0.05"},
+		{money: NewMoney(1000000, 0), want: "1000000.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tt.money), got, tt.want)
+		}
+	}
+}
+
+func TestMoneyFromFloat(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   Money
+	}{
+		{amount: 0.1 + 0.2, want: 30},
+		{amount: 19.99, want: NewMoney(19, 99)},
+		{amount: 1.005, want: NewMoney(1, 0)},
+		{amount: -2.5, want: -NewMoney(2, 50)},
+	}
+
+	for _, tt := range tests {
+		if got := MoneyFromFloat(tt.amount); got != tt.want {
+			t.Errorf("MoneyFromFloat(%v) = %d, want %d", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Money
+		wantErr bool
+	}{
+		{name: "string", input: `"12.50"`, want: NewMoney(12, 50)},
+		{name: "negative string", input: `"-0.99"`, want: -99},
+		{name: "legacy number", input: `12.5`, want: NewMoney(12, 50)},
+		{name: "legacy number rounded", input: `0.125`, want: 13},
+		{name: "legacy float noise", input: `0.30000000000000004`, want: 30},
+		{name: "legacy exponent", input: `1.5e2`, want: NewMoney(150, 0)},
+		{name: "null", input: `null`, want: 0},
+		{name: "string with three decimals", input: `"1.234"`, wantErr: true},
+		{name: "invalid string", input: `"twelve"`, wantErr: true},
+		{name: "boolean", input: `true`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("unmarshaling %s gave %v, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unmarshaling %s failed: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("unmarshaling %s gave %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	for _, m := range []Money{0, 1, -1, NewMoney(12, 50), -NewMoney(1234, 56)} {
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("marshaling %d failed: %v", m, err)
+		}
+		var got Money
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("unmarshaling %s failed: %v", data, err)
+		}
+		if got != m {
+			t.Errorf("round trip of %d through %s gave %d", m, data, got)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Operation is a change made through the services that can be undone and
+// redone. It records every expense and budget it touched, and the category
+// catalog if it changed, before and after.
+type Operation struct {
+	Time        time.Time       `json:"time"`
+	Description string          `json:"description"`
+	Expenses    []ExpenseChange `json:"expenses,omitempty"`
+	Budgets     []BudgetChange  `json:"budgets,omitempty"`
+	Catalog     *CatalogChange  `json:"catalog,omitempty"`
+}
+
+// ExpenseChange is the state of an expense before and after an operation.
+// Before is nil for an added expense and After is nil for a deleted one.
+type ExpenseChange struct {
+	Before *Expense `json:"before,omitempty"`
+	After  *Expense `json:"after,omitempty"`
+}
+
+// BudgetChange is the state of a budget before and after an operation.
+// Before is nil for a new budget and After is nil for a deleted one.
+type BudgetChange struct {
+	Before *Budget `json:"before,omitempty"`
+	After  *Budget `json:"after,omitempty"`
+}
+
+// CatalogChange is the category catalog before and after an operation
+type CatalogChange struct {
+	Before CategoryCatalog `json:"before"`
+	After  CategoryCatalog `json:"after"`
+}
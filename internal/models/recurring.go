@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Frequency is how often a recurring expense repeats
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "daily"
+	FrequencyWeekly  Frequency = "weekly"
+	FrequencyMonthly Frequency = "monthly"
+	FrequencyYearly  Frequency = "yearly"
+)
+
+// ParseFrequency parses a frequency name, ignoring case
+func ParseFrequency(value string) (Frequency, error) {
+	switch frequency := Frequency(strings.ToLower(strings.TrimSpace(value))); frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+		return frequency, nil
+	default:
+		return "", fmt.Errorf("invalid frequency %q, expected daily, weekly, monthly or yearly", value)
+	}
+}
+
+// RecurringRule describes an expense that repeats on a schedule, such as rent
+// or a subscription. Occurrences start on Start and repeat every Interval
+// days, weeks, months or years until Until or until Count occurrences have
+// been scheduled, whichever comes first.
+type RecurringRule struct {
+	ID          int        `json:"id"`
+	Description string     `json:"description"`
+	Amount      Money      `json:"amount"`
+	Currency    string     `json:"currency,omitempty"` // Empty for the base currency
+	Category    string     `json:"category,omitempty"`
+	Frequency   Frequency  `json:"frequency"`
+	Interval    int        `json:"interval"`
+	Start       time.Time  `json:"start"`
+	Until       *time.Time `json:"until,omitempty"` // Last day an occurrence may fall on
+	Count       int        `json:"count,omitempty"` // Zero for no limit
+	Paused      bool       `json:"paused,omitempty"`
+
+	// Generated is the number of occurrences already turned into expenses or
+	// skipped while paused. Pending lists the dates of occurrences that are
+	// being turned into expenses, so an interrupted run can be completed
+	// without creating any of them twice.
+	Generated int         `json:"generated"`
+	Pending   []time.Time `json:"pending,omitempty"`
+}
+
+// Occurrence returns the date of the n-th occurrence, counting from zero.
+// Monthly and yearly rules that start late in the month fall on the last day
+// of shorter months, so a rule starting on January 31 repeats on February 28.
+func (r RecurringRule) Occurrence(n int) time.Time {
+	interval := max(r.Interval, 1)
+
+	switch r.Frequency {
+	case FrequencyDaily:
+		return r.Start.AddDate(0, 0, n*interval)
+	case FrequencyWeekly:
+		return r.Start.AddDate(0, 0, 7*n*interval)
+	case FrequencyYearly:
+		return addMonthsClamped(r.Start, 12*n*interval)
+	default:
+		return addMonthsClamped(r.Start, n*interval)
+	}
+}
+
+// addMonthsClamped adds months to t, keeping the day of the month but
+// limiting it to the length of the resulting month
+func addMonthsClamped(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+// Scheduled reports whether the n-th occurrence is within the rule's end date and count
+func (r RecurringRule) Scheduled(n int) bool {
+	if r.Count > 0 && n >= r.Count {
+		return false
+	}
+	if r.Until != nil && r.Occurrence(n).After(*r.Until) {
+		return false
+	}
+	return true
+}
+
+// Next returns the date of the next occurrence that has not been generated,
+// and false when the rule has ended
+func (r RecurringRule) Next() (time.Time, bool) {
+	n := r.Generated + len(r.Pending)
+	if !r.Scheduled(n) {
+		return time.Time{}, false
+	}
+	return r.Occurrence(n), true
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// day returns midnight UTC on the given date
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRecurringRuleOccurrence(t *testing.T) {
+	tests := []struct {
+		name string
+		rule RecurringRule
+		want []time.Time
+	}{
+		{
+			name: "monthly from the 31st",
+			rule: RecurringRule{Frequency: FrequencyMonthly, Start: day(2025, time.January, 31)},
+			want: []time.Time{
+				day(2025, time.January, 31), day(2025, time.February, 28), day(2025, time.March, 31),
+				day(2025, time.April, 30), day(2025, time.May, 31),
+			},
+		},
+		{
+			name: "monthly from the 31st in a leap year",
+			rule: RecurringRule{Frequency: FrequencyMonthly, Start: day(2024, time.January, 31)},
+			want: []time.Time{day(2024, time.January, 31), day(2024, time.February, 29), day(2024, time.March, 31)},
+		},
+		{
+			name: "monthly from the 30th across the year end",
+			rule: RecurringRule{Frequency: FrequencyMonthly, Start: day(2024, time.December, 30)},
+			want: []time.Time{day(2024, time.December, 30), day(2025, time.January, 30), day(2025, time.February, 28), day(2025, time.March, 30)},
+		},
+		{
+			name: "every two months from the 31st",
+			rule: RecurringRule{Frequency: FrequencyMonthly, Interval: 2, Start: day(2025, time.August, 31)},
+			want: []time.Time{day(2025, time.August, 31), day(2025, time.October, 31), day(2025, time.December, 31), day(2026, time.February, 28)},
+		},
+		{
+			name: "yearly from a leap day",
+			rule: RecurringRule{Frequency: FrequencyYearly, Start: day(2024, time.February, 29)},
+			want: []time.Time{
+				day(2024, time.February, 29), day(2025, time.February, 28), day(2026, time.February, 28),
+				day(2027, time.February, 28), day(2028, time.February, 29),
+			},
+		},
+		{
+			name: "weekly across a leap day",
+			rule: RecurringRule{Frequency: FrequencyWeekly, Start: day(2024, time.February, 22)},
+			want: []time.Time{day(2024, time.February, 22), day(2024, time.February, 29), day(2024, time.March, 7)},
+		},
+		{
+			name: "every three days",
+			rule: RecurringRule{Frequency: FrequencyDaily, Interval: 3, Start: day(2025, time.February, 25)},
+			want: []time.Time{day(2025, time.February, 25), day(2025, time.February, 28), day(2025, time.March, 3)},
+		},
+		{
+			name: "zero interval repeats every period",
+			rule: RecurringRule{Frequency: FrequencyDaily, Start: day(2025, time.March, 1)},
+			want: []time.Time{day(2025, time.March, 1), day(2025, time.March, 2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for n, want := range tt.want {
+				if got := tt.rule.Occurrence(n); !got.Equal(want) {
+					t.Errorf("occurrence %d = %s, want %s", n, got.Format(time.DateOnly), want.Format(time.DateOnly))
+				}
+			}
+		})
+	}
+}
+
+func TestRecurringRuleNext(t *testing.T) {
+	until := day(2025, time.April, 30)
+
+	tests := []struct {
+		name   string
+		rule   RecurringRule
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "first occurrence",
+			rule:   RecurringRule{Frequency: FrequencyMonthly, Start: day(2025, time.January, 31)},
+			want:   day(2025, time.January, 31),
+			wantOK: true,
+		},
+		{
+			name:   "after generated and pending occurrences",
+			rule:   RecurringRule{Frequency: FrequencyMonthly, Start: day(2025, time.January, 31), Generated: 1, Pending: []time.Time{day(2025, time.February, 28)}},
+			want:   day(2025, time.March, 31),
+			wantOK: true,
+		},
+		{
+			name:   "last occurrence on the end date",
+			rule:   RecurringRule{Frequency: FrequencyMonthly, Start: day(2025, time.January, 31), Until: &until, Generated: 3},
+			want:   day(2025, time.April, 30),
+			wantOK: true,
+		},
+		{
+			name: "past the end date",
+			rule: RecurringRule{Frequency: FrequencyMonthly, Start: day(2025, time.January, 31), Until: &until, Generated: 4},
+		},
+		{
+			name:   "within the count",
+			rule:   RecurringRule{Frequency: FrequencyYearly, Start: day(2024, time.February, 29), Count: 2, Generated: 1},
+			want:   day(2025, time.February, 28),
+			wantOK: true,
+		},
+		{
+			name: "count reached",
+			rule: RecurringRule{Frequency: FrequencyYearly, Start: day(2024, time.February, 29), Count: 2, Generated: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.rule.Next()
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("Next() = %s, %v, want %s, %v", got.Format(time.DateOnly), ok, tt.want.Format(time.DateOnly), tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseFrequency(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Frequency
+		wantErr bool
+	}{
+		{input: "monthly", want: FrequencyMonthly},
+		{input: " Weekly ", want: FrequencyWeekly},
+		{input: "YEARLY", want: FrequencyYearly},
+		{input: "daily", want: FrequencyDaily},
+		{input: "fortnightly", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFrequency(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFrequency(%q) = %q, %v, want %q, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
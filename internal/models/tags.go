@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// NormalizeTag lowercases and trims a tag and checks that it is usable. Tags
+// cannot contain whitespace or commas, which separate tags in CSV files.
+func NormalizeTag(tag string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(tag))
+	if normalized == "" {
+		return "", fmt.Errorf("tag cannot be empty")
+	}
+	if strings.ContainsFunc(normalized, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) {
+		return "", fmt.Errorf("invalid tag %q, tags cannot contain spaces or commas", tag)
+	}
+	return normalized, nil
+}
+
+// NormalizeTags normalizes each tag and drops duplicates, keeping the first
+// occurrence of each. It returns nil when there are no tags.
+func NormalizeTags(tags []string) ([]string, error) {
+	var result []string
+	for _, tag := range tags {
+		normalized, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(result, normalized) {
+			result = append(result, normalized)
+		}
+	}
+	return result, nil
+}
+
+// ParseTags splits a comma-separated list of tags, as written in CSV files,
+// and normalizes them
+func ParseTags(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	return NormalizeTags(strings.Split(value, ","))
+}
+
+// HasTag reports whether the expense carries the given tag, ignoring case
+func (e Expense) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
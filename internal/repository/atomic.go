@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backupGenerations is the number of previous versions kept for each data file
+// as <file>.bak.1 (newest) to <file>.bak.N (oldest)
+const backupGenerations = 5
+
+// writeFileAtomic replaces the file at path with data so that a crash or a full
+// disk never leaves a truncated file behind. The data is written to a temporary
+// file in the same directory, synced to disk and renamed over the original.
+// The previous version is kept as the newest backup generation.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if err := rotateBackups(path); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// backupPath returns the path of a backup generation of a data file
+func backupPath(path string, generation int) string {
+	return fmt.Sprintf("%s.bak.%d", path, generation)
+}
+
+// rotateBackups shifts existing backups one generation older, dropping the
+// oldest, and keeps the current contents of path as generation 1
+func rotateBackups(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	for generation := backupGenerations - 1; generation >= 1; generation-- {
+		err := os.Rename(backupPath(path, generation), backupPath(path, generation+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate backups: %w", err)
+		}
+	}
+
+	// A hard link preserves the current version without copying it; the
+	// following rename only replaces the directory entry of path
+	newest := backupPath(path, 1)
+	if err := os.Link(path, newest); err != nil {
+		if err := copyFile(path, newest); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies the contents of src to dst, syncing dst to disk
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// syncDir flushes a directory entry change such as a rename to disk. Not all
+// platforms support syncing directories, so errors are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// recoverCorruptFile checks that the data file at path is readable with the
+// given validate function. If it is not, the newest valid backup generation is
+// restored in its place and the corrupt file is kept with a ".corrupt-<time>"
+// suffix. A warning naming both files is written to standard error.
+func recoverCorruptFile(path string, validate func([]byte) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	corruption := validate(data)
+	if corruption == nil {
+		return nil
+	}
+
+	for generation := 1; generation <= backupGenerations; generation++ {
+		backup := backupPath(path, generation)
+		backupData, err := os.ReadFile(backup)
+		if err != nil || validate(backupData) != nil {
+			continue
+		}
+
+		corruptPath := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102-150405"))
+		if err := os.Rename(path, corruptPath); err != nil {
+			return fmt.Errorf("failed to move corrupt %s aside: %w", filepath.Base(path), err)
+		}
+		// The corrupt file is no longer at path, so this does not rotate it into the backups
+		if err := writeFileAtomic(path, backupData, 0644); err != nil {
+			return fmt.Errorf("failed to restore %s from backup: %w", filepath.Base(path), err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: %s is corrupt (%v); restored the last good backup %s. The corrupt file was kept as %s\n",
+			filepath.Base(path), corruption, filepath.Base(backup), filepath.Base(corruptPath))
+		return nil
+	}
+
+	return fmt.Errorf("%s is corrupt and no valid backup was found: %w", filepath.Base(path), corruption)
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+func TestWriteFileAtomicRotatesBackups(t *testing.T) {
+	tests := []struct {
+		writes      int
+		wantBackups []string // Newest first
+	}{
+		{writes: 1, wantBackups: nil},
+		{writes: 2, wantBackups: []string{"v1"}},
+		{writes: 4, wantBackups: []string{"v3", "v2", "v1"}},
+		{writes: backupGenerations + 1, wantBackups: []string{"v5", "v4", "v3", "v2", "v1"}},
+		{writes: backupGenerations + 3, wantBackups: []string{"v7", "v6", "v5", "v4", "v3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d writes", tt.writes), func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "data.json")
+			for i := 1; i <= tt.writes; i++ {
+				if err := writeFileAtomic(path, []byte(fmt.Sprintf("v%d", i)), 0644); err != nil {
+					t.Fatalf("write %d failed: %v", i, err)
+				}
+			}
+
+			assertFileContent(t, path, fmt.Sprintf("v%d", tt.writes))
+			for i, want := range tt.wantBackups {
+				assertFileContent(t, backupPath(path, i+1), want)
+			}
+			if _, err := os.Stat(backupPath(path, len(tt.wantBackups)+1)); !os.IsNotExist(err) {
+				t.Errorf("backup generation %d exists, want at most %d", len(tt.wantBackups)+1, len(tt.wantBackups))
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if strings.Contains(entry.Name(), ".tmp-") {
+					t.Errorf("temporary file %s was left behind", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomicSetsPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := writeFileAtomic(path, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("permissions = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestRecoverCorruptFile(t *testing.T) {
+	validate := func(data []byte) error {
+		var v map[string]any
+		return json.Unmarshal(data, &v)
+	}
+
+	tests := []struct {
+		name        string
+		current     string
+		backups     []string // Generation 1 first; empty strings are skipped
+		want        string
+		wantErr     bool
+		wantCorrupt bool
+	}{
+		{name: "valid file", current: `{"a": 1}`, backups: []string{`{"a": 0}`}, want: `{"a": 1}`},
+		{name: "newest backup", current: `{"a": `, backups: []string{`{"a": 2}`, `{"a": 1}`}, want: `{"a": 2}`, wantCorrupt: true},
+		{name: "skips corrupt backups", current: `{`, backups: []string{`{`, "", `{"a": 3}`}, want: `{"a": 3}`, wantCorrupt: true},
+		{name: "no valid backup", current: `{`, backups: []string{`[`}, want: `{`, wantErr: true},
+		{name: "no backups", current: ``, want: ``, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "data.json")
+			if err := os.WriteFile(path, []byte(tt.current), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for i, backup := range tt.backups {
+				if backup == "" {
+					continue
+				}
+				if err := os.WriteFile(backupPath(path, i+1), []byte(backup), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := recoverCorruptFile(path, validate)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("recoverCorruptFile returned %v, want error %v", err, tt.wantErr)
+			}
+			assertFileContent(t, path, tt.want)
+
+			corrupt, err := filepath.Glob(path + ".corrupt-*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantCorrupt != (len(corrupt) == 1) {
+				t.Fatalf("corrupt copies %v, want one kept: %v", corrupt, tt.wantCorrupt)
+			}
+			if tt.wantCorrupt {
+				assertFileContent(t, corrupt[0], tt.current)
+			}
+		})
+	}
+}
+
+func TestJSONFileRepositoryRecoversFromBackup(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewJSONFileRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Add(testExpense("Coffee")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Add(testExpense("Lunch")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A torn write leaves the file unreadable; the backup holds the first expense
+	if err := os.WriteFile(filepath.Join(dir, "expenses.json"), []byte(`{"expenses": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err = NewJSONFileRepository(dir)
+	if err != nil {
+		t.Fatalf("NewJSONFileRepository failed: %v", err)
+	}
+	expenses, err := repo.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expenses) != 1 || expenses[0].Description != "Coffee" {
+		t.Errorf("recovered %v, want only the first expense", expenses)
+	}
+}
+
+// testExpense returns an expense with the given description dated in March 2025
+func testExpense(description string) models.Expense {
+	return models.Expense{
+		Description: description,
+		Amount:      models.NewMoney(5, 0),
+		Date:        time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC),
+	}
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// JSONBudgetRepository implements BudgetRepository using a JSON file for storage.
+// Like JSONFileRepository, writes are transactions under an advisory file lock.
+type JSONBudgetRepository struct {
+	filePath    string
+	mutex       sync.RWMutex
+	lockTimeout time.Duration
+}
+
+// NewJSONBudgetRepository creates a new repository that stores budgets in a JSON file
+func NewJSONBudgetRepository(dataDir string) (*JSONBudgetRepository, error) {
+	// Ensure data directory exists
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	repo := &JSONBudgetRepository{
+		filePath:    filepath.Join(dataDir, "budgets.json"),
+		lockTimeout: defaultLockTimeout,
+	}
+
+	lock, err := acquireLock(lockPath(repo.filePath), true, repo.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	// Create file if it doesn't exist
+	if _, err := os.Stat(repo.filePath); os.IsNotExist(err) {
+		if err := repo.writeBudgetsFile([]models.Budget{}); err != nil {
+			return nil, fmt.Errorf("failed to create initial budgets file: %w", err)
+		}
+	} else {
+		// Fall back to the last good backup if the file was damaged
+		if err := recoverCorruptFile(repo.filePath, validateBudgetsFile); err != nil {
+			return nil, err
+		}
+		if err := migrateDataFile(repo.filePath, "budgets"); err != nil {
+			return nil, fmt.Errorf("failed to migrate budgets file: %w", err)
+		}
+	}
+
+	return repo, nil
+}
+
+// budgetsFile is the versioned envelope stored in budgets.json
+type budgetsFile struct {
+	Version int             `json:"version"`
+	Budgets []models.Budget `json:"budgets"`
+}
+
+// validateBudgetsFile checks that data is a readable budgets file
+func validateBudgetsFile(data []byte) error {
+	var file budgetsFile
+	return json.Unmarshal(data, &file)
+}
+
+// loadBudgets reads all budgets from the JSON file under a shared lock
+func (r *JSONBudgetRepository) loadBudgets() ([]models.Budget, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), false, r.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	return r.readBudgetsFile()
+}
+
+// updateBudgets runs a read-modify-write transaction under an exclusive lock.
+// The budgets returned by fn are saved unless it returns an error.
+func (r *JSONBudgetRepository) updateBudgets(fn func(budgets []models.Budget) ([]models.Budget, error)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), true, r.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	budgets, err := r.readBudgetsFile()
+	if err != nil {
+		return err
+	}
+
+	budgets, err = fn(budgets)
+	if err != nil {
+		return err
+	}
+
+	return r.writeBudgetsFile(budgets)
+}
+
+// readBudgetsFile reads all budgets from the JSON file; the caller holds the lock
+func (r *JSONBudgetRepository) readBudgetsFile() ([]models.Budget, error) {
+	file, err := os.ReadFile(r.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read budgets file: %w", err)
+	}
+
+	var data budgetsFile
+	if err := json.Unmarshal(file, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal budgets: %w", err)
+	}
+
+	return data.Budgets, nil
+}
+
+// writeBudgetsFile writes all budgets to the JSON file; the caller holds the lock
+func (r *JSONBudgetRepository) writeBudgetsFile(budgets []models.Budget) error {
+	data := budgetsFile{
+		Version: currentSchemaVersion,
+		Budgets: budgets,
+	}
+
+	fileData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal budgets: %w", err)
+	}
+
+	if err := writeFileAtomic(r.filePath, fileData, 0644); err != nil {
+		return fmt.Errorf("failed to write budgets file: %w", err)
+	}
+
+	return nil
+}
+
+// findBudget returns the index of the budget for the given month, year and category, or -1
+func findBudget(budgets []models.Budget, month time.Month, year int, category string) int {
+	return slices.IndexFunc(budgets, func(b models.Budget) bool {
+		return b.Month == month && b.Year == year && b.Category == category
+	})
+}
+
+// sortBudgets orders budgets by year and month, with the overall cap before category budgets
+func sortBudgets(budgets []models.Budget) {
+	slices.SortFunc(budgets, func(a, b models.Budget) int {
+		if a.Year != b.Year {
+			return a.Year - b.Year
+		}
+		if a.Month != b.Month {
+			return int(a.Month) - int(b.Month)
+		}
+		return strings.Compare(a.Category, b.Category)
+	})
+}
+
+// Set creates the budget for its month, year and category, replacing any existing one
+func (r *JSONBudgetRepository) Set(budget models.Budget) error {
+	return r.updateBudgets(func(budgets []models.Budget) ([]models.Budget, error) {
+		if i := findBudget(budgets, budget.Month, budget.Year, budget.Category); i >= 0 {
+			budgets[i] = budget
+		} else {
+			budgets = append(budgets, budget)
+		}
+		return budgets, nil
+	})
+}
+
+// Get retrieves the budget for a specific month, year and category
+func (r *JSONBudgetRepository) Get(month time.Month, year int, category string) (models.Budget, error) {
+	budgets, err := r.loadBudgets()
+	if err != nil {
+		return models.Budget{}, err
+	}
+
+	if i := findBudget(budgets, month, year, category); i >= 0 {
+		return budgets[i], nil
+	}
+
+	return models.Budget{}, fmt.Errorf("budget %w for specified month and year", ErrNotFound)
+}
+
+// GetByMonth retrieves the overall and category budgets for a specific month and year
+func (r *JSONBudgetRepository) GetByMonth(month time.Month, year int) ([]models.Budget, error) {
+	budgets, err := r.loadBudgets()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.Budget
+	for _, budget := range budgets {
+		if budget.Month == month && budget.Year == year {
+			result = append(result, budget)
+		}
+	}
+	sortBudgets(result)
+
+	return result, nil
+}
+
+// GetAll retrieves all budgets ordered by year and month
+func (r *JSONBudgetRepository) GetAll() ([]models.Budget, error) {
+	budgets, err := r.loadBudgets()
+	if err != nil {
+		return nil, err
+	}
+
+	sortBudgets(budgets)
+
+	return budgets, nil
+}
+
+// Update runs a read-modify-write transaction on all budgets under an exclusive lock
+func (r *JSONBudgetRepository) Update(fn func(budgets []models.Budget) ([]models.Budget, error)) error {
+	return r.updateBudgets(fn)
+}
+
+// Delete removes the budget for a specific month, year and category
+func (r *JSONBudgetRepository) Delete(month time.Month, year int, category string) error {
+	return r.updateBudgets(func(budgets []models.Budget) ([]models.Budget, error) {
+		i := findBudget(budgets, month, year, category)
+		if i == -1 {
+			return nil, fmt.Errorf("budget %w for specified month and year", ErrNotFound)
+		}
+
+		return slices.Delete(budgets, i, i+1), nil
+	})
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// JSONCategoryRuleRepository implements CategoryRuleRepository using a JSON file for storage.
+// Like JSONFileRepository, writes are transactions under an advisory file lock.
+type JSONCategoryRuleRepository struct {
+	filePath    string
+	mutex       sync.RWMutex
+	lockTimeout time.Duration
+}
+
+// categoryRulesFile is the versioned envelope stored in category_rules.json
+type categoryRulesFile struct {
+	Version int                   `json:"version"`
+	Rules   []models.CategoryRule `json:"rules"`
+}
+
+// NewJSONCategoryRuleRepository creates a new repository that stores category rules in a JSON file
+func NewJSONCategoryRuleRepository(dataDir string) (*JSONCategoryRuleRepository, error) {
+	// Ensure data directory exists
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	repo := &JSONCategoryRuleRepository{
+		filePath:    filepath.Join(dataDir, "category_rules.json"),
+		lockTimeout: defaultLockTimeout,
+	}
+
+	lock, err := acquireLock(lockPath(repo.filePath), true, repo.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	// Create file if it doesn't exist
+	if _, err := os.Stat(repo.filePath); os.IsNotExist(err) {
+		if err := repo.writeCategoryRulesFile([]models.CategoryRule{}); err != nil {
+			return nil, fmt.Errorf("failed to create initial category rules file: %w", err)
+		}
+	} else {
+		// Fall back to the last good backup if the file was damaged
+		if err := recoverCorruptFile(repo.filePath, validateCategoryRulesFile); err != nil {
+			return nil, err
+		}
+		if err := migrateDataFile(repo.filePath, "rules"); err != nil {
+			return nil, fmt.Errorf("failed to migrate category rules file: %w", err)
+		}
+	}
+
+	return repo, nil
+}
+
+// validateCategoryRulesFile checks that data is a readable category rules file
+func validateCategoryRulesFile(data []byte) error {
+	var file categoryRulesFile
+	return json.Unmarshal(data, &file)
+}
+
+// updateCategoryRules runs a read-modify-write transaction under an exclusive lock
+func (r *JSONCategoryRuleRepository) updateCategoryRules(fn func(rules []models.CategoryRule) ([]models.CategoryRule, error)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), true, r.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	rules, err := r.readCategoryRulesFile()
+	if err != nil {
+		return err
+	}
+
+	rules, err = fn(rules)
+	if err != nil {
+		return err
+	}
+
+	return r.writeCategoryRulesFile(rules)
+}
+
+// readCategoryRulesFile reads all rules from the JSON file; the caller holds the lock
+func (r *JSONCategoryRuleRepository) readCategoryRulesFile() ([]models.CategoryRule, error) {
+	file, err := os.ReadFile(r.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read category rules file: %w", err)
+	}
+
+	var data categoryRulesFile
+	if err := json.Unmarshal(file, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal category rules: %w", err)
+	}
+
+	return data.Rules, nil
+}
+
+// writeCategoryRulesFile writes all rules to the JSON file; the caller holds the lock
+func (r *JSONCategoryRuleRepository) writeCategoryRulesFile(rules []models.CategoryRule) error {
+	data := categoryRulesFile{
+		Version: currentSchemaVersion,
+		Rules:   rules,
+	}
+
+	fileData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal category rules: %w", err)
+	}
+
+	if err := writeFileAtomic(r.filePath, fileData, 0644); err != nil {
+		return fmt.Errorf("failed to write category rules file: %w", err)
+	}
+
+	return nil
+}
+
+// Add stores a new rule after the existing ones, under the next free ID, and returns that ID
+func (r *JSONCategoryRuleRepository) Add(rule models.CategoryRule) (int, error) {
+	err := r.updateCategoryRules(func(rules []models.CategoryRule) ([]models.CategoryRule, error) {
+		rule.ID = 1
+		for _, existing := range rules {
+			if existing.ID >= rule.ID {
+				rule.ID = existing.ID + 1
+			}
+		}
+		return append(rules, rule), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return rule.ID, nil
+}
+
+// GetAll retrieves all rules in the order they are evaluated
+func (r *JSONCategoryRuleRepository) GetAll() ([]models.CategoryRule, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), false, r.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	return r.readCategoryRulesFile()
+}
+
+// Delete removes a rule by its ID. Expenses it already categorized keep their category.
+func (r *JSONCategoryRuleRepository) Delete(id int) error {
+	return r.updateCategoryRules(func(rules []models.CategoryRule) ([]models.CategoryRule, error) {
+		i := slices.IndexFunc(rules, func(rule models.CategoryRule) bool {
+			return rule.ID == id
+		})
+		if i == -1 {
+			return nil, fmt.Errorf("category rule %w", ErrNotFound)
+		}
+
+		return slices.Delete(rules, i, i+1), nil
+	})
+}
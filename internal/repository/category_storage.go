@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// JSONCategoryRepository implements CategoryRepository using a JSON file for storage.
+// Like JSONFileRepository, writes are transactions under an advisory file lock.
+type JSONCategoryRepository struct {
+	filePath    string
+	mutex       sync.RWMutex
+	lockTimeout time.Duration
+}
+
+// categoriesFile is the versioned envelope stored in categories.json
+type categoriesFile struct {
+	Version    int               `json:"version"`
+	Strict     bool              `json:"strict"`
+	Categories []models.Category `json:"categories"`
+}
+
+// NewJSONCategoryRepository creates a new repository that stores the category catalog in a JSON file
+func NewJSONCategoryRepository(dataDir string) (*JSONCategoryRepository, error) {
+	// Ensure data directory exists
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	repo := &JSONCategoryRepository{
+		filePath:    filepath.Join(dataDir, "categories.json"),
+		lockTimeout: defaultLockTimeout,
+	}
+
+	lock, err := acquireLock(lockPath(repo.filePath), true, repo.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	// Create file if it doesn't exist
+	if _, err := os.Stat(repo.filePath); os.IsNotExist(err) {
+		if err := repo.writeCatalogFile(models.CategoryCatalog{}); err != nil {
+			return nil, fmt.Errorf("failed to create initial categories file: %w", err)
+		}
+	} else {
+		// Fall back to the last good backup if the file was damaged
+		if err := recoverCorruptFile(repo.filePath, validateCategoriesFile); err != nil {
+			return nil, err
+		}
+		if err := migrateDataFile(repo.filePath, "categories"); err != nil {
+			return nil, fmt.Errorf("failed to migrate categories file: %w", err)
+		}
+	}
+
+	return repo, nil
+}
+
+// validateCategoriesFile checks that data is a readable categories file
+func validateCategoriesFile(data []byte) error {
+	var file categoriesFile
+	return json.Unmarshal(data, &file)
+}
+
+// Load reads the catalog from the JSON file under a shared lock
+func (r *JSONCategoryRepository) Load() (models.CategoryCatalog, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), false, r.lockTimeout)
+	if err != nil {
+		return models.CategoryCatalog{}, err
+	}
+	defer lock.release()
+
+	return r.readCatalogFile()
+}
+
+// Update runs a read-modify-write transaction on the catalog under an exclusive lock
+func (r *JSONCategoryRepository) Update(fn func(catalog models.CategoryCatalog) (models.CategoryCatalog, error)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), true, r.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	catalog, err := r.readCatalogFile()
+	if err != nil {
+		return err
+	}
+
+	catalog, err = fn(catalog)
+	if err != nil {
+		return err
+	}
+
+	return r.writeCatalogFile(catalog)
+}
+
+// readCatalogFile reads the catalog from the JSON file; the caller holds the lock
+func (r *JSONCategoryRepository) readCatalogFile() (models.CategoryCatalog, error) {
+	file, err := os.ReadFile(r.filePath)
+	if err != nil {
+		return models.CategoryCatalog{}, fmt.Errorf("failed to read categories file: %w", err)
+	}
+
+	var data categoriesFile
+	if err := json.Unmarshal(file, &data); err != nil {
+		return models.CategoryCatalog{}, fmt.Errorf("failed to unmarshal categories: %w", err)
+	}
+
+	return models.CategoryCatalog{Strict: data.Strict, Categories: data.Categories}, nil
+}
+
+// writeCatalogFile writes the catalog to the JSON file; the caller holds the lock
+func (r *JSONCategoryRepository) writeCatalogFile(catalog models.CategoryCatalog) error {
+	data := categoriesFile{
+		Version:    currentSchemaVersion,
+		Strict:     catalog.Strict,
+		Categories: catalog.Categories,
+	}
+	if data.Categories == nil {
+		data.Categories = []models.Category{}
+	}
+
+	fileData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal categories: %w", err)
+	}
+
+	if err := writeFileAtomic(r.filePath, fileData, 0644); err != nil {
+		return fmt.Errorf("failed to write categories file: %w", err)
+	}
+
+	return nil
+}
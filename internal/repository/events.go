@@ -0,0 +1,618 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// EventType identifies the kind of change recorded in the event log
+type EventType string
+
+const (
+	EventExpenseAdded    EventType = "ExpenseAdded"
+	EventExpenseUpdated  EventType = "ExpenseUpdated"
+	EventExpenseDeleted  EventType = "ExpenseDeleted"  // Moved to the trash
+	EventExpenseRestored EventType = "ExpenseRestored" // Taken out of the trash
+	EventExpensePurged   EventType = "ExpensePurged"   // Removed from the trash for good
+)
+
+// snapshotInterval is the number of events appended between snapshots
+const snapshotInterval = 100
+
+// Event is a single change to the expenses, stored as one line of the event
+// log. Added and updated events carry the full expense after the change,
+// including whether it is in the trash.
+type Event struct {
+	Seq       int64           `json:"seq"`
+	Type      EventType       `json:"type"`
+	Time      time.Time       `json:"time"`
+	ExpenseID int             `json:"expenseId"`
+	Expense   *models.Expense `json:"expense,omitempty"`
+}
+
+// ledger is the state of the expenses rebuilt by replaying the event log
+type ledger struct {
+	seq         int64     // sequence number of the last applied event
+	offset      int64     // size of the log up to and including that event
+	time        time.Time // time the last applied event was recorded
+	nextID      int
+	snapshotSeq int64 // sequence number of the snapshot the ledger started from
+	expenses    map[int]models.Expense
+}
+
+// ledgerSnapshot is the on-disk form of a ledger
+type ledgerSnapshot struct {
+	Version  int              `json:"version"`
+	Seq      int64            `json:"seq"`
+	Offset   int64            `json:"offset"`
+	Time     time.Time        `json:"time"`
+	NextID   int              `json:"nextId"`
+	Expenses []models.Expense `json:"expenses"`
+}
+
+// newLedger returns the state before any event
+func newLedger() *ledger {
+	return &ledger{nextID: 1, expenses: make(map[int]models.Expense)}
+}
+
+// apply updates the ledger with an event
+func (l *ledger) apply(event Event) error {
+	expense, exists := l.expenses[event.ExpenseID]
+	live := exists && expense.DeletedAt == nil
+	trashed := exists && expense.DeletedAt != nil
+
+	switch event.Type {
+	case EventExpenseAdded, EventExpenseUpdated:
+		if event.Expense == nil || event.Expense.ID != event.ExpenseID {
+			return fmt.Errorf("event %d: missing or mismatched expense", event.Seq)
+		}
+		if event.Type == EventExpenseAdded && exists {
+			return fmt.Errorf("expense %d already exists", event.ExpenseID)
+		}
+		if event.Type == EventExpenseUpdated && !exists {
+			return fmt.Errorf("expense %w", ErrNotFound)
+		}
+		l.expenses[event.ExpenseID] = *event.Expense
+	case EventExpenseDeleted:
+		if !live {
+			return fmt.Errorf("expense %w", ErrNotFound)
+		}
+		deletedAt := event.Time
+		expense.DeletedAt = &deletedAt
+		l.expenses[event.ExpenseID] = expense
+	case EventExpenseRestored:
+		if !trashed {
+			return fmt.Errorf("expense %w in trash", ErrNotFound)
+		}
+		expense.DeletedAt = nil
+		l.expenses[event.ExpenseID] = expense
+	case EventExpensePurged:
+		if !trashed {
+			return fmt.Errorf("expense %w in trash", ErrNotFound)
+		}
+		delete(l.expenses, event.ExpenseID)
+	default:
+		return fmt.Errorf("event %d: unknown type %q", event.Seq, event.Type)
+	}
+
+	// IDs are never reused, so the history of an ID stays unambiguous
+	if event.ExpenseID >= l.nextID {
+		l.nextID = event.ExpenseID + 1
+	}
+	l.seq = event.Seq
+	l.time = event.Time
+	return nil
+}
+
+// all returns every expense in the ledger, including the trash, ordered by ID
+func (l *ledger) all() []models.Expense {
+	expenses := make([]models.Expense, 0, len(l.expenses))
+	for _, id := range slices.Sorted(maps.Keys(l.expenses)) {
+		expenses = append(expenses, l.expenses[id])
+	}
+	return expenses
+}
+
+// list returns the expenses that are not in the trash ordered by ID
+func (l *ledger) list() []models.Expense {
+	return liveExpenses(l.all())
+}
+
+// live returns the expense with the given ID unless it is missing or in the trash
+func (l *ledger) live(id int) (models.Expense, bool) {
+	expense, ok := l.expenses[id]
+	return expense, ok && expense.DeletedAt == nil
+}
+
+// EventLogRepository implements ExpenseRepository as an append-only log of
+// events in JSON Lines format. The current state is rebuilt by replaying the
+// log, starting from the latest snapshot, so no change is ever lost and the
+// expenses can be listed as they stood at any point in time.
+type EventLogRepository struct {
+	logPath      string
+	snapshotPath string
+	mutex        sync.RWMutex
+	lockTimeout  time.Duration
+}
+
+// NewEventLogRepository creates a new repository that records events in dataDir
+func NewEventLogRepository(dataDir string) (*EventLogRepository, error) {
+	// Ensure data directory exists
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	repo := &EventLogRepository{
+		logPath:      filepath.Join(dataDir, "events.jsonl"),
+		snapshotPath: filepath.Join(dataDir, "events.snapshot.json"),
+		lockTimeout:  defaultLockTimeout,
+	}
+
+	file, err := os.OpenFile(repo.logPath, os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event log: %w", err)
+	}
+	file.Close()
+
+	return repo, nil
+}
+
+// replay rebuilds the ledger from the events recorded before until, or from
+// all events if until is zero. The snapshot is only a cache: when it is
+// missing, newer than until or out of step with the log, the whole log is
+// replayed instead.
+func (r *EventLogRepository) replay(until time.Time) (*ledger, error) {
+	if snapshot, ok := r.readSnapshot(); ok && (until.IsZero() || snapshot.time.Before(until)) {
+		if err := r.replayLog(snapshot, until); err == nil {
+			return snapshot, nil
+		}
+	}
+
+	l := newLedger()
+	if err := r.replayLog(l, until); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// replayLog applies the events after the ledger's offset that were recorded
+// before until. A trailing line without a newline is the remains of an
+// interrupted append and is ignored.
+func (r *EventLogRepository) replayLog(l *ledger, until time.Time) error {
+	file, err := os.Open(r.logPath)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read event log: %w", err)
+	}
+	if info.Size() < l.offset {
+		return fmt.Errorf("event log is shorter than its snapshot")
+	}
+
+	if _, err := file.Seek(l.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read event log: %w", err)
+	}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read event log: %w", err)
+		}
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return fmt.Errorf("corrupt event log at byte %d: %w", l.offset, err)
+		}
+		if event.Seq != l.seq+1 {
+			return fmt.Errorf("corrupt event log at byte %d: expected event %d, found %d", l.offset, l.seq+1, event.Seq)
+		}
+		if !until.IsZero() && !event.Time.Before(until) {
+			return nil
+		}
+		if err := l.apply(event); err != nil {
+			return fmt.Errorf("corrupt event log at event %d: %w", event.Seq, err)
+		}
+		l.offset += int64(len(line))
+	}
+}
+
+// readSnapshot loads the latest snapshot, if there is a usable one
+func (r *EventLogRepository) readSnapshot() (*ledger, bool) {
+	data, err := os.ReadFile(r.snapshotPath)
+	if err != nil {
+		return nil, false
+	}
+
+	var snapshot ledgerSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil || snapshot.Version != currentSchemaVersion {
+		return nil, false
+	}
+
+	l := &ledger{
+		seq:         snapshot.Seq,
+		offset:      snapshot.Offset,
+		time:        snapshot.Time,
+		nextID:      snapshot.NextID,
+		snapshotSeq: snapshot.Seq,
+		expenses:    make(map[int]models.Expense, len(snapshot.Expenses)),
+	}
+	for _, expense := range snapshot.Expenses {
+		l.expenses[expense.ID] = expense
+	}
+	return l, true
+}
+
+// writeSnapshot saves the ledger so later replays can start from it
+func (r *EventLogRepository) writeSnapshot(l *ledger) error {
+	data, err := json.MarshalIndent(ledgerSnapshot{
+		Version:  currentSchemaVersion,
+		Seq:      l.seq,
+		Offset:   l.offset,
+		Time:     l.time,
+		NextID:   l.nextID,
+		Expenses: l.all(),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	if err := writeFileAtomic(r.snapshotPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// load rebuilds the ledger from the events recorded before until under a
+// shared lock; a zero until loads the current state
+func (r *EventLogRepository) load(until time.Time) (*ledger, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	lock, err := acquireLock(lockPath(r.logPath), false, r.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	return r.replay(until)
+}
+
+// record runs a transaction under an exclusive lock: fn inspects the current
+// ledger and returns the events to append, which are numbered, timestamped
+// and written to the log in a single synced append
+func (r *EventLogRepository) record(fn func(l *ledger) ([]Event, error)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lock, err := acquireLock(lockPath(r.logPath), true, r.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	l, err := r.replay(time.Time{})
+	if err != nil {
+		return err
+	}
+
+	events, err := fn(l)
+	if err != nil || len(events) == 0 {
+		return err
+	}
+
+	offset := l.offset
+	now := time.Now()
+	var buf bytes.Buffer
+	for _, event := range events {
+		event.Seq = l.seq + 1
+		event.Time = now
+
+		line, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		line = append(line, '\n')
+
+		if err := l.apply(event); err != nil {
+			return err
+		}
+		l.offset += int64(len(line))
+		buf.Write(line)
+	}
+
+	file, err := os.OpenFile(r.logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	// Drop the remains of an interrupted append before adding to the log
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to repair event log: %w", err)
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append events: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync event log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close event log: %w", err)
+	}
+
+	if l.seq-l.snapshotSeq >= snapshotInterval {
+		// The events are already safe in the log; a failed snapshot only
+		// makes the next replay slower
+		r.writeSnapshot(l)
+	}
+
+	return nil
+}
+
+// Add stores a new expense under the next free ID and returns that ID
+func (r *EventLogRepository) Add(expense models.Expense) (int, error) {
+	ids, err := r.AddMany([]models.Expense{expense})
+	if err != nil {
+		return 0, err
+	}
+
+	return ids[0], nil
+}
+
+// AddMany records new expenses under consecutive free IDs in a single append,
+// so either all of them are saved or none are
+func (r *EventLogRepository) AddMany(expenses []models.Expense) ([]int, error) {
+	var ids []int
+
+	err := r.record(func(l *ledger) ([]Event, error) {
+		ids = make([]int, 0, len(expenses))
+		events := make([]Event, 0, len(expenses))
+		for i, expense := range expenses {
+			expense.ID = l.nextID + i
+			if expense.Date.IsZero() {
+				expense.Date = time.Now()
+			}
+
+			events = append(events, Event{Type: EventExpenseAdded, ExpenseID: expense.ID, Expense: &expense})
+			ids = append(ids, expense.ID)
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Restore records expenses under their existing IDs in a single append. It
+// fails without saving anything if any of the IDs is invalid or already taken.
+func (r *EventLogRepository) Restore(expenses []models.Expense) error {
+	return r.record(func(l *ledger) ([]Event, error) {
+		events := make([]Event, 0, len(expenses))
+		for _, expense := range expenses {
+			if expense.ID <= 0 {
+				return nil, fmt.Errorf("invalid expense ID %d", expense.ID)
+			}
+
+			events = append(events, Event{Type: EventExpenseAdded, ExpenseID: expense.ID, Expense: &expense})
+		}
+		return events, nil
+	})
+}
+
+// GetByID retrieves an expense by its ID
+func (r *EventLogRepository) GetByID(id int) (models.Expense, error) {
+	l, err := r.load(time.Time{})
+	if err != nil {
+		return models.Expense{}, err
+	}
+
+	expense, ok := l.live(id)
+	if !ok {
+		return models.Expense{}, fmt.Errorf("expense %w", ErrNotFound)
+	}
+
+	return expense, nil
+}
+
+// GetAll retrieves all expenses
+func (r *EventLogRepository) GetAll() ([]models.Expense, error) {
+	l, err := r.load(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	return l.list(), nil
+}
+
+// AsOf retrieves the expenses as they stood at the given time, before any
+// change recorded at or after it
+func (r *EventLogRepository) AsOf(t time.Time) ([]models.Expense, error) {
+	l, err := r.load(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.list(), nil
+}
+
+// GetByMonth retrieves expenses for a specific month and year
+func (r *EventLogRepository) GetByMonth(month time.Month, year int) ([]models.Expense, error) {
+	expenses, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.Expense
+	for _, expense := range expenses {
+		if expense.Date.Month() == month && expense.Date.Year() == year {
+			result = append(result, expense)
+		}
+	}
+
+	return result, nil
+}
+
+// Find retrieves the expenses selected by a query
+func (r *EventLogRepository) Find(query Query) ([]models.Expense, error) {
+	expenses, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return query.Apply(expenses), nil
+}
+
+// Update records a new version of the expense that has the same ID
+func (r *EventLogRepository) Update(expense models.Expense) error {
+	return r.UpdateMany([]models.Expense{expense})
+}
+
+// UpdateMany records new versions of the expenses that have the same IDs in a
+// single append, so either all of them are changed or none are
+func (r *EventLogRepository) UpdateMany(expenses []models.Expense) error {
+	return r.record(func(l *ledger) ([]Event, error) {
+		events := make([]Event, 0, len(expenses))
+		for _, expense := range expenses {
+			if _, ok := l.live(expense.ID); !ok {
+				return nil, fmt.Errorf("expense %w", ErrNotFound)
+			}
+
+			expense.DeletedAt = nil
+			events = append(events, Event{Type: EventExpenseUpdated, ExpenseID: expense.ID, Expense: &expense})
+		}
+		return events, nil
+	})
+}
+
+// Delete records that an expense was moved to the trash
+func (r *EventLogRepository) Delete(id int) error {
+	return r.record(func(l *ledger) ([]Event, error) {
+		if _, ok := l.live(id); !ok {
+			return nil, fmt.Errorf("expense %w", ErrNotFound)
+		}
+
+		return []Event{{Type: EventExpenseDeleted, ExpenseID: id}}, nil
+	})
+}
+
+// GetDeleted retrieves the expenses in the trash, most recently deleted first
+func (r *EventLogRepository) GetDeleted() ([]models.Expense, error) {
+	l, err := r.load(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	return trashedExpenses(l.all()), nil
+}
+
+// Undelete records that an expense was taken out of the trash
+func (r *EventLogRepository) Undelete(id int) error {
+	return r.record(func(l *ledger) ([]Event, error) {
+		if expense, ok := l.expenses[id]; !ok || expense.DeletedAt == nil {
+			return nil, fmt.Errorf("expense %w in trash", ErrNotFound)
+		}
+
+		return []Event{{Type: EventExpenseRestored, ExpenseID: id}}, nil
+	})
+}
+
+// Purge records that the expenses moved to the trash before the given time
+// were removed for good and returns how many there were. Their history stays
+// in the log and can still be seen with AsOf.
+func (r *EventLogRepository) Purge(deletedBefore time.Time) (int, error) {
+	var events []Event
+
+	err := r.record(func(l *ledger) ([]Event, error) {
+		for _, expense := range l.all() {
+			if expense.DeletedAt != nil && expense.DeletedAt.Before(deletedBefore) {
+				events = append(events, Event{Type: EventExpensePurged, ExpenseID: expense.ID})
+			}
+		}
+		return events, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(events), nil
+}
+
+// Transaction passes every expense, including those in the trash, to fn and
+// records the expenses it returns in a single append: an updated event for
+// each that replaces an expense with the same ID and an added event for each
+// other one
+func (r *EventLogRepository) Transaction(fn func(expenses []models.Expense) ([]models.Expense, error)) error {
+	return r.record(func(l *ledger) ([]Event, error) {
+		changed, err := fn(l.all())
+		if err != nil {
+			return nil, err
+		}
+
+		exists := make(map[int]bool, len(l.expenses)+len(changed))
+		for id := range l.expenses {
+			exists[id] = true
+		}
+
+		events := make([]Event, 0, len(changed))
+		for _, expense := range changed {
+			if expense.ID <= 0 {
+				return nil, fmt.Errorf("invalid expense ID %d", expense.ID)
+			}
+
+			eventType := EventExpenseAdded
+			if exists[expense.ID] {
+				eventType = EventExpenseUpdated
+			}
+			exists[expense.ID] = true
+			events = append(events, Event{Type: eventType, ExpenseID: expense.ID, Expense: &expense})
+		}
+		return events, nil
+	})
+}
+
+// GetSummary returns a summary of all expenses
+func (r *EventLogRepository) GetSummary() (models.ExpenseSummary, error) {
+	expenses, err := r.GetAll()
+	if err != nil {
+		return models.ExpenseSummary{}, err
+	}
+
+	return summarizeExpenses(expenses), nil
+}
+
+// GetMonthlySummary returns a summary of expenses for a specific month and year
+func (r *EventLogRepository) GetMonthlySummary(month time.Month, year int) (models.ExpenseSummary, error) {
+	expenses, err := r.GetByMonth(month, year)
+	if err != nil {
+		return models.ExpenseSummary{}, err
+	}
+
+	summary := summarizeExpenses(expenses)
+	summary.Month = month
+	summary.Year = year
+	return summary, nil
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// eventTime is the time of the first event in the test logs
+var eventTime = time.Date(2025, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+func TestEventLogAsOf(t *testing.T) {
+	coffee := testExpense("Coffee")
+	coffee.ID = 1
+	latte := coffee
+	latte.Description = "Latte"
+	lunch := testExpense("Lunch")
+	lunch.ID = 2
+
+	// Event i is recorded i hours after eventTime
+	repo := newTestEventLog(t, []Event{
+		{Type: EventExpenseAdded, ExpenseID: 1, Expense: &coffee},
+		{Type: EventExpenseAdded, ExpenseID: 2, Expense: &lunch},
+		{Type: EventExpenseUpdated, ExpenseID: 1, Expense: &latte},
+		{Type: EventExpenseDeleted, ExpenseID: 2},
+		{Type: EventExpenseRestored, ExpenseID: 2},
+		{Type: EventExpenseDeleted, ExpenseID: 1},
+		{Type: EventExpensePurged, ExpenseID: 1},
+	})
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want []string
+	}{
+		{name: "before the first event", at: eventTime.Add(-time.Minute), want: nil},
+		{name: "at the first event", at: eventTime, want: nil},
+		{name: "after the first event", at: eventTime.Add(time.Minute), want: []string{"Coffee"}},
+		{name: "after the second event", at: eventTime.Add(90 * time.Minute), want: []string{"Coffee", "Lunch"}},
+		{name: "after the update", at: eventTime.Add(150 * time.Minute), want: []string{"Latte", "Lunch"}},
+		{name: "while in the trash", at: eventTime.Add(210 * time.Minute), want: []string{"Latte"}},
+		{name: "after the restore", at: eventTime.Add(270 * time.Minute), want: []string{"Latte", "Lunch"}},
+		{name: "after the purge", at: eventTime.Add(24 * time.Hour), want: []string{"Lunch"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expenses, err := repo.AsOf(tt.at)
+			if err != nil {
+				t.Fatalf("AsOf failed: %v", err)
+			}
+			if got := descriptions(expenses); !slices.Equal(got, tt.want) {
+				t.Errorf("AsOf(%s) = %v, want %v", tt.at.Format(time.Kitchen), got, tt.want)
+			}
+		})
+	}
+
+	current, err := repo.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := descriptions(current); !slices.Equal(got, []string{"Lunch"}) {
+		t.Errorf("GetAll = %v, want [Lunch]", got)
+	}
+}
+
+func TestEventLogIgnoresInterruptedAppend(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewEventLogRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Add(testExpense("Coffee")); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, "events.jsonl"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"seq": 2, "type": "ExpenseAdd`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	expenses, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if got := descriptions(expenses); !slices.Equal(got, []string{"Coffee"}) {
+		t.Errorf("GetAll = %v, want [Coffee]", got)
+	}
+
+	// The next append replaces the remains of the interrupted one
+	id, err := repo.Add(testExpense("Lunch"))
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if id != 2 {
+		t.Errorf("Add returned ID %d, want 2", id)
+	}
+	expenses, err = repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if got := descriptions(expenses); !slices.Equal(got, []string{"Coffee", "Lunch"}) {
+		t.Errorf("GetAll = %v, want [Coffee Lunch]", got)
+	}
+}
+
+func TestEventLogSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewEventLogRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for range snapshotInterval + 5 {
+		if _, err := repo.Add(testExpense("Coffee")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "events.snapshot.json")); err != nil {
+		t.Fatalf("no snapshot was written: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		snapshot []byte // Replaces the snapshot unless nil
+	}{
+		{name: "from the snapshot"},
+		{name: "corrupt snapshot", snapshot: []byte(`{"seq": `)},
+		{name: "snapshot ahead of the log", snapshot: []byte(`{"version": 1, "seq": 500, "offset": 999999, "nextId": 501}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.snapshot != nil {
+				if err := os.WriteFile(filepath.Join(dir, "events.snapshot.json"), tt.snapshot, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expenses, err := repo.GetAll()
+			if err != nil {
+				t.Fatalf("GetAll failed: %v", err)
+			}
+			if len(expenses) != snapshotInterval+5 {
+				t.Errorf("GetAll returned %d expenses, want %d", len(expenses), snapshotInterval+5)
+			}
+
+			// A time before the snapshot is answered from the log alone
+			earlier, err := repo.AsOf(start)
+			if err != nil {
+				t.Fatalf("AsOf failed: %v", err)
+			}
+			if len(earlier) != 0 {
+				t.Errorf("AsOf(start) returned %d expenses, want none", len(earlier))
+			}
+		})
+	}
+}
+
+func TestLedgerApplyRejects(t *testing.T) {
+	expense := testExpense("Coffee")
+	expense.ID = 1
+	other := testExpense("Lunch")
+	other.ID = 2
+
+	tests := []struct {
+		name  string
+		event Event
+	}{
+		{name: "added twice", event: Event{Type: EventExpenseAdded, ExpenseID: 1, Expense: &expense}},
+		{name: "added without expense", event: Event{Type: EventExpenseAdded, ExpenseID: 3}},
+		{name: "mismatched expense", event: Event{Type: EventExpenseUpdated, ExpenseID: 1, Expense: &other}},
+		{name: "update of missing expense", event: Event{Type: EventExpenseUpdated, ExpenseID: 2, Expense: &other}},
+		{name: "delete of missing expense", event: Event{Type: EventExpenseDeleted, ExpenseID: 2}},
+		{name: "restore of live expense", event: Event{Type: EventExpenseRestored, ExpenseID: 1}},
+		{name: "purge of live expense", event: Event{Type: EventExpensePurged, ExpenseID: 1}},
+		{name: "unknown type", event: Event{Type: "ExpenseCopied", ExpenseID: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLedger()
+			if err := l.apply(Event{Seq: 1, Type: EventExpenseAdded, ExpenseID: 1, Expense: &expense}); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.event.Seq = 2
+			if err := l.apply(tt.event); err == nil {
+				t.Error("apply succeeded, want an error")
+			}
+			if l.seq != 1 {
+				t.Errorf("ledger is at event %d after a rejected event, want 1", l.seq)
+			}
+		})
+	}
+}
+
+// newTestEventLog returns a repository whose log holds events, numbered from
+// 1 and recorded an hour apart starting at eventTime
+func newTestEventLog(t *testing.T, events []Event) *EventLogRepository {
+	t.Helper()
+	dir := t.TempDir()
+
+	var buf bytes.Buffer
+	for i, event := range events {
+		event.Seq = int64(i + 1)
+		event.Time = eventTime.Add(time.Duration(i) * time.Hour)
+		line, err := json.Marshal(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(append(line, '\n'))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "events.jsonl"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewEventLogRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+// descriptions returns the descriptions of expenses in order
+func descriptions(expenses []models.Expense) []string {
+	var result []string
+	for _, expense := range expenses {
+		result = append(result, expense.Description)
+	}
+	return result
+}
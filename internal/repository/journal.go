@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// journalLimit is the number of operations kept for undo
+const journalLimit = 100
+
+var (
+	// ErrNothingToUndo is returned by Undo when the journal has no operations left
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo is returned by Redo when no undone operation can be redone
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// JSONJournalRepository implements JournalRepository using a JSON file for
+// storage. It keeps two stacks: operations that can be undone, newest last,
+// and undone operations that can be redone, most recently undone last.
+type JSONJournalRepository struct {
+	filePath    string
+	mutex       sync.Mutex
+	lockTimeout time.Duration
+}
+
+// journalFile is the versioned envelope stored in journal.json
+type journalFile struct {
+	Version int                `json:"version"`
+	Done    []models.Operation `json:"done"`
+	Undone  []models.Operation `json:"undone"`
+}
+
+// NewJSONJournalRepository creates a new repository that stores the operation journal in a JSON file
+func NewJSONJournalRepository(dataDir string) (*JSONJournalRepository, error) {
+	// Ensure data directory exists
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	repo := &JSONJournalRepository{
+		filePath:    filepath.Join(dataDir, "journal.json"),
+		lockTimeout: defaultLockTimeout,
+	}
+
+	lock, err := acquireLock(lockPath(repo.filePath), true, repo.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	// Create file if it doesn't exist
+	if _, err := os.Stat(repo.filePath); os.IsNotExist(err) {
+		if err := repo.writeJournalFile(journalFile{}); err != nil {
+			return nil, fmt.Errorf("failed to create initial journal file: %w", err)
+		}
+	} else {
+		// Fall back to the last good backup if the file was damaged
+		if err := recoverCorruptFile(repo.filePath, validateJournalFile); err != nil {
+			return nil, err
+		}
+		if err := migrateDataFile(repo.filePath, "done"); err != nil {
+			return nil, fmt.Errorf("failed to migrate journal file: %w", err)
+		}
+	}
+
+	return repo, nil
+}
+
+// validateJournalFile checks that data is a readable journal file
+func validateJournalFile(data []byte) error {
+	var file journalFile
+	return json.Unmarshal(data, &file)
+}
+
+// updateJournal runs a read-modify-write transaction under an exclusive lock.
+// The journal is saved unless fn returns an error.
+func (r *JSONJournalRepository) updateJournal(fn func(journal *journalFile) error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), true, r.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	journal, err := r.readJournalFile()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&journal); err != nil {
+		return err
+	}
+
+	return r.writeJournalFile(journal)
+}
+
+// readJournalFile reads the journal from the JSON file; the caller holds the lock
+func (r *JSONJournalRepository) readJournalFile() (journalFile, error) {
+	file, err := os.ReadFile(r.filePath)
+	if err != nil {
+		return journalFile{}, fmt.Errorf("failed to read journal file: %w", err)
+	}
+
+	var data journalFile
+	if err := json.Unmarshal(file, &data); err != nil {
+		return journalFile{}, fmt.Errorf("failed to unmarshal journal: %w", err)
+	}
+
+	return data, nil
+}
+
+// writeJournalFile writes the journal to the JSON file; the caller holds the lock
+func (r *JSONJournalRepository) writeJournalFile(journal journalFile) error {
+	journal.Version = currentSchemaVersion
+	if journal.Done == nil {
+		journal.Done = []models.Operation{}
+	}
+	if journal.Undone == nil {
+		journal.Undone = []models.Operation{}
+	}
+
+	fileData, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal: %w", err)
+	}
+
+	if err := writeFileAtomic(r.filePath, fileData, 0644); err != nil {
+		return fmt.Errorf("failed to write journal file: %w", err)
+	}
+
+	return nil
+}
+
+// Record adds a new operation to the journal. Operations undone earlier can
+// no longer be redone, and the oldest operation is dropped beyond journalLimit.
+func (r *JSONJournalRepository) Record(op models.Operation) error {
+	return r.updateJournal(func(journal *journalFile) error {
+		journal.Done = append(journal.Done, op)
+		if len(journal.Done) > journalLimit {
+			journal.Done = journal.Done[len(journal.Done)-journalLimit:]
+		}
+		journal.Undone = nil
+		return nil
+	})
+}
+
+// Undo passes the most recent operation to apply, which reverses it, and then
+// moves it to the redo stack. The journal is left unchanged if apply fails.
+func (r *JSONJournalRepository) Undo(apply func(op models.Operation) error) (models.Operation, error) {
+	var op models.Operation
+
+	err := r.updateJournal(func(journal *journalFile) error {
+		if len(journal.Done) == 0 {
+			return ErrNothingToUndo
+		}
+
+		op = journal.Done[len(journal.Done)-1]
+		if err := apply(op); err != nil {
+			return err
+		}
+
+		journal.Done = journal.Done[:len(journal.Done)-1]
+		journal.Undone = append(journal.Undone, op)
+		return nil
+	})
+
+	return op, err
+}
+
+// Redo passes the most recently undone operation to apply, which performs it
+// again, and then moves it back to the undo stack. The journal is left
+// unchanged if apply fails.
+func (r *JSONJournalRepository) Redo(apply func(op models.Operation) error) (models.Operation, error) {
+	var op models.Operation
+
+	err := r.updateJournal(func(journal *journalFile) error {
+		if len(journal.Undone) == 0 {
+			return ErrNothingToRedo
+		}
+
+		op = journal.Undone[len(journal.Undone)-1]
+		if err := apply(op); err != nil {
+			return err
+		}
+
+		journal.Undone = journal.Undone[:len(journal.Undone)-1]
+		journal.Done = append(journal.Done, op)
+		return nil
+	})
+
+	return op, err
+}
+
+// List returns the operations that can be undone and redone, each newest first
+func (r *JSONJournalRepository) List() ([]models.Operation, []models.Operation, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), false, r.lockTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer lock.release()
+
+	journal, err := r.readJournalFile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slices.Reverse(journal.Done)
+	slices.Reverse(journal.Undone)
+	return journal.Done, journal.Undone, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrBusy is returned when the data files stay locked by another process for
+// longer than the lock timeout
+var ErrBusy = errors.New("database is busy: another expense-tracker process is using the data files, try again")
+
+// errWouldBlock is returned by tryLock when the lock is held elsewhere
+var errWouldBlock = errors.New("lock is held by another process")
+
+const (
+	// defaultLockTimeout is how long to wait for another process to release the data files
+	defaultLockTimeout = 5 * time.Second
+	// lockRetryInterval is how often a held lock is retried
+	lockRetryInterval = 20 * time.Millisecond
+)
+
+// fileLock is an OS-level advisory lock held on a lock file
+type fileLock struct {
+	file *os.File
+}
+
+// lockPath returns the path of the lock file guarding a data file. A separate
+// file is locked because atomic writes replace the data file itself.
+func lockPath(dataFile string) string {
+	return dataFile + ".lock"
+}
+
+// acquireLock takes a shared or exclusive advisory lock on the lock file at
+// path, waiting up to timeout for other processes to release it
+func acquireLock(path string, exclusive bool, timeout time.Duration) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(file, exclusive)
+		if err == nil {
+			return &fileLock{file: file}, nil
+		}
+		if !errors.Is(err, errWouldBlock) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", filepath.Base(path), err)
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, ErrBusy
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// release drops the lock and closes the lock file
+func (l *fileLock) release() error {
+	unlockErr := unlock(l.file)
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	return unlockErr
+}
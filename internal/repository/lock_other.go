@@ -0,0 +1,16 @@
+//go:build !unix
+
+package repository
+
+import "os"
+
+// tryLock is a no-op on platforms without flock(2); concurrent access is then
+// only serialized within a single process
+func tryLock(file *os.File, exclusive bool) error {
+	return nil
+}
+
+// unlock is a no-op on platforms without flock(2)
+func unlock(file *os.File) error {
+	return nil
+}
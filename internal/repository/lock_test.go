@@ -0,0 +1,113 @@
+//go:build unix
+
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentAddsKeepEveryExpense(t *testing.T) {
+	const writers = 20
+	dir := t.TempDir()
+
+	// Each writer opens its own repository, as separate processes would, so
+	// the adds are only serialized by the file lock
+	ids := make([]int, writers)
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo, err := NewJSONFileRepository(dir)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			ids[i], errs[i] = repo.Add(testExpense(fmt.Sprintf("Expense %d", i)))
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[int]bool)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("writer %d failed: %v", i, err)
+		}
+		if seen[ids[i]] {
+			t.Errorf("ID %d was assigned twice", ids[i])
+		}
+		seen[ids[i]] = true
+	}
+
+	repo, err := NewJSONFileRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expenses, err := repo.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expenses) != writers {
+		t.Fatalf("stored %d expenses, want %d", len(expenses), writers)
+	}
+	for _, expense := range expenses {
+		if !seen[expense.ID] {
+			t.Errorf("stored expense %d was not returned by any add", expense.ID)
+		}
+	}
+}
+
+func TestAcquireLockTimesOutWhileHeld(t *testing.T) {
+	tests := []struct {
+		name          string
+		heldExclusive bool
+		exclusive     bool
+		wantErr       error
+	}{
+		{name: "exclusive while held exclusively", heldExclusive: true, exclusive: true, wantErr: ErrBusy},
+		{name: "shared while held exclusively", heldExclusive: true, exclusive: false, wantErr: ErrBusy},
+		{name: "exclusive while shared", heldExclusive: false, exclusive: true, wantErr: ErrBusy},
+		{name: "shared while shared", heldExclusive: false, exclusive: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.json.lock")
+			held, err := acquireLock(path, tt.heldExclusive, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			timeout := 100 * time.Millisecond
+			start := time.Now()
+			lock, err := acquireLock(path, tt.exclusive, timeout)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("acquireLock returned %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				lock.release()
+				held.release()
+				return
+			}
+			if waited := time.Since(start); waited < timeout {
+				t.Errorf("acquireLock gave up after %s, before the %s timeout", waited, timeout)
+			}
+
+			// Once the holder lets go, the lock can be taken again
+			if err := held.release(); err != nil {
+				t.Fatal(err)
+			}
+			lock, err = acquireLock(path, tt.exclusive, timeout)
+			if err != nil {
+				t.Fatalf("acquireLock after release returned %v", err)
+			}
+			lock.release()
+		})
+	}
+}
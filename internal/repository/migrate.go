@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// schemaMigration upgrades the records of a JSON data file by one version
+type schemaMigration struct {
+	description string
+	apply       func(records []map[string]json.RawMessage) error
+}
+
+// schemaMigrations lists the forward migrations of the JSON data files in
+// order: entry i upgrades a file from version i to version i+1. Files written
+// before the format was versioned have no "version" field and are version 0.
+// Append new entries here whenever existing records must be rewritten; new
+// optional fields stored with omitempty are read as empty from older files
+// and need no new version.
+var schemaMigrations = []schemaMigration{
+	{description: "store amounts as exact decimal strings", apply: migrateLegacyAmounts},
+}
+
+// currentSchemaVersion is the version of the data files written by this build
+var currentSchemaVersion = len(schemaMigrations)
+
+// migrateDataFile brings the data file at filePath, whose records are stored
+// under collection, up to currentSchemaVersion by running every pending
+// migration in order. Before a file is rewritten the original is kept
+// alongside with a ".v<N>" suffix naming its old version. Files written by a
+// newer build are rejected rather than risk losing fields it added.
+func migrateDataFile(filePath string, collection string) error {
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(file, &data); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", filePath, err)
+	}
+
+	version := 0
+	if raw, ok := data["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return fmt.Errorf("invalid version in %s: %w", filePath, err)
+		}
+	}
+
+	if version > currentSchemaVersion {
+		return fmt.Errorf("%s has schema version %d, but this version of expense-tracker only supports up to %d; please upgrade",
+			filePath, version, currentSchemaVersion)
+	}
+	if version == currentSchemaVersion {
+		return nil
+	}
+
+	var records []map[string]json.RawMessage
+	if raw, ok := data[collection]; ok {
+		if err := json.Unmarshal(raw, &records); err != nil {
+			return fmt.Errorf("failed to unmarshal %s: %w", collection, err)
+		}
+	}
+
+	for v := version; v < currentSchemaVersion; v++ {
+		migration := schemaMigrations[v]
+		if err := migration.apply(records); err != nil {
+			return fmt.Errorf("failed to migrate %s from version %d to %d (%s): %w",
+				filePath, v, v+1, migration.description, err)
+		}
+	}
+
+	if records == nil {
+		records = []map[string]json.RawMessage{}
+	}
+	if data[collection], err = json.Marshal(records); err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", collection, err)
+	}
+	if data["version"], err = json.Marshal(currentSchemaVersion); err != nil {
+		return fmt.Errorf("failed to marshal version: %w", err)
+	}
+
+	fileData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filePath, err)
+	}
+
+	if err := writeFileAtomic(fmt.Sprintf("%s.v%d", filePath, version), file, 0644); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", filePath, err)
+	}
+
+	if err := writeFileAtomic(filePath, fileData, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// migrateLegacyAmounts converts records that store "amount" as a floating
+// point JSON number into the exact decimal string format used by models.Money
+func migrateLegacyAmounts(records []map[string]json.RawMessage) error {
+	for _, record := range records {
+		raw, ok := record["amount"]
+		if !ok || bytes.HasPrefix(bytes.TrimSpace(raw), []byte(`"`)) {
+			continue
+		}
+
+		var amount models.Money
+		if err := amount.UnmarshalJSON(raw); err != nil {
+			return fmt.Errorf("failed to migrate amount %s: %w", raw, err)
+		}
+
+		var err error
+		if record["amount"], err = json.Marshal(amount); err != nil {
+			return fmt.Errorf("failed to marshal amount: %w", err)
+		}
+	}
+
+	return nil
+}
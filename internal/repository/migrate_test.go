@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+func TestMigrateDataFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		wantAmounts []string
+		wantBackup  string
+		wantErr     bool
+	}{
+		{
+			name:        "unversioned file with numeric amounts",
+			file:        `{"expenses": [{"id": 1, "amount": 5}, {"id": 2, "amount": 0.1}, {"id": 3, "amount": 19.999}]}`,
+			wantAmounts: []string{"5.00", "0.10", "20.00"},
+			wantBackup:  ".v0",
+		},
+		{
+			name:        "unversioned file with string amounts",
+			file:        `{"expenses": [{"id": 1, "amount": "12.50"}]}`,
+			wantAmounts: []string{"12.50"},
+			wantBackup:  ".v0",
+		},
+		{
+			name:        "unversioned file without records",
+			file:        `{}`,
+			wantAmounts: []string{},
+			wantBackup:  ".v0",
+		},
+		{
+			name:        "current version",
+			file:        `{"version": 1, "expenses": [{"id": 1, "amount": "3.00"}]}`,
+			wantAmounts: []string{"3.00"},
+		},
+		{
+			name:    "newer version",
+			file:    `{"version": 99, "expenses": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			file:    `{"version": "one", "expenses": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid legacy amount",
+			file:    `{"expenses": [{"id": 1, "amount": true}]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "expenses.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := migrateDataFile(path, "expenses")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("migrateDataFile succeeded, want an error")
+				}
+				assertFileContent(t, path, tt.file)
+				return
+			}
+			if err != nil {
+				t.Fatalf("migrateDataFile failed: %v", err)
+			}
+
+			var data struct {
+				Version  int `json:"version"`
+				Expenses []struct {
+					Amount json.RawMessage `json:"amount"`
+				} `json:"expenses"`
+			}
+			readJSON(t, path, &data)
+			if data.Version != currentSchemaVersion {
+				t.Errorf("version = %d, want %d", data.Version, currentSchemaVersion)
+			}
+			if len(data.Expenses) != len(tt.wantAmounts) {
+				t.Fatalf("got %d expenses, want %d", len(data.Expenses), len(tt.wantAmounts))
+			}
+			for i, want := range tt.wantAmounts {
+				var got string
+				if err := json.Unmarshal(data.Expenses[i].Amount, &got); err != nil {
+					t.Fatalf("amount %s of expense %d is not a string: %v", data.Expenses[i].Amount, i, err)
+				}
+				if got != want {
+					t.Errorf("amount of expense %d = %q, want %q", i, got, want)
+				}
+			}
+
+			if tt.wantBackup != "" {
+				assertFileContent(t, path+tt.wantBackup, tt.file)
+			}
+		})
+	}
+}
+
+func TestNewJSONFileRepositoryMigratesLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `{"expenses": [{"id": 1, "description": "Coffee", "amount": 5.5, "date": "2025-06-02T15:17:39Z"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "expenses.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewJSONFileRepository(dir)
+	if err != nil {
+		t.Fatalf("NewJSONFileRepository failed: %v", err)
+	}
+	expense, err := repo.GetByID(1)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if expense.Description != "Coffee" || expense.Amount != models.NewMoney(5, 50) {
+		t.Errorf("got %q for %s, want \"Coffee\" for 5.50", expense.Description, expense.Amount)
+	}
+}
+
+// readJSON decodes the JSON file at path into v
+func readJSON(t *testing.T, path string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", filepath.Base(path), err)
+	}
+}
+
+// assertFileContent fails the test unless the file at path holds want
+func assertFileContent(t *testing.T, path string, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("%s holds %q, want %q", filepath.Base(path), data, want)
+	}
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// SortField names the expense field Find orders results by
+type SortField string
+
+const (
+	SortByID          SortField = "id"
+	SortByDate        SortField = "date"
+	SortByAmount      SortField = "amount"
+	SortByDescription SortField = "description"
+	SortByCategory    SortField = "category"
+)
+
+// Query selects, orders and pages expenses for ExpenseRepository.Find.
+// Zero values leave the corresponding filter unset.
+type Query struct {
+	From     time.Time     // Only expenses on or after this instant
+	To       time.Time     // Only expenses before this instant
+	Category string        // Case-insensitive category match, including its subcategories
+	Tags     []string      // Only expenses carrying all of these tags, ignoring case
+	Min      *models.Money // Minimum amount in the expense's own currency
+	Max      *models.Money // Maximum amount in the expense's own currency
+	Search   string        // Case-insensitive substring of the description
+	SortBy   SortField     // Defaults to SortByID
+	Desc     bool          // Sort in descending order
+	Limit    int           // Maximum number of results, 0 for no limit
+	Offset   int           // Number of sorted results to skip
+}
+
+// Validate checks that the query is well formed
+func (q Query) Validate() error {
+	switch q.SortBy {
+	case "", SortByID, SortByDate, SortByAmount, SortByDescription, SortByCategory:
+	default:
+		return fmt.Errorf("invalid sort field %q, expected id, date, amount, description or category", q.SortBy)
+	}
+	if q.Limit < 0 {
+		return fmt.Errorf("limit cannot be negative")
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("offset cannot be negative")
+	}
+	if q.Min != nil && q.Max != nil && *q.Min > *q.Max {
+		return fmt.Errorf("minimum amount cannot be greater than maximum amount")
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return fmt.Errorf("from date must be before to date")
+	}
+	return nil
+}
+
+// Matches reports whether an expense passes the query's filters
+func (q Query) Matches(expense models.Expense) bool {
+	if !q.From.IsZero() && expense.Date.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !expense.Date.Before(q.To) {
+		return false
+	}
+	if q.Category != "" && !models.WithinCategory(expense.Category, q.Category) {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !expense.HasTag(tag) {
+			return false
+		}
+	}
+	if q.Min != nil && expense.Amount < *q.Min {
+		return false
+	}
+	if q.Max != nil && expense.Amount > *q.Max {
+		return false
+	}
+	if q.Search != "" && !strings.Contains(strings.ToLower(expense.Description), strings.ToLower(q.Search)) {
+		return false
+	}
+	return true
+}
+
+// Apply filters, sorts and pages an in-memory list of expenses.
+// It is used by repositories that cannot push the query down to storage.
+func (q Query) Apply(expenses []models.Expense) []models.Expense {
+	var result []models.Expense
+	for _, expense := range expenses {
+		if q.Matches(expense) {
+			result = append(result, expense)
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b models.Expense) int {
+		c := q.compare(a, b)
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if q.Desc {
+			return -c
+		}
+		return c
+	})
+
+	if q.Offset >= len(result) {
+		return []models.Expense{}
+	}
+	result = result[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(result) {
+		result = result[:q.Limit]
+	}
+
+	return result
+}
+
+// compare orders two expenses by the query's sort field
+func (q Query) compare(a, b models.Expense) int {
+	switch q.SortBy {
+	case SortByDate:
+		return a.Date.Compare(b.Date)
+	case SortByAmount:
+		return cmp.Compare(a.Amount, b.Amount)
+	case SortByDescription:
+		return strings.Compare(strings.ToLower(a.Description), strings.ToLower(b.Description))
+	case SortByCategory:
+		return strings.Compare(strings.ToLower(a.Category), strings.ToLower(b.Category))
+	default:
+		return cmp.Compare(a.ID, b.ID)
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// storeKinds lists the kinds of expense store accepted by OpenExpenseRepository
+var storeKinds = []string{"json", "sqlite", "events"}
+
+// openTestStore opens an empty expense store of the given kind in a temporary directory
+func openTestStore(t *testing.T, kind string) ExpenseRepository {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "expenses")
+	if kind == "sqlite" {
+		path += ".db"
+	}
+
+	repo, err := OpenExpenseRepository(kind + ":" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlite, ok := repo.(*SQLiteRepository); ok {
+		t.Cleanup(func() { sqlite.Close() })
+	}
+	return repo
+}
+
+// addTagged adds an expense for each description with the tags listed for it
+func addTagged(t *testing.T, repo ExpenseRepository, tagged map[string][]string) {
+	t.Helper()
+	for _, description := range slices.Sorted(maps.Keys(tagged)) {
+		expense := testExpense(description)
+		expense.Tags = tagged[description]
+		if _, err := repo.Add(expense); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindByTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{name: "one tag", tags: []string{"work"}, want: []string{"Coffee", "Taxi"}},
+		{name: "tag in other case", tags: []string{"WORK"}, want: []string{"Coffee", "Taxi"}},
+		{name: "all of several tags", tags: []string{"work", "travel"}, want: []string{"Taxi"}},
+		{name: "tag no expense carries", tags: []string{"gifts"}, want: nil},
+		{name: "no tags", tags: nil, want: []string{"Coffee", "Lunch", "Taxi"}},
+	}
+
+	for _, kind := range storeKinds {
+		repo := openTestStore(t, kind)
+		addTagged(t, repo, map[string][]string{
+			"Coffee": {"work"},
+			"Lunch":  nil,
+			"Taxi":   {"travel", "work"},
+		})
+
+		for _, tt := range tests {
+			t.Run(kind+"/"+tt.name, func(t *testing.T) {
+				expenses, err := repo.Find(Query{Tags: tt.tags})
+				if err != nil {
+					t.Fatalf("Find failed: %v", err)
+				}
+				if got := descriptions(expenses); !slices.Equal(got, tt.want) {
+					t.Errorf("Find found %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestSummaryTagTotals(t *testing.T) {
+	for _, kind := range storeKinds {
+		t.Run(kind, func(t *testing.T) {
+			repo := openTestStore(t, kind)
+			addTagged(t, repo, map[string][]string{
+				"Coffee": {"work"},
+				"Lunch":  nil,
+				"Taxi":   {"travel", "work"},
+			})
+			// Expenses in the trash do not count
+			trashed := testExpense("Hotel")
+			trashed.Tags = []string{"travel"}
+			id, err := repo.Add(trashed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Delete(id); err != nil {
+				t.Fatal(err)
+			}
+
+			summary, err := repo.GetSummary()
+			if err != nil {
+				t.Fatalf("GetSummary failed: %v", err)
+			}
+			want := map[string]models.Money{"work": models.NewMoney(10, 0), "travel": models.NewMoney(5, 0)}
+			if len(summary.TagTotals) != len(want) {
+				t.Fatalf("tag totals = %v, want %v", summary.TagTotals, want)
+			}
+			for tag, total := range want {
+				if summary.TagTotals[tag] != total {
+					t.Errorf("total of %s = %s, want %s", tag, summary.TagTotals[tag], total)
+				}
+			}
+			if summary.TotalAmount != models.NewMoney(15, 0) {
+				t.Errorf("total = %s, want 15.00", summary.TotalAmount)
+			}
+		})
+	}
+}
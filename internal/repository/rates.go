@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// LoadExchangeRates reads a table of historical exchange rates quoted against EUR.
+// Files ending in .xml are read in the European Central Bank eurofxref format;
+// any other file is read as CSV with a "Date,Currency,Rate" header.
+func LoadExchangeRates(filePath string) ([]models.ExchangeRate, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rates file: %w", err)
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(filePath), ".xml") {
+		return readECBRates(file)
+	}
+	return readCSVRates(file)
+}
+
+// readECBRates parses the ECB eurofxref daily or historical XML format
+func readECBRates(r io.Reader) ([]models.ExchangeRate, error) {
+	var envelope struct {
+		Cube struct {
+			Days []struct {
+				Time  string `xml:"time,attr"`
+				Rates []struct {
+					Currency string `xml:"currency,attr"`
+					Rate     string `xml:"rate,attr"`
+				} `xml:"Cube"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	}
+
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse rates XML: %w", err)
+	}
+
+	var rates []models.ExchangeRate
+	for _, day := range envelope.Cube.Days {
+		for _, entry := range day.Rates {
+			rate, err := newExchangeRate(day.Time, entry.Currency, entry.Rate)
+			if err != nil {
+				return nil, err
+			}
+			rates = append(rates, rate)
+		}
+	}
+
+	return rates, nil
+}
+
+// readCSVRates parses rates from CSV with a Date,Currency,Rate header
+func readCSVRates(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rates header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	dateCol, okDate := columns["date"]
+	currencyCol, okCurrency := columns["currency"]
+	rateCol, okRate := columns["rate"]
+	if !okDate || !okCurrency || !okRate {
+		return nil, errors.New("rates CSV must have Date, Currency and Rate columns")
+	}
+
+	var rates []models.ExchangeRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rates CSV: %w", err)
+		}
+
+		rate, err := newExchangeRate(record[dateCol], record[currencyCol], record[rateCol])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// newExchangeRate validates and converts the textual fields of a rate
+func newExchangeRate(date string, currency string, rate string) (models.ExchangeRate, error) {
+	day, err := time.Parse("2006-01-02", strings.TrimSpace(date))
+	if err != nil {
+		return models.ExchangeRate{}, fmt.Errorf("invalid rate date %q", date)
+	}
+
+	code, err := models.NormalizeCurrency(currency)
+	if err != nil || strings.TrimSpace(currency) == "" {
+		return models.ExchangeRate{}, fmt.Errorf("invalid rate currency %q", currency)
+	}
+
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || value.Sign() <= 0 {
+		return models.ExchangeRate{}, fmt.Errorf("invalid rate %q for %s", rate, code)
+	}
+
+	return models.ExchangeRate{Date: day, Currency: code, Rate: value}, nil
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// JSONRecurringRepository implements RecurringRepository using a JSON file for storage.
+// Like JSONFileRepository, writes are transactions under an advisory file lock.
+type JSONRecurringRepository struct {
+	filePath    string
+	mutex       sync.RWMutex
+	lockTimeout time.Duration
+}
+
+// recurringFile is the versioned envelope stored in recurring.json
+type recurringFile struct {
+	Version int                    `json:"version"`
+	Rules   []models.RecurringRule `json:"rules"`
+}
+
+// NewJSONRecurringRepository creates a new repository that stores recurring rules in a JSON file
+func NewJSONRecurringRepository(dataDir string) (*JSONRecurringRepository, error) {
+	// Ensure data directory exists
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	repo := &JSONRecurringRepository{
+		filePath:    filepath.Join(dataDir, "recurring.json"),
+		lockTimeout: defaultLockTimeout,
+	}
+
+	lock, err := acquireLock(lockPath(repo.filePath), true, repo.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	// Create file if it doesn't exist
+	if _, err := os.Stat(repo.filePath); os.IsNotExist(err) {
+		if err := repo.writeRulesFile([]models.RecurringRule{}); err != nil {
+			return nil, fmt.Errorf("failed to create initial recurring rules file: %w", err)
+		}
+	} else {
+		// Fall back to the last good backup if the file was damaged
+		if err := recoverCorruptFile(repo.filePath, validateRulesFile); err != nil {
+			return nil, err
+		}
+		if err := migrateDataFile(repo.filePath, "rules"); err != nil {
+			return nil, fmt.Errorf("failed to migrate recurring rules file: %w", err)
+		}
+	}
+
+	return repo, nil
+}
+
+// validateRulesFile checks that data is a readable recurring rules file
+func validateRulesFile(data []byte) error {
+	var file recurringFile
+	return json.Unmarshal(data, &file)
+}
+
+// loadRules reads all rules from the JSON file under a shared lock
+func (r *JSONRecurringRepository) loadRules() ([]models.RecurringRule, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), false, r.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	return r.readRulesFile()
+}
+
+// Transaction runs a read-modify-write transaction under an exclusive lock.
+// fn may call save to persist an intermediate state without giving up the
+// lock. The rules returned by fn are saved unless it returns an error.
+func (r *JSONRecurringRepository) Transaction(fn func(rules []models.RecurringRule, save func([]models.RecurringRule) error) ([]models.RecurringRule, error)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), true, r.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	rules, err := r.readRulesFile()
+	if err != nil {
+		return err
+	}
+
+	rules, err = fn(rules, r.writeRulesFile)
+	if err != nil {
+		return err
+	}
+
+	return r.writeRulesFile(rules)
+}
+
+// updateRules runs a read-modify-write transaction under an exclusive lock
+func (r *JSONRecurringRepository) updateRules(fn func(rules []models.RecurringRule) ([]models.RecurringRule, error)) error {
+	return r.Transaction(func(rules []models.RecurringRule, _ func([]models.RecurringRule) error) ([]models.RecurringRule, error) {
+		return fn(rules)
+	})
+}
+
+// readRulesFile reads all rules from the JSON file; the caller holds the lock
+func (r *JSONRecurringRepository) readRulesFile() ([]models.RecurringRule, error) {
+	file, err := os.ReadFile(r.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recurring rules file: %w", err)
+	}
+
+	var data recurringFile
+	if err := json.Unmarshal(file, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recurring rules: %w", err)
+	}
+
+	return data.Rules, nil
+}
+
+// writeRulesFile writes all rules to the JSON file; the caller holds the lock
+func (r *JSONRecurringRepository) writeRulesFile(rules []models.RecurringRule) error {
+	data := recurringFile{
+		Version: currentSchemaVersion,
+		Rules:   rules,
+	}
+
+	fileData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recurring rules: %w", err)
+	}
+
+	if err := writeFileAtomic(r.filePath, fileData, 0644); err != nil {
+		return fmt.Errorf("failed to write recurring rules file: %w", err)
+	}
+
+	return nil
+}
+
+// Add stores a new rule under the next free ID and returns that ID
+func (r *JSONRecurringRepository) Add(rule models.RecurringRule) (int, error) {
+	err := r.updateRules(func(rules []models.RecurringRule) ([]models.RecurringRule, error) {
+		rule.ID = 1
+		for _, existing := range rules {
+			if existing.ID >= rule.ID {
+				rule.ID = existing.ID + 1
+			}
+		}
+		return append(rules, rule), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return rule.ID, nil
+}
+
+// Get retrieves a rule by its ID
+func (r *JSONRecurringRepository) Get(id int) (models.RecurringRule, error) {
+	rules, err := r.loadRules()
+	if err != nil {
+		return models.RecurringRule{}, err
+	}
+
+	if i := findRule(rules, id); i >= 0 {
+		return rules[i], nil
+	}
+
+	return models.RecurringRule{}, fmt.Errorf("recurring rule %w", ErrNotFound)
+}
+
+// GetAll retrieves all rules ordered by ID
+func (r *JSONRecurringRepository) GetAll() ([]models.RecurringRule, error) {
+	return r.loadRules()
+}
+
+// Update replaces the stored rule that has the same ID
+func (r *JSONRecurringRepository) Update(rule models.RecurringRule) error {
+	return r.updateRules(func(rules []models.RecurringRule) ([]models.RecurringRule, error) {
+		i := findRule(rules, rule.ID)
+		if i == -1 {
+			return nil, fmt.Errorf("recurring rule %w", ErrNotFound)
+		}
+
+		rules[i] = rule
+		return rules, nil
+	})
+}
+
+// Delete removes a rule by its ID. Expenses it already created are kept.
+func (r *JSONRecurringRepository) Delete(id int) error {
+	return r.updateRules(func(rules []models.RecurringRule) ([]models.RecurringRule, error) {
+		i := findRule(rules, id)
+		if i == -1 {
+			return nil, fmt.Errorf("recurring rule %w", ErrNotFound)
+		}
+
+		return slices.Delete(rules, i, i+1), nil
+	})
+}
+
+// findRule returns the index of the rule with the given ID, or -1
+func findRule(rules []models.RecurringRule, id int) int {
+	return slices.IndexFunc(rules, func(rule models.RecurringRule) bool {
+		return rule.ID == id
+	})
+}
@@ -1,17 +1,84 @@
 package repository
 
 import (
+	"errors"
 	"time"
 
 	"github.com/Businge931/expense-tracker/internal/models"
 )
 
+// ErrNotFound is matched with errors.Is by errors reporting that a record
+// does not exist
+var ErrNotFound = errors.New("not found")
+
 type ExpenseRepository interface {
-	Add(description string, amount float64, category string) (int, error)
+	Add(expense models.Expense) (int, error)
+	AddMany(expenses []models.Expense) ([]int, error)
+	Restore(expenses []models.Expense) error
 	GetByID(id int) (models.Expense, error)
 	GetAll() ([]models.Expense, error)
 	GetByMonth(month time.Month, year int) ([]models.Expense, error)
+	Find(query Query) ([]models.Expense, error)
+	Update(expense models.Expense) error
+	UpdateMany(expenses []models.Expense) error
 	Delete(id int) error
+	GetDeleted() ([]models.Expense, error)
+	Undelete(id int) error
+	Purge(deletedBefore time.Time) (int, error)
 	GetSummary() (models.ExpenseSummary, error)
 	GetMonthlySummary(month time.Month, year int) (models.ExpenseSummary, error)
+	// Transaction passes every expense, including those in the trash, to fn
+	// and saves the expenses it returns in a single write: each replaces the
+	// stored expense with the same ID, trash state included, or is added
+	// under its ID when there is none. Nothing is saved if fn fails.
+	Transaction(fn func(expenses []models.Expense) ([]models.Expense, error)) error
+}
+
+// HistoryRepository is implemented by expense repositories that keep every
+// past version of the expenses
+type HistoryRepository interface {
+	AsOf(t time.Time) ([]models.Expense, error)
+}
+
+type BudgetRepository interface {
+	Set(budget models.Budget) error
+	Get(month time.Month, year int, category string) (models.Budget, error)
+	GetByMonth(month time.Month, year int) ([]models.Budget, error)
+	GetAll() ([]models.Budget, error)
+	Delete(month time.Month, year int, category string) error
+	// Update runs a read-modify-write transaction on all budgets; the budgets
+	// returned by fn replace the stored ones unless it fails
+	Update(fn func(budgets []models.Budget) ([]models.Budget, error)) error
+}
+
+// JournalRepository stores the operations that can be undone and redone
+type JournalRepository interface {
+	Record(op models.Operation) error
+	Undo(apply func(op models.Operation) error) (models.Operation, error)
+	Redo(apply func(op models.Operation) error) (models.Operation, error)
+	List() (done []models.Operation, undone []models.Operation, err error)
+}
+
+// RecurringRepository stores the rules for recurring expenses
+type RecurringRepository interface {
+	Add(rule models.RecurringRule) (int, error)
+	Get(id int) (models.RecurringRule, error)
+	GetAll() ([]models.RecurringRule, error)
+	Update(rule models.RecurringRule) error
+	Delete(id int) error
+	Transaction(fn func(rules []models.RecurringRule, save func([]models.RecurringRule) error) ([]models.RecurringRule, error)) error
+}
+
+// CategoryRepository stores the category catalog
+type CategoryRepository interface {
+	Load() (models.CategoryCatalog, error)
+	Update(fn func(catalog models.CategoryCatalog) (models.CategoryCatalog, error)) error
+}
+
+// CategoryRuleRepository stores the rules that categorize new expenses, in
+// the order they are evaluated
+type CategoryRuleRepository interface {
+	Add(rule models.CategoryRule) (int, error)
+	GetAll() ([]models.CategoryRule, error)
+	Delete(id int) error
 }
@@ -0,0 +1,682 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// sqliteSchema creates the expenses table and its indexes. Amounts are stored
+// in minor units. The date is kept both as RFC 3339 text, whose leading
+// "YYYY-MM" is the month in the expense's own time zone, and as Unix
+// nanoseconds for range filters and ordering.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS expenses (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	description TEXT    NOT NULL,
+	amount      INTEGER NOT NULL,
+	currency    TEXT    NOT NULL DEFAULT '',
+	category    TEXT    NOT NULL DEFAULT '',
+	date        TEXT    NOT NULL,
+	date_unix   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_expenses_date ON expenses (date);
+CREATE INDEX IF NOT EXISTS idx_expenses_date_unix ON expenses (date_unix);
+CREATE INDEX IF NOT EXISTS idx_expenses_category ON expenses (category COLLATE NOCASE);
+`
+
+// sqliteMigrations lists the schema migrations of the SQLite store in order:
+// entry i upgrades a database from user_version i to i+1. Append new entries
+// here whenever the stored model changes.
+var sqliteMigrations = []string{
+	sqliteSchema,
+	// Deleted expenses stay in the table, marked with the Unix nanoseconds at
+	// which they were moved to the trash
+	`ALTER TABLE expenses ADD COLUMN deleted_at INTEGER;
+	CREATE INDEX IF NOT EXISTS idx_expenses_deleted_at ON expenses (deleted_at);`,
+	// Tags are stored as a JSON array of lowercase strings
+	`ALTER TABLE expenses ADD COLUMN tags TEXT NOT NULL DEFAULT '[]';`,
+	`ALTER TABLE expenses ADD COLUMN merchant TEXT NOT NULL DEFAULT '';`,
+}
+
+// expenseColumns lists the columns scanned by scanExpense, in order
+const expenseColumns = "id, description, amount, currency, category, tags, merchant, date, deleted_at"
+
+// liveCondition restricts a query to expenses that are not in the trash
+const liveCondition = "deleted_at IS NULL"
+
+// SQLiteRepository implements ExpenseRepository using an embedded SQLite database
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository opens, creating if necessary, a SQLite database at dbPath
+func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
+	if dir := filepath.Dir(dbPath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	// Transactions take the write lock when they begin, so one that reads
+	// before writing waits for other writers instead of failing. The path is
+	// escaped so that a '?', '#' or '%' in it is not read as part of the URI.
+	dsn := (&url.URL{
+		Scheme:   "file",
+		Path:     filepath.ToSlash(dbPath),
+		OmitHost: true,
+		RawQuery: fmt.Sprintf("_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_txlock=immediate",
+			defaultLockTimeout.Milliseconds()),
+	}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteRepository{db: db}, nil
+}
+
+// migrateSQLite runs the pending schema migrations in a single transaction,
+// tracking the schema version in the database's user_version
+func migrateSQLite(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", sqliteError(err))
+	}
+	defer tx.Rollback()
+
+	var version int
+	if err := tx.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", sqliteError(err))
+	}
+
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("database has schema version %d, but this version of expense-tracker only supports up to %d; please upgrade",
+			version, len(sqliteMigrations))
+	}
+	if version == len(sqliteMigrations) {
+		return nil
+	}
+
+	for v := version; v < len(sqliteMigrations); v++ {
+		if _, err := tx.Exec(sqliteMigrations[v]); err != nil {
+			return fmt.Errorf("failed to migrate database schema from version %d to %d: %w", v, v+1, sqliteError(err))
+		}
+	}
+
+	// PRAGMA statements cannot take bound parameters
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, len(sqliteMigrations))); err != nil {
+		return fmt.Errorf("failed to update schema version: %w", sqliteError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit schema migration: %w", sqliteError(err))
+	}
+
+	return nil
+}
+
+// Close closes the database
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// sqliteError maps a locked database to ErrBusy and leaves other errors unchanged
+func sqliteError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+			return ErrBusy
+		}
+	}
+	return err
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanExpense reads an expense selected with expenseColumns
+func scanExpense(row scanner) (models.Expense, error) {
+	var expense models.Expense
+	var amount int64
+	var tags, date string
+	var deletedAt sql.NullInt64
+
+	if err := row.Scan(&expense.ID, &expense.Description, &amount, &expense.Currency, &expense.Category, &tags, &expense.Merchant, &date, &deletedAt); err != nil {
+		return models.Expense{}, err
+	}
+	if err := json.Unmarshal([]byte(tags), &expense.Tags); err != nil {
+		return models.Expense{}, fmt.Errorf("invalid tags %q for expense %d: %w", tags, expense.ID, err)
+	}
+	if len(expense.Tags) == 0 {
+		expense.Tags = nil
+	}
+	if deletedAt.Valid {
+		t := time.Unix(0, deletedAt.Int64)
+		expense.DeletedAt = &t
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return models.Expense{}, fmt.Errorf("invalid date %q for expense %d: %w", date, expense.ID, err)
+	}
+	expense.Date = parsed
+	expense.Amount = models.Money(amount)
+
+	return expense, nil
+}
+
+// queryExpenses runs a query selecting expenseColumns and collects the results
+func (r *SQLiteRepository) queryExpenses(query string, args ...any) ([]models.Expense, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expenses: %w", sqliteError(err))
+	}
+
+	return scanExpenses(rows)
+}
+
+// scanExpenses collects and closes the rows of a query selecting expenseColumns
+func scanExpenses(rows *sql.Rows) ([]models.Expense, error) {
+	defer rows.Close()
+
+	var expenses []models.Expense
+	for rows.Next() {
+		expense, err := scanExpense(rows)
+		if err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, expense)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query expenses: %w", sqliteError(err))
+	}
+
+	return expenses, nil
+}
+
+// encodeTags returns the JSON array stored in the tags column
+func encodeTags(tags []string) string {
+	if len(tags) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(tags) // Marshalling a string slice cannot fail
+	return string(data)
+}
+
+// monthPrefix returns the "YYYY-MM" prefix of dates in a month
+func monthPrefix(month time.Month, year int) string {
+	return fmt.Sprintf("%04d-%02d", year, int(month))
+}
+
+// Add stores a new expense and returns its ID
+func (r *SQLiteRepository) Add(expense models.Expense) (int, error) {
+	ids, err := r.AddMany([]models.Expense{expense})
+	if err != nil {
+		return 0, err
+	}
+
+	return ids[0], nil
+}
+
+// AddMany stores new expenses in a single transaction, so either all of them
+// are saved or none are
+func (r *SQLiteRepository) AddMany(expenses []models.Expense) ([]int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", sqliteError(err))
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO expenses (description, amount, currency, category, tags, merchant, date, date_unix) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare insert: %w", sqliteError(err))
+	}
+	defer stmt.Close()
+
+	ids := make([]int, 0, len(expenses))
+	for _, expense := range expenses {
+		if expense.Date.IsZero() {
+			expense.Date = time.Now()
+		}
+
+		result, err := stmt.Exec(expense.Description, int64(expense.Amount), expense.Currency, expense.Category, encodeTags(expense.Tags), expense.Merchant,
+			expense.Date.Format(time.RFC3339Nano), expense.Date.UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert expense: %w", sqliteError(err))
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read expense ID: %w", err)
+		}
+		ids = append(ids, int(id))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit expenses: %w", sqliteError(err))
+	}
+
+	return ids, nil
+}
+
+// Restore stores expenses under their existing IDs in a single transaction. It
+// fails without saving anything if any of the IDs is invalid or already taken.
+func (r *SQLiteRepository) Restore(expenses []models.Expense) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", sqliteError(err))
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO expenses (id, description, amount, currency, category, tags, merchant, date, date_unix, deleted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", sqliteError(err))
+	}
+	defer stmt.Close()
+
+	for _, expense := range expenses {
+		if expense.ID <= 0 {
+			return fmt.Errorf("invalid expense ID %d", expense.ID)
+		}
+
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM expenses WHERE id = ?)`, expense.ID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check expense %d: %w", expense.ID, sqliteError(err))
+		}
+		if exists {
+			return fmt.Errorf("expense %d already exists", expense.ID)
+		}
+
+		var deletedAt sql.NullInt64
+		if expense.DeletedAt != nil {
+			deletedAt = sql.NullInt64{Int64: expense.DeletedAt.UnixNano(), Valid: true}
+		}
+
+		if _, err := stmt.Exec(expense.ID, expense.Description, int64(expense.Amount), expense.Currency, expense.Category, encodeTags(expense.Tags), expense.Merchant,
+			expense.Date.Format(time.RFC3339Nano), expense.Date.UnixNano(), deletedAt); err != nil {
+			return fmt.Errorf("failed to insert expense: %w", sqliteError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit expenses: %w", sqliteError(err))
+	}
+
+	return nil
+}
+
+// GetByID retrieves an expense by its ID
+func (r *SQLiteRepository) GetByID(id int) (models.Expense, error) {
+	row := r.db.QueryRow(`SELECT `+expenseColumns+` FROM expenses WHERE id = ? AND `+liveCondition, id)
+
+	expense, err := scanExpense(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Expense{}, fmt.Errorf("expense %w", ErrNotFound)
+	}
+	if err != nil {
+		return models.Expense{}, fmt.Errorf("failed to get expense: %w", sqliteError(err))
+	}
+
+	return expense, nil
+}
+
+// GetAll retrieves all expenses that are not in the trash
+func (r *SQLiteRepository) GetAll() ([]models.Expense, error) {
+	return r.queryExpenses(`SELECT ` + expenseColumns + ` FROM expenses WHERE ` + liveCondition + ` ORDER BY id`)
+}
+
+// GetByMonth retrieves expenses for a specific month and year
+func (r *SQLiteRepository) GetByMonth(month time.Month, year int) ([]models.Expense, error) {
+	return r.queryExpenses(`SELECT `+expenseColumns+` FROM expenses WHERE substr(date, 1, 7) = ? AND `+liveCondition+` ORDER BY id`,
+		monthPrefix(month, year))
+}
+
+// Find retrieves the expenses selected by a query, filtering, sorting and
+// paging in SQL
+func (r *SQLiteRepository) Find(query Query) ([]models.Expense, error) {
+	where := []string{liveCondition}
+	var args []any
+
+	if !query.From.IsZero() {
+		where = append(where, "date_unix >= ?")
+		args = append(args, query.From.UnixNano())
+	}
+	if !query.To.IsZero() {
+		where = append(where, "date_unix < ?")
+		args = append(args, query.To.UnixNano())
+	}
+	if query.Category != "" {
+		where = append(where, "(category = ? COLLATE NOCASE OR substr(category, 1, ?) = ? COLLATE NOCASE)")
+		prefix := query.Category + models.CategorySeparator
+		args = append(args, query.Category, utf8.RuneCountInString(prefix), prefix)
+	}
+	for _, tag := range query.Tags {
+		where = append(where, "EXISTS (SELECT 1 FROM json_each(tags) WHERE value = lower(?))")
+		args = append(args, tag)
+	}
+	if query.Min != nil {
+		where = append(where, "amount >= ?")
+		args = append(args, int64(*query.Min))
+	}
+	if query.Max != nil {
+		where = append(where, "amount <= ?")
+		args = append(args, int64(*query.Max))
+	}
+	if query.Search != "" {
+		where = append(where, "instr(lower(description), lower(?)) > 0")
+		args = append(args, query.Search)
+	}
+
+	statement := `SELECT ` + expenseColumns + ` FROM expenses WHERE ` + strings.Join(where, " AND ")
+
+	direction := "ASC"
+	if query.Desc {
+		direction = "DESC"
+	}
+	switch query.SortBy {
+	case SortByDate:
+		statement += " ORDER BY date_unix " + direction
+	case SortByAmount:
+		statement += " ORDER BY amount " + direction
+	case SortByDescription:
+		statement += " ORDER BY lower(description) " + direction
+	case SortByCategory:
+		statement += " ORDER BY lower(category) " + direction
+	}
+	if query.SortBy == "" || query.SortBy == SortByID {
+		statement += " ORDER BY id " + direction
+	} else {
+		statement += ", id " + direction
+	}
+
+	if query.Limit > 0 || query.Offset > 0 {
+		limit := query.Limit
+		if limit == 0 {
+			limit = -1 // No limit
+		}
+		statement += " LIMIT ? OFFSET ?"
+		args = append(args, limit, query.Offset)
+	}
+
+	expenses, err := r.queryExpenses(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	if expenses == nil {
+		expenses = []models.Expense{}
+	}
+
+	return expenses, nil
+}
+
+// Update replaces the stored expense that has the same ID
+func (r *SQLiteRepository) Update(expense models.Expense) error {
+	return r.UpdateMany([]models.Expense{expense})
+}
+
+// UpdateMany replaces the stored expenses that have the same IDs in a single
+// transaction, so either all of them are changed or none are
+func (r *SQLiteRepository) UpdateMany(expenses []models.Expense) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", sqliteError(err))
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`UPDATE expenses SET description = ?, amount = ?, currency = ?, category = ?, tags = ?, merchant = ?, date = ?, date_unix = ? WHERE id = ? AND ` + liveCondition)
+	if err != nil {
+		return fmt.Errorf("failed to prepare update: %w", sqliteError(err))
+	}
+	defer stmt.Close()
+
+	for _, expense := range expenses {
+		result, err := stmt.Exec(expense.Description, int64(expense.Amount), expense.Currency, expense.Category, encodeTags(expense.Tags), expense.Merchant,
+			expense.Date.Format(time.RFC3339Nano), expense.Date.UnixNano(), expense.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update expense: %w", sqliteError(err))
+		}
+		if err := expectOneRow(result); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit expenses: %w", sqliteError(err))
+	}
+
+	return nil
+}
+
+// Delete moves an expense to the trash by its ID
+func (r *SQLiteRepository) Delete(id int) error {
+	result, err := r.db.Exec(`UPDATE expenses SET deleted_at = ? WHERE id = ? AND `+liveCondition, time.Now().UnixNano(), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete expense: %w", sqliteError(err))
+	}
+
+	return expectOneRow(result)
+}
+
+// GetDeleted retrieves the expenses in the trash, most recently deleted first
+func (r *SQLiteRepository) GetDeleted() ([]models.Expense, error) {
+	return r.queryExpenses(`SELECT ` + expenseColumns + ` FROM expenses WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`)
+}
+
+// Undelete takes an expense out of the trash by its ID
+func (r *SQLiteRepository) Undelete(id int) error {
+	result, err := r.db.Exec(`UPDATE expenses SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore expense: %w", sqliteError(err))
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("expense %w in trash", ErrNotFound)
+	}
+	return nil
+}
+
+// Purge permanently removes the expenses moved to the trash before the given
+// time and returns how many were removed
+func (r *SQLiteRepository) Purge(deletedBefore time.Time) (int, error) {
+	result, err := r.db.Exec(`DELETE FROM expenses WHERE deleted_at < ?`, deletedBefore.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("failed to empty trash: %w", sqliteError(err))
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// Transaction passes every expense, including those in the trash, to fn and
+// saves the expenses it returns in a single transaction, replacing the
+// expenses with the same IDs or adding them under their IDs
+func (r *SQLiteRepository) Transaction(fn func(expenses []models.Expense) ([]models.Expense, error)) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", sqliteError(err))
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT ` + expenseColumns + ` FROM expenses ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to query expenses: %w", sqliteError(err))
+	}
+	expenses, err := scanExpenses(rows)
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[int]bool, len(expenses))
+	for _, expense := range expenses {
+		exists[expense.ID] = true
+	}
+
+	changed, err := fn(expenses)
+	if err != nil {
+		return err
+	}
+
+	insert, err := tx.Prepare(`INSERT INTO expenses (id, description, amount, currency, category, tags, merchant, date, date_unix, deleted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", sqliteError(err))
+	}
+	defer insert.Close()
+
+	update, err := tx.Prepare(`UPDATE expenses SET description = ?, amount = ?, currency = ?, category = ?, tags = ?, merchant = ?, date = ?, date_unix = ?, deleted_at = ? WHERE id = ?`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare update: %w", sqliteError(err))
+	}
+	defer update.Close()
+
+	for _, expense := range changed {
+		if expense.ID <= 0 {
+			return fmt.Errorf("invalid expense ID %d", expense.ID)
+		}
+
+		var deletedAt sql.NullInt64
+		if expense.DeletedAt != nil {
+			deletedAt = sql.NullInt64{Int64: expense.DeletedAt.UnixNano(), Valid: true}
+		}
+
+		if exists[expense.ID] {
+			_, err = update.Exec(expense.Description, int64(expense.Amount), expense.Currency, expense.Category, encodeTags(expense.Tags), expense.Merchant,
+				expense.Date.Format(time.RFC3339Nano), expense.Date.UnixNano(), deletedAt, expense.ID)
+		} else {
+			_, err = insert.Exec(expense.ID, expense.Description, int64(expense.Amount), expense.Currency, expense.Category, encodeTags(expense.Tags), expense.Merchant,
+				expense.Date.Format(time.RFC3339Nano), expense.Date.UnixNano(), deletedAt)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save expense: %w", sqliteError(err))
+		}
+		exists[expense.ID] = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit expenses: %w", sqliteError(err))
+	}
+
+	return nil
+}
+
+// expectOneRow reports "expense not found" when a statement changed no rows
+func expectOneRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("expense %w", ErrNotFound)
+	}
+	return nil
+}
+
+// GetSummary returns a summary of all expenses, aggregated in SQL
+func (r *SQLiteRepository) GetSummary() (models.ExpenseSummary, error) {
+	return r.summarize("WHERE "+liveCondition, nil)
+}
+
+// GetMonthlySummary returns a summary of expenses for a specific month and year,
+// aggregated in SQL
+func (r *SQLiteRepository) GetMonthlySummary(month time.Month, year int) (models.ExpenseSummary, error) {
+	summary, err := r.summarize("WHERE substr(date, 1, 7) = ? AND "+liveCondition, []any{monthPrefix(month, year)})
+	if err != nil {
+		return models.ExpenseSummary{}, err
+	}
+
+	summary.Month = month
+	summary.Year = year
+	return summary, nil
+}
+
+// summarize totals the expenses matching a WHERE clause by currency, category and tag
+func (r *SQLiteRepository) summarize(where string, args []any) (models.ExpenseSummary, error) {
+	rows, err := r.db.Query(`SELECT currency, category, SUM(amount), COUNT(*) FROM expenses `+where+` GROUP BY currency, category`, args...)
+	if err != nil {
+		return models.ExpenseSummary{}, fmt.Errorf("failed to summarize expenses: %w", sqliteError(err))
+	}
+	defer rows.Close()
+
+	summary := models.ExpenseSummary{
+		CategoryTotals: make(map[string]models.Money),
+		CurrencyTotals: make(map[string]models.Money),
+		TagTotals:      make(map[string]models.Money),
+	}
+
+	for rows.Next() {
+		var currency, category string
+		var total int64
+		var count int
+		if err := rows.Scan(&currency, &category, &total, &count); err != nil {
+			return models.ExpenseSummary{}, fmt.Errorf("failed to summarize expenses: %w", err)
+		}
+
+		amount := models.Money(total)
+		summary.TotalAmount += amount
+		summary.ExpenseCount += count
+		summary.CurrencyTotals[models.Expense{Currency: currency}.CurrencyCode()] += amount
+		if category != "" {
+			summary.CategoryTotals[category] += amount
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return models.ExpenseSummary{}, fmt.Errorf("failed to summarize expenses: %w", sqliteError(err))
+	}
+
+	if err := r.summarizeTags(where, args, summary.TagTotals); err != nil {
+		return models.ExpenseSummary{}, err
+	}
+
+	return summary, nil
+}
+
+// summarizeTags adds the totals per tag of the expenses matching a WHERE
+// clause to totals
+func (r *SQLiteRepository) summarizeTags(where string, args []any, totals map[string]models.Money) error {
+	rows, err := r.db.Query(`SELECT json_each.value, SUM(amount) FROM expenses, json_each(expenses.tags) `+where+` GROUP BY json_each.value`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to summarize tags: %w", sqliteError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag string
+		var total int64
+		if err := rows.Scan(&tag, &total); err != nil {
+			return fmt.Errorf("failed to summarize tags: %w", err)
+		}
+		totals[tag] += models.Money(total)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to summarize tags: %w", sqliteError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewSQLiteRepositoryPath(t *testing.T) {
+	tests := []string{
+		"expenses.db",
+		"with space.db",
+		"what?.db",
+		"hash#1.db",
+		"100%.db",
+		"a%20b.db",
+		"sub dir/expenses.db",
+	}
+
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, name)
+
+			repo, err := NewSQLiteRepository(path)
+			if err != nil {
+				t.Fatalf("NewSQLiteRepository failed: %v", err)
+			}
+			if _, err := repo.Add(testExpense("Coffee")); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// The database is created at exactly the given path
+			if _, err := os.Stat(path); err != nil {
+				t.Fatalf("database not created at %s: %v", path, err)
+			}
+
+			repo, err = NewSQLiteRepository(path)
+			if err != nil {
+				t.Fatalf("reopening failed: %v", err)
+			}
+			defer repo.Close()
+			expenses, err := repo.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(expenses) != 1 {
+				t.Errorf("reopened database has %d expenses, want 1", len(expenses))
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package repository
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,10 +12,13 @@ import (
 	"github.com/Businge931/expense-tracker/internal/models"
 )
 
-// JSONFileRepository implements ExpenseRepository using a JSON file for storage
+// JSONFileRepository implements ExpenseRepository using a JSON file for storage.
+// Every read-modify-write is a transaction held under an advisory lock on the
+// file, so concurrent goroutines and CLI processes never lose each other's updates.
 type JSONFileRepository struct {
-	filePath string
-	mutex    sync.RWMutex
+	filePath    string
+	mutex       sync.RWMutex
+	lockTimeout time.Duration
 }
 
 // NewJSONFileRepository creates a new repository that stores data in a JSON file
@@ -26,46 +28,95 @@ func NewJSONFileRepository(dataDir string) (*JSONFileRepository, error) {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	filePath := filepath.Join(dataDir, "expenses.json")
+	repo := &JSONFileRepository{
+		filePath:    filepath.Join(dataDir, "expenses.json"),
+		lockTimeout: defaultLockTimeout,
+	}
+
+	lock, err := acquireLock(lockPath(repo.filePath), true, repo.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
 
 	// Create file if it doesn't exist
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	if _, err := os.Stat(repo.filePath); os.IsNotExist(err) {
 		// Initialize with empty expenses array
-		initialData := struct {
-			Expenses []models.Expense `json:"expenses"`
-		}{
-			Expenses: []models.Expense{},
+		if err := repo.writeExpensesFile([]models.Expense{}); err != nil {
+			return nil, fmt.Errorf("failed to create initial expenses file: %w", err)
 		}
-
-		data, err := json.MarshalIndent(initialData, "", "  ")
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal initial data: %w", err)
+	} else {
+		// Fall back to the last good backup if the file was damaged
+		if err := recoverCorruptFile(repo.filePath, validateExpensesFile); err != nil {
+			return nil, err
 		}
-
-		if err := os.WriteFile(filePath, data, 0644); err != nil {
-			return nil, fmt.Errorf("failed to create initial expenses file: %w", err)
+		if err := migrateDataFile(repo.filePath, "expenses"); err != nil {
+			return nil, fmt.Errorf("failed to migrate expenses file: %w", err)
 		}
 	}
 
-	return &JSONFileRepository{
-		filePath: filePath,
-	}, nil
+	return repo, nil
+}
+
+// expensesFile is the versioned envelope stored in expenses.json
+type expensesFile struct {
+	Version  int              `json:"version"`
+	Expenses []models.Expense `json:"expenses"`
 }
 
-// loadExpenses reads all expenses from the JSON file
+// validateExpensesFile checks that data is a readable expenses file
+func validateExpensesFile(data []byte) error {
+	var file expensesFile
+	return json.Unmarshal(data, &file)
+}
+
+// loadExpenses reads all expenses from the JSON file under a shared lock
 func (r *JSONFileRepository) loadExpenses() ([]models.Expense, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	file, err := os.ReadFile(r.filePath)
+	lock, err := acquireLock(lockPath(r.filePath), false, r.lockTimeout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read expenses file: %w", err)
+		return nil, err
 	}
+	defer lock.release()
+
+	return r.readExpensesFile()
+}
 
-	var data struct {
-		Expenses []models.Expense `json:"expenses"`
+// updateExpenses runs a read-modify-write transaction under an exclusive lock.
+// The expenses returned by fn are saved unless it returns an error.
+func (r *JSONFileRepository) updateExpenses(fn func(expenses []models.Expense) ([]models.Expense, error)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lock, err := acquireLock(lockPath(r.filePath), true, r.lockTimeout)
+	if err != nil {
+		return err
 	}
+	defer lock.release()
 
+	expenses, err := r.readExpensesFile()
+	if err != nil {
+		return err
+	}
+
+	expenses, err = fn(expenses)
+	if err != nil {
+		return err
+	}
+
+	return r.writeExpensesFile(expenses)
+}
+
+// readExpensesFile reads all expenses from the JSON file; the caller holds the lock
+func (r *JSONFileRepository) readExpensesFile() ([]models.Expense, error) {
+	file, err := os.ReadFile(r.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expenses file: %w", err)
+	}
+
+	var data expensesFile
 	if err := json.Unmarshal(file, &data); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal expenses: %w", err)
 	}
@@ -73,14 +124,10 @@ func (r *JSONFileRepository) loadExpenses() ([]models.Expense, error) {
 	return data.Expenses, nil
 }
 
-// saveExpenses writes all expenses to the JSON file
-func (r *JSONFileRepository) saveExpenses(expenses []models.Expense) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	data := struct {
-		Expenses []models.Expense `json:"expenses"`
-	}{
+// writeExpensesFile writes all expenses to the JSON file; the caller holds the lock
+func (r *JSONFileRepository) writeExpensesFile(expenses []models.Expense) error {
+	data := expensesFile{
+		Version:  currentSchemaVersion,
 		Expenses: expenses,
 	}
 
@@ -89,44 +136,80 @@ func (r *JSONFileRepository) saveExpenses(expenses []models.Expense) error {
 		return fmt.Errorf("failed to marshal expenses: %w", err)
 	}
 
-	if err := os.WriteFile(r.filePath, fileData, 0644); err != nil {
+	if err := writeFileAtomic(r.filePath, fileData, 0644); err != nil {
 		return fmt.Errorf("failed to write expenses file: %w", err)
 	}
 
 	return nil
 }
 
-// Add adds a new expense and returns its ID
-func (r *JSONFileRepository) Add(description string, amount float64, category string) (int, error) {
-	expenses, err := r.loadExpenses()
+// Add stores a new expense under the next free ID and returns that ID
+func (r *JSONFileRepository) Add(expense models.Expense) (int, error) {
+	ids, err := r.AddMany([]models.Expense{expense})
 	if err != nil {
 		return 0, err
 	}
 
-	// Find the highest ID and increment
-	maxID := 0
-	for _, e := range expenses {
-		if e.ID > maxID {
-			maxID = e.ID
+	return ids[0], nil
+}
+
+// AddMany stores new expenses under consecutive free IDs with a single write,
+// so either all of them are saved or none are
+func (r *JSONFileRepository) AddMany(newExpenses []models.Expense) ([]int, error) {
+	ids := make([]int, 0, len(newExpenses))
+
+	err := r.updateExpenses(func(expenses []models.Expense) ([]models.Expense, error) {
+		// Find the highest ID and increment
+		maxID := 0
+		for _, e := range expenses {
+			if e.ID > maxID {
+				maxID = e.ID
+			}
 		}
-	}
 
-	// Create new expense
-	expense := models.Expense{
-		ID:          maxID + 1,
-		Description: description,
-		Amount:      amount,
-		Category:    category,
-		Date:        time.Now(),
-	}
+		for _, expense := range newExpenses {
+			maxID++
+			expense.ID = maxID
+			if expense.Date.IsZero() {
+				expense.Date = time.Now()
+			}
 
-	expenses = append(expenses, expense)
+			expenses = append(expenses, expense)
+			ids = append(ids, expense.ID)
+		}
 
-	if err := r.saveExpenses(expenses); err != nil {
-		return 0, err
+		return expenses, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return expense.ID, nil
+	return ids, nil
+}
+
+// Restore stores expenses under their existing IDs with a single write. It
+// fails without saving anything if any of the IDs is invalid or already taken.
+func (r *JSONFileRepository) Restore(restored []models.Expense) error {
+	return r.updateExpenses(func(expenses []models.Expense) ([]models.Expense, error) {
+		taken := make(map[int]bool, len(expenses)+len(restored))
+		for _, e := range expenses {
+			taken[e.ID] = true
+		}
+
+		for _, expense := range restored {
+			if expense.ID <= 0 {
+				return nil, fmt.Errorf("invalid expense ID %d", expense.ID)
+			}
+			if taken[expense.ID] {
+				return nil, fmt.Errorf("expense %d already exists", expense.ID)
+			}
+			taken[expense.ID] = true
+			expenses = append(expenses, expense)
+		}
+
+		slices.SortFunc(expenses, func(a, b models.Expense) int { return a.ID - b.ID })
+		return expenses, nil
+	})
 }
 
 // GetByID retrieves an expense by its ID
@@ -137,22 +220,34 @@ func (r *JSONFileRepository) GetByID(id int) (models.Expense, error) {
 	}
 
 	for _, expense := range expenses {
-		if expense.ID == id {
+		if expense.ID == id && expense.DeletedAt == nil {
 			return expense, nil
 		}
 	}
 
-	return models.Expense{}, errors.New("expense not found")
+	return models.Expense{}, fmt.Errorf("expense %w", ErrNotFound)
 }
 
-// GetAll retrieves all expenses
+// GetAll retrieves all expenses that are not in the trash
 func (r *JSONFileRepository) GetAll() ([]models.Expense, error) {
-	return r.loadExpenses()
+	expenses, err := r.loadExpenses()
+	if err != nil {
+		return nil, err
+	}
+
+	return liveExpenses(expenses), nil
+}
+
+// liveExpenses returns the expenses that are not in the trash
+func liveExpenses(expenses []models.Expense) []models.Expense {
+	return slices.DeleteFunc(expenses, func(e models.Expense) bool {
+		return e.DeletedAt != nil
+	})
 }
 
 // GetByMonth retrieves expenses for a specific month and year
 func (r *JSONFileRepository) GetByMonth(month time.Month, year int) ([]models.Expense, error) {
-	expenses, err := r.loadExpenses()
+	expenses, err := r.GetAll()
 	if err != nil {
 		return nil, err
 	}
@@ -167,52 +262,154 @@ func (r *JSONFileRepository) GetByMonth(month time.Month, year int) ([]models.Ex
 	return result, nil
 }
 
-// Delete removes an expense by its ID
-func (r *JSONFileRepository) Delete(id int) error {
-	expenses, err := r.loadExpenses()
+// Find retrieves the expenses selected by a query
+func (r *JSONFileRepository) Find(query Query) ([]models.Expense, error) {
+	expenses, err := r.GetAll()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	foundIndex := -1
-	for i, expense := range expenses {
-		if expense.ID == id {
-			foundIndex = i
-			break
+	return query.Apply(expenses), nil
+}
+
+// Update replaces the stored expense that has the same ID
+func (r *JSONFileRepository) Update(expense models.Expense) error {
+	return r.UpdateMany([]models.Expense{expense})
+}
+
+// UpdateMany replaces the stored expenses that have the same IDs in a single
+// write, so either all of them are changed or none are
+func (r *JSONFileRepository) UpdateMany(updated []models.Expense) error {
+	return r.updateExpenses(func(expenses []models.Expense) ([]models.Expense, error) {
+		for _, expense := range updated {
+			foundIndex := -1
+			for i, e := range expenses {
+				if e.ID == expense.ID && e.DeletedAt == nil {
+					foundIndex = i
+					break
+				}
+			}
+
+			if foundIndex == -1 {
+				return nil, fmt.Errorf("expense %w", ErrNotFound)
+			}
+
+			expense.DeletedAt = nil
+			expenses[foundIndex] = expense
 		}
-	}
+		return expenses, nil
+	})
+}
 
-	if foundIndex == -1 {
-		return errors.New("expense not found")
-	}
+// Delete moves an expense to the trash by its ID
+func (r *JSONFileRepository) Delete(id int) error {
+	return r.updateExpenses(func(expenses []models.Expense) ([]models.Expense, error) {
+		foundIndex := -1
+		for i, expense := range expenses {
+			if expense.ID == id && expense.DeletedAt == nil {
+				foundIndex = i
+				break
+			}
+		}
 
-	// Remove the expense from the slice
-	expenses = slices.Delete(expenses, foundIndex, foundIndex+1)
+		if foundIndex == -1 {
+			return nil, fmt.Errorf("expense %w", ErrNotFound)
+		}
 
-	return r.saveExpenses(expenses)
+		now := time.Now()
+		expenses[foundIndex].DeletedAt = &now
+		return expenses, nil
+	})
 }
 
-// GetSummary returns a summary of all expenses
-func (r *JSONFileRepository) GetSummary() (models.ExpenseSummary, error) {
+// GetDeleted retrieves the expenses in the trash, most recently deleted first
+func (r *JSONFileRepository) GetDeleted() ([]models.Expense, error) {
 	expenses, err := r.loadExpenses()
 	if err != nil {
-		return models.ExpenseSummary{}, err
+		return nil, err
 	}
 
-	summary := models.ExpenseSummary{
-		TotalAmount:    0,
-		CategoryTotals: make(map[string]float64),
-		ExpenseCount:   len(expenses),
+	return trashedExpenses(expenses), nil
+}
+
+// trashedExpenses returns the expenses in the trash, most recently deleted first
+func trashedExpenses(expenses []models.Expense) []models.Expense {
+	deleted := slices.DeleteFunc(expenses, func(e models.Expense) bool {
+		return e.DeletedAt == nil
+	})
+	slices.SortStableFunc(deleted, func(a, b models.Expense) int {
+		return b.DeletedAt.Compare(*a.DeletedAt)
+	})
+	return deleted
+}
+
+// Undelete takes an expense out of the trash by its ID
+func (r *JSONFileRepository) Undelete(id int) error {
+	return r.updateExpenses(func(expenses []models.Expense) ([]models.Expense, error) {
+		for i, expense := range expenses {
+			if expense.ID == id && expense.DeletedAt != nil {
+				expenses[i].DeletedAt = nil
+				return expenses, nil
+			}
+		}
+
+		return nil, fmt.Errorf("expense %w in trash", ErrNotFound)
+	})
+}
+
+// Purge permanently removes the expenses moved to the trash before the given
+// time and returns how many were removed
+func (r *JSONFileRepository) Purge(deletedBefore time.Time) (int, error) {
+	purged := 0
+
+	err := r.updateExpenses(func(expenses []models.Expense) ([]models.Expense, error) {
+		kept := slices.DeleteFunc(expenses, func(e models.Expense) bool {
+			return e.DeletedAt != nil && e.DeletedAt.Before(deletedBefore)
+		})
+		purged = len(expenses) - len(kept)
+		return kept, nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	for _, expense := range expenses {
-		summary.TotalAmount += expense.Amount
-		if expense.Category != "" {
-			summary.CategoryTotals[expense.Category] += expense.Amount
+	return purged, nil
+}
+
+// Transaction passes every expense, including those in the trash, to fn and
+// saves the expenses it returns in a single write, replacing the expenses with
+// the same IDs or adding them under their IDs
+func (r *JSONFileRepository) Transaction(fn func(expenses []models.Expense) ([]models.Expense, error)) error {
+	return r.updateExpenses(func(expenses []models.Expense) ([]models.Expense, error) {
+		changed, err := fn(slices.Clone(expenses))
+		if err != nil {
+			return nil, err
 		}
+
+		for _, expense := range changed {
+			if expense.ID <= 0 {
+				return nil, fmt.Errorf("invalid expense ID %d", expense.ID)
+			}
+			if i := slices.IndexFunc(expenses, func(e models.Expense) bool { return e.ID == expense.ID }); i >= 0 {
+				expenses[i] = expense
+			} else {
+				expenses = append(expenses, expense)
+			}
+		}
+
+		slices.SortFunc(expenses, func(a, b models.Expense) int { return a.ID - b.ID })
+		return expenses, nil
+	})
+}
+
+// GetSummary returns a summary of all expenses
+func (r *JSONFileRepository) GetSummary() (models.ExpenseSummary, error) {
+	expenses, err := r.GetAll()
+	if err != nil {
+		return models.ExpenseSummary{}, err
 	}
 
-	return summary, nil
+	return summarizeExpenses(expenses), nil
 }
 
 // GetMonthlySummary returns a summary of expenses for a specific month and year
@@ -222,20 +419,32 @@ func (r *JSONFileRepository) GetMonthlySummary(month time.Month, year int) (mode
 		return models.ExpenseSummary{}, err
 	}
 
+	summary := summarizeExpenses(expenses)
+	summary.Month = month
+	summary.Year = year
+	return summary, nil
+}
+
+// summarizeExpenses totals expenses per currency, per category and per tag
+func summarizeExpenses(expenses []models.Expense) models.ExpenseSummary {
 	summary := models.ExpenseSummary{
 		TotalAmount:    0,
-		CategoryTotals: make(map[string]float64),
+		CategoryTotals: make(map[string]models.Money),
+		CurrencyTotals: make(map[string]models.Money),
+		TagTotals:      make(map[string]models.Money),
 		ExpenseCount:   len(expenses),
-		Month:          month,
-		Year:           year,
 	}
 
 	for _, expense := range expenses {
 		summary.TotalAmount += expense.Amount
+		summary.CurrencyTotals[expense.CurrencyCode()] += expense.Amount
 		if expense.Category != "" {
 			summary.CategoryTotals[expense.Category] += expense.Amount
 		}
+		for _, tag := range expense.Tags {
+			summary.TagTotals[tag] += expense.Amount
+		}
 	}
 
-	return summary, nil
+	return summary
 }
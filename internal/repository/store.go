@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OpenExpenseRepository opens the expense store described by spec, which has
+// the form "json:DIRECTORY", "sqlite:FILE" or "events:DIRECTORY". A spec without a kind is
+// treated as a JSON data directory.
+func OpenExpenseRepository(spec string) (ExpenseRepository, error) {
+	kind, path, err := ParseStoreSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case "json":
+		return NewJSONFileRepository(path)
+	case "sqlite":
+		return NewSQLiteRepository(path)
+	case "events":
+		return NewEventLogRepository(path)
+	default:
+		return nil, fmt.Errorf("invalid store %q: unknown kind %q, expected json, sqlite or events", spec, kind)
+	}
+}
+
+// ParseStoreSpec splits a store spec into its kind and path, defaulting to
+// the json kind when the spec has none
+func ParseStoreSpec(spec string) (kind, path string, err error) {
+	kind, path, found := strings.Cut(spec, ":")
+	if !found {
+		kind, path = "json", spec
+	}
+	if path == "" {
+		return "", "", fmt.Errorf("invalid store %q: missing path", spec)
+	}
+	return kind, path, nil
+}
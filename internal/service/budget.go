@@ -1,39 +1,38 @@
 package service
 
 import (
-	"errors"
 	"fmt"
 	"time"
-)
 
-// Budget represents a monthly budget
-type Budget struct {
-	Month  time.Month `json:"month"`
-	Year   int        `json:"year"`
-	Amount float64    `json:"amount"`
-}
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
 
 // BudgetService handles budget-related operations
 type BudgetService struct {
+	repo           repository.BudgetRepository
 	expenseService *ExpenseService
-	budgets        map[string]Budget // key is "year-month"
+	journal        repository.JournalRepository
 }
 
-// NewBudgetService creates a new budget service
-func NewBudgetService(expenseService *ExpenseService) *BudgetService {
+// NewBudgetService creates a new budget service. Changes are recorded in the
+// journal for undo, unless it is nil.
+func NewBudgetService(repo repository.BudgetRepository, expenseService *ExpenseService, journal repository.JournalRepository) *BudgetService {
 	return &BudgetService{
+		repo:           repo,
 		expenseService: expenseService,
-		budgets:        make(map[string]Budget),
+		journal:        journal,
 	}
 }
 
-// SetBudget sets a budget for a specific month and year
-func (s *BudgetService) SetBudget(month int, year int, amount float64) error {
+// SetBudget sets a budget for a specific month and year, replacing any existing one.
+// An empty category sets the overall cap for the month.
+func (s *BudgetService) SetBudget(month int, year int, category string, amount models.Money) error {
 	if month < 1 || month > 12 {
-		return errors.New("month must be between 1 and 12")
+		return invalidInput("month must be between 1 and 12")
 	}
 	if amount <= 0 {
-		return errors.New("budget amount must be greater than zero")
+		return invalidInput("budget amount must be greater than zero")
 	}
 
 	// If year is not specified (0), use current year
@@ -41,20 +40,46 @@ func (s *BudgetService) SetBudget(month int, year int, amount float64) error {
 		year = time.Now().Year()
 	}
 
-	key := fmt.Sprintf("%d-%d", year, month)
-	s.budgets[key] = Budget{
-		Month:  time.Month(month),
-		Year:   year,
-		Amount: amount,
+	category, err := s.expenseService.ResolveCategory(category)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	budget := models.Budget{
+		Month:    time.Month(month),
+		Year:     year,
+		Category: category,
+		Amount:   amount,
+	}
+
+	change := models.BudgetChange{After: &budget}
+	if existing, err := s.repo.Get(budget.Month, budget.Year, budget.Category); err == nil {
+		change.Before = &existing
+	}
+
+	if err := s.repo.Set(budget); err != nil {
+		return err
+	}
+
+	return recordOperation(s.journal, models.Operation{
+		Description: "set " + budgetDescription(budget),
+		Budgets:     []models.BudgetChange{change},
+	})
+}
+
+// budgetDescription names a budget in journal entries, e.g. "Food budget for June 2025"
+func budgetDescription(budget models.Budget) string {
+	name := "budget"
+	if budget.Category != "" {
+		name = budget.Category + " budget"
+	}
+	return fmt.Sprintf("%s for %s %d", name, budget.Month, budget.Year)
 }
 
-// GetBudget returns the budget for a specific month and year
-func (s *BudgetService) GetBudget(month int, year int) (Budget, error) {
+// GetBudget returns the budget for a specific month, year and category
+func (s *BudgetService) GetBudget(month int, year int, category string) (models.Budget, error) {
 	if month < 1 || month > 12 {
-		return Budget{}, errors.New("month must be between 1 and 12")
+		return models.Budget{}, invalidInput("month must be between 1 and 12")
 	}
 
 	// If year is not specified (0), use current year
@@ -62,30 +87,100 @@ func (s *BudgetService) GetBudget(month int, year int) (Budget, error) {
 		year = time.Now().Year()
 	}
 
-	key := fmt.Sprintf("%d-%d", year, month)
-	budget, exists := s.budgets[key]
-	if !exists {
-		return Budget{}, errors.New("budget not found for specified month and year")
+	category, err := s.expenseService.canonicalCategory(category)
+	if err != nil {
+		return models.Budget{}, err
 	}
 
-	return budget, nil
+	return s.repo.Get(time.Month(month), year, category)
+}
+
+// GetAllBudgets returns all stored budgets
+func (s *BudgetService) GetAllBudgets() ([]models.Budget, error) {
+	return s.repo.GetAll()
 }
 
-// CheckBudget checks if the current expenses exceed the budget for a specific month and year
-// Returns remaining budget amount and a boolean indicating if budget is exceeded
-func (s *BudgetService) CheckBudget(month int, year int) (float64, bool, error) {
-	budget, err := s.GetBudget(month, year)
+// DeleteBudget deletes the budget for a specific month, year and category
+func (s *BudgetService) DeleteBudget(month int, year int, category string) error {
+	if month < 1 || month > 12 {
+		return invalidInput("month must be between 1 and 12")
+	}
+
+	// If year is not specified (0), use current year
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	category, err := s.expenseService.canonicalCategory(category)
 	if err != nil {
-		return 0, false, err
+		return err
 	}
 
-	summary, err := s.expenseService.GetMonthlySummary(month)
+	budget, err := s.repo.Get(time.Month(month), year, category)
 	if err != nil {
-		return 0, false, err
+		return err
 	}
 
-	remaining := budget.Amount - summary.TotalAmount
-	exceeded := remaining < 0
+	if err := s.repo.Delete(budget.Month, budget.Year, budget.Category); err != nil {
+		return err
+	}
 
-	return remaining, exceeded, nil
+	return recordOperation(s.journal, models.Operation{
+		Description: "delete " + budgetDescription(budget),
+		Budgets:     []models.BudgetChange{{Before: &budget}},
+	})
+}
+
+// CheckBudget checks the expenses for a specific month and year against the overall
+// cap and every category budget set for that month
+func (s *BudgetService) CheckBudget(month int, year int) (models.BudgetReport, error) {
+	if month < 1 || month > 12 {
+		return models.BudgetReport{}, invalidInput("month must be between 1 and 12")
+	}
+
+	// If year is not specified (0), use current year
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	budgets, err := s.repo.GetByMonth(time.Month(month), year)
+	if err != nil {
+		return models.BudgetReport{}, err
+	}
+	if len(budgets) == 0 {
+		return models.BudgetReport{}, fmt.Errorf("budget %w for specified month and year", repository.ErrNotFound)
+	}
+
+	summary, err := s.expenseService.GetMonthlySummary(month, year)
+	if err != nil {
+		return models.BudgetReport{}, err
+	}
+
+	report := models.BudgetReport{
+		Month: time.Month(month),
+		Year:  year,
+	}
+
+	for _, budget := range budgets {
+		if budget.Category == "" {
+			status := newBudgetStatus(budget, summary.TotalAmount)
+			report.Overall = &status
+			continue
+		}
+		report.Categories = append(report.Categories, newBudgetStatus(budget, summary.CategoryTotal(budget.Category)))
+	}
+
+	return report, nil
+}
+
+// newBudgetStatus compares the amount spent with a budget
+func newBudgetStatus(budget models.Budget, spent models.Money) models.BudgetStatus {
+	remaining := budget.Amount - spent
+	return models.BudgetStatus{
+		Category:  budget.Category,
+		Budget:    budget.Amount,
+		Spent:     spent,
+		Remaining: remaining,
+		Exceeded:  remaining < 0,
+	}
 }
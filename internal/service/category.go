@@ -0,0 +1,407 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+// CategoryService manages the category catalog and keeps expenses and budgets
+// in step when categories are renamed, merged or deleted
+type CategoryService struct {
+	repo           repository.CategoryRepository
+	expenseService *ExpenseService
+	budgets        repository.BudgetRepository
+	journal        repository.JournalRepository
+}
+
+// CategoryUsage reports how many expenses are booked on a category
+type CategoryUsage struct {
+	Name      string `json:"name"`
+	Expenses  int    `json:"expenses"`
+	InCatalog bool   `json:"inCatalog"`
+}
+
+// NewCategoryService creates a new category service. Changes are recorded in
+// the journal for undo, unless it is nil.
+func NewCategoryService(repo repository.CategoryRepository, expenseService *ExpenseService, budgets repository.BudgetRepository, journal repository.JournalRepository) *CategoryService {
+	return &CategoryService{
+		repo:           repo,
+		expenseService: expenseService,
+		budgets:        budgets,
+		journal:        journal,
+	}
+}
+
+// GetCatalog returns the category catalog
+func (s *CategoryService) GetCatalog() (models.CategoryCatalog, error) {
+	return s.repo.Load()
+}
+
+// GetCategories returns every category in the catalog or used by an
+// expense, with the parents of each, ordered so that subcategories follow
+// their parent. Names that differ only in case are counted together.
+func (s *CategoryService) GetCategories() ([]CategoryUsage, error) {
+	catalog, err := s.repo.Load()
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.expenseService.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]*CategoryUsage)
+	var use func(name string, inCatalog bool) *CategoryUsage
+	use = func(name string, inCatalog bool) *CategoryUsage {
+		key := strings.ToLower(name)
+		if usage[key] == nil {
+			usage[key] = &CategoryUsage{Name: name, InCatalog: inCatalog}
+			if parent := models.CategoryParent(name); parent != "" {
+				use(parent, false)
+			}
+		}
+		if inCatalog {
+			usage[key].InCatalog = true
+		}
+		return usage[key]
+	}
+
+	for _, category := range catalog.Categories {
+		use(category.Name, true)
+	}
+	for _, expense := range expenses {
+		if name, err := models.NormalizeCategory(expense.Category); err == nil && name != "" {
+			use(name, false).Expenses++
+		}
+	}
+
+	result := make([]CategoryUsage, 0, len(usage))
+	for _, category := range usage {
+		result = append(result, *category)
+	}
+	slices.SortFunc(result, func(a, b CategoryUsage) int {
+		return models.CompareCategories(a.Name, b.Name)
+	})
+
+	return result, nil
+}
+
+// AddCategory adds a category, and any of its parents that are missing, to
+// the catalog and returns its name
+func (s *CategoryService) AddCategory(name string) (string, error) {
+	name, err := models.NormalizeCategory(name)
+	if err != nil {
+		return "", asInvalidInput(err)
+	}
+	if name == "" {
+		return "", invalidInput("category name cannot be empty")
+	}
+
+	err = s.updateCatalog("add category "+name, func(catalog models.CategoryCatalog) (models.CategoryCatalog, error) {
+		if existing, ok := catalog.Lookup(name); ok {
+			return catalog, invalidInput("category %q already exists", existing)
+		}
+		return withCategory(catalog, name), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// SetStrict turns strict mode on or off. In strict mode new expenses, budgets
+// and recurring rules must use a category from the catalog.
+func (s *CategoryService) SetStrict(strict bool) error {
+	description := "turn off strict categories"
+	if strict {
+		description = "turn on strict categories"
+	}
+
+	return s.updateCatalog(description, func(catalog models.CategoryCatalog) (models.CategoryCatalog, error) {
+		catalog.Strict = strict
+		return catalog, nil
+	})
+}
+
+// updateCatalog changes the catalog and records the change as an operation
+func (s *CategoryService) updateCatalog(description string, fn func(catalog models.CategoryCatalog) (models.CategoryCatalog, error)) error {
+	var change models.CatalogChange
+
+	err := s.repo.Update(func(catalog models.CategoryCatalog) (models.CategoryCatalog, error) {
+		change.Before = catalog
+		change.Before.Categories = slices.Clone(catalog.Categories)
+
+		after, err := fn(catalog)
+		change.After = after
+		return after, err
+	})
+	if err != nil {
+		return err
+	}
+
+	return recordOperation(s.journal, models.Operation{Description: description, Catalog: &change})
+}
+
+// withCategory returns a copy of the catalog including name and all of its
+// parents, ordered so that subcategories follow their parent
+func withCategory(catalog models.CategoryCatalog, name string) models.CategoryCatalog {
+	catalog.Categories = slices.Clone(catalog.Categories)
+	for category := name; category != ""; category = models.CategoryParent(category) {
+		if _, ok := catalog.Lookup(category); !ok {
+			catalog.Categories = append(catalog.Categories, models.Category{Name: category})
+		}
+	}
+
+	slices.SortFunc(catalog.Categories, func(a, b models.Category) int {
+		return models.CompareCategories(a.Name, b.Name)
+	})
+	return catalog
+}
+
+// RenameCategory renames a category, moving its subcategories with it, in
+// the catalog and on every expense and budget using it. It returns how many
+// expenses were changed. Renaming onto an existing category is refused; use
+// MergeCategories to combine categories.
+func (s *CategoryService) RenameCategory(from, to string) (int, error) {
+	from, to, err := normalizeCategoryPair(from, to)
+	if err != nil {
+		return 0, err
+	}
+	if from == to {
+		return 0, invalidInput("the new category name is the same as the old one")
+	}
+
+	catalog, err := s.repo.Load()
+	if err != nil {
+		return 0, err
+	}
+	if existing, ok := catalog.Lookup(to); ok && !strings.EqualFold(from, to) {
+		return 0, invalidInput("category %q already exists; merge the categories instead", existing)
+	}
+
+	return s.moveCategories([]string{from}, to, fmt.Sprintf("rename category %s to %s", from, to))
+}
+
+// MergeCategories moves every expense and budget in each of the source
+// categories, and their subcategories, into the target category and removes
+// the sources from the catalog. It returns how many expenses were changed.
+func (s *CategoryService) MergeCategories(into string, sources []string) (int, error) {
+	if len(sources) == 0 {
+		return 0, invalidInput("at least one category to merge is required")
+	}
+
+	catalog, err := s.repo.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	normalized := make([]string, 0, len(sources))
+	for _, source := range sources {
+		source, target, err := normalizeCategoryPair(source, into)
+		if err != nil {
+			return 0, err
+		}
+		if strings.EqualFold(source, target) {
+			return 0, invalidInput("cannot merge category %q into itself", source)
+		}
+		normalized = append(normalized, source)
+		into = target
+	}
+	if existing, ok := catalog.Lookup(into); ok {
+		into = existing
+	}
+
+	return s.moveCategories(normalized, into, fmt.Sprintf("merge categories %s into %s", strings.Join(normalized, ", "), into))
+}
+
+// normalizeCategoryPair normalizes the source and target of a rename or merge
+func normalizeCategoryPair(from, to string) (string, string, error) {
+	from, err := models.NormalizeCategory(from)
+	if err != nil {
+		return "", "", asInvalidInput(err)
+	}
+	to, err = models.NormalizeCategory(to)
+	if err != nil {
+		return "", "", asInvalidInput(err)
+	}
+	if from == "" || to == "" {
+		return "", "", invalidInput("category name cannot be empty")
+	}
+	if !strings.EqualFold(from, to) && models.WithinCategory(to, from) {
+		return "", "", invalidInput("cannot move category %q into its own subcategory", from)
+	}
+	return from, to, nil
+}
+
+// budgetKey identifies a budget regardless of the case of its category
+type budgetKey struct {
+	month    time.Month
+	year     int
+	category string
+}
+
+// newBudgetKey returns the key of a budget
+func newBudgetKey(budget models.Budget) budgetKey {
+	return budgetKey{budget.Month, budget.Year, strings.ToLower(budget.Category)}
+}
+
+// moveCategories moves each source category, with its subcategories, to the
+// target in the catalog, on expenses, including those in the trash, and on
+// budgets, and records it all as a single operation. The catalog, budgets and
+// expenses are read and written in nested transactions, so a concurrent
+// change is never overwritten. It returns how many expenses outside the trash
+// were changed.
+func (s *CategoryService) moveCategories(sources []string, target string, description string) (int, error) {
+	found := false
+	move := func(name string) (string, bool) {
+		for _, source := range sources {
+			if models.WithinCategory(name, source) {
+				found = true
+				return target + name[len(source):], true
+			}
+		}
+		return name, false
+	}
+
+	op := models.Operation{Description: description}
+	changed := 0
+
+	err := s.repo.Update(func(catalog models.CategoryCatalog) (models.CategoryCatalog, error) {
+		moved := models.CategoryCatalog{Strict: catalog.Strict}
+		for _, category := range catalog.Categories {
+			name, _ := move(category.Name)
+			moved = withCategory(moved, name)
+		}
+		moved = withCategory(moved, target)
+
+		before := catalog
+		before.Categories = slices.Clone(catalog.Categories)
+		op.Catalog = &models.CatalogChange{Before: before, After: moved}
+
+		err := s.budgets.Update(func(budgets []models.Budget) ([]models.Budget, error) {
+			budgets, err := moveBudgets(budgets, move, &op)
+			if err != nil {
+				return nil, err
+			}
+
+			err = s.expenseService.repo.Transaction(func(expenses []models.Expense) ([]models.Expense, error) {
+				var updated []models.Expense
+				for _, expense := range expenses {
+					name, ok := move(expense.Category)
+					if !ok || name == expense.Category {
+						continue
+					}
+
+					before, after := expense, expense
+					after.Category = name
+					updated = append(updated, after)
+					op.Expenses = append(op.Expenses, models.ExpenseChange{Before: &before, After: &after})
+					if expense.DeletedAt == nil {
+						changed++
+					}
+				}
+
+				if !found {
+					return nil, fmt.Errorf("category %s %w", strings.Join(sources, " or "), repository.ErrNotFound)
+				}
+				return updated, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			return budgets, nil
+		})
+		if err != nil {
+			return catalog, err
+		}
+		return moved, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := recordOperation(s.journal, op); err != nil {
+		return changed, err
+	}
+
+	return changed, nil
+}
+
+// moveBudgets renames the categories of the budgets with move and records the
+// changes in op. A budget moved onto one that already exists is refused.
+func moveBudgets(budgets []models.Budget, move func(name string) (string, bool), op *models.Operation) ([]models.Budget, error) {
+	taken := make(map[budgetKey]bool)
+	var kept, oldBudgets, newBudgets []models.Budget
+	for _, budget := range budgets {
+		if name, ok := move(budget.Category); ok && name != budget.Category {
+			oldBudgets = append(oldBudgets, budget)
+			budget.Category = name
+			newBudgets = append(newBudgets, budget)
+		} else {
+			taken[newBudgetKey(budget)] = true
+			kept = append(kept, budget)
+		}
+	}
+	for _, budget := range newBudgets {
+		if taken[newBudgetKey(budget)] {
+			return nil, invalidInput("the %s already exists; delete one of the budgets first", budgetDescription(budget))
+		}
+		taken[newBudgetKey(budget)] = true
+	}
+
+	for i := range oldBudgets {
+		op.Budgets = append(op.Budgets, models.BudgetChange{Before: &oldBudgets[i]})
+	}
+	for i := range newBudgets {
+		op.Budgets = append(op.Budgets, models.BudgetChange{After: &newBudgets[i]})
+	}
+	return append(kept, newBudgets...), nil
+}
+
+// DeleteCategory removes a category and its subcategories from the catalog.
+// A category still used by an expense or budget cannot be deleted; merge it
+// into another category instead.
+func (s *CategoryService) DeleteCategory(name string) error {
+	name, err := models.NormalizeCategory(name)
+	if err != nil {
+		return asInvalidInput(err)
+	}
+	if name == "" {
+		return invalidInput("category name cannot be empty")
+	}
+
+	expenses, err := s.expenseService.repo.Find(repository.Query{Category: name})
+	if err != nil {
+		return err
+	}
+	if len(expenses) > 0 {
+		return invalidInput("category %q is used by %d expenses; merge it into another category instead", name, len(expenses))
+	}
+
+	budgets, err := s.budgets.GetAll()
+	if err != nil {
+		return err
+	}
+	for _, budget := range budgets {
+		if budget.Category != "" && models.WithinCategory(budget.Category, name) {
+			return invalidInput("category %q is used by the %s; merge it into another category instead", name, budgetDescription(budget))
+		}
+	}
+
+	return s.updateCatalog("delete category "+name, func(catalog models.CategoryCatalog) (models.CategoryCatalog, error) {
+		if _, ok := catalog.Lookup(name); !ok {
+			return catalog, fmt.Errorf("category %q %w", name, repository.ErrNotFound)
+		}
+
+		catalog.Categories = slices.DeleteFunc(slices.Clone(catalog.Categories), func(category models.Category) bool {
+			return models.WithinCategory(category.Name, name)
+		})
+		return catalog, nil
+	})
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+// CategoryRuleService manages the rules that categorize expenses entered
+// without a category and applies them to existing expenses
+type CategoryRuleService struct {
+	repo           repository.CategoryRuleRepository
+	expenseService *ExpenseService
+	journal        repository.JournalRepository
+}
+
+// NewCategoryRule holds the fields of a category rule to be added. Empty
+// text fields and nil amounts are not checked.
+type NewCategoryRule struct {
+	Category string
+	Contains string
+	Pattern  string
+	Merchant string
+	Min      *models.Money
+	Max      *models.Money
+}
+
+// Categorization reports the category a rule gave, or would give, an expense
+type Categorization struct {
+	Expense  models.Expense      `json:"expense"`
+	Previous string              `json:"previous,omitempty"` // Category before the rule was applied
+	Rule     models.CategoryRule `json:"rule"`
+}
+
+// NewCategoryRuleService creates a new category rule service. Changes to
+// expenses are recorded in the journal for undo, unless it is nil.
+func NewCategoryRuleService(repo repository.CategoryRuleRepository, expenseService *ExpenseService, journal repository.JournalRepository) *CategoryRuleService {
+	return &CategoryRuleService{
+		repo:           repo,
+		expenseService: expenseService,
+		journal:        journal,
+	}
+}
+
+// AddRule validates and stores a new rule after the existing ones and returns its ID
+func (s *CategoryRuleService) AddRule(input NewCategoryRule) (int, error) {
+	rule := models.CategoryRule{
+		Category: input.Category,
+		Contains: strings.TrimSpace(input.Contains),
+		Pattern:  input.Pattern,
+		Merchant: strings.TrimSpace(input.Merchant),
+		Min:      input.Min,
+		Max:      input.Max,
+	}
+	if err := rule.Validate(); err != nil {
+		return 0, asInvalidInput(err)
+	}
+
+	category, err := s.expenseService.ResolveCategory(rule.Category)
+	if err != nil {
+		return 0, err
+	}
+	rule.Category = category
+
+	return s.repo.Add(rule)
+}
+
+// GetRules returns all rules in the order they are evaluated
+func (s *CategoryRuleService) GetRules() ([]models.CategoryRule, error) {
+	return s.repo.GetAll()
+}
+
+// DeleteRule removes a rule. Expenses it categorized keep their category.
+func (s *CategoryRuleService) DeleteRule(id int) error {
+	return s.repo.Delete(id)
+}
+
+// TestRules returns every rule matching the expense in the order they are
+// evaluated. Only the first of them would set the category.
+func (s *CategoryRuleService) TestRules(expense models.Expense) ([]models.CategoryRule, error) {
+	rules, err := s.expenseService.categoryRules()
+	if err != nil {
+		return nil, err
+	}
+	return rules.MatchAll(expense), nil
+}
+
+// ApplyRules categorizes the existing expenses that have no category. With
+// retroactive set, expenses that already have a category are recategorized
+// too when a rule matches them. All changes are saved in a single transaction
+// and recorded as one operation, unless dryRun is set, in which case nothing
+// is saved. It returns the expenses whose category changed.
+func (s *CategoryRuleService) ApplyRules(retroactive bool, dryRun bool) ([]Categorization, error) {
+	rules, err := s.expenseService.categoryRules()
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := s.expenseService.catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		expenses, err := s.expenseService.repo.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		changes, _, err := applyRules(expenses, rules, catalog, retroactive)
+		return changes, err
+	}
+
+	var changes []Categorization
+	var op models.Operation
+	err = s.expenseService.repo.Transaction(func(expenses []models.Expense) ([]models.Expense, error) {
+		var updated []models.Expense
+		var err error
+		if changes, op.Expenses, err = applyRules(expenses, rules, catalog, retroactive); err != nil {
+			return nil, err
+		}
+		for _, change := range op.Expenses {
+			updated = append(updated, *change.After)
+		}
+		return updated, nil
+	})
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+
+	op.Description = fmt.Sprintf("apply category rules to %d expenses", len(changes))
+	if err := recordOperation(s.journal, op); err != nil {
+		return changes, err
+	}
+
+	return changes, nil
+}
+
+// applyRules categorizes the expenses that have no category, or with
+// retroactive set every expense a rule matches, and returns the changes.
+// Expenses in the trash are left alone.
+func applyRules(expenses []models.Expense, rules models.CategoryRules, catalog models.CategoryCatalog, retroactive bool) ([]Categorization, []models.ExpenseChange, error) {
+	var changes []Categorization
+	var expenseChanges []models.ExpenseChange
+	for _, expense := range expenses {
+		if expense.DeletedAt != nil || (expense.Category != "" && !retroactive) {
+			continue
+		}
+
+		before := expense
+		expense.Category = ""
+		rule, err := categorize(&expense, rules, catalog)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rule == nil || expense.Category == before.Category {
+			continue
+		}
+
+		after := expense
+		changes = append(changes, Categorization{Expense: after, Previous: before.Category, Rule: *rule})
+		expenseChanges = append(expenseChanges, models.ExpenseChange{Before: &before, After: &after})
+	}
+	return changes, expenseChanges, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+func TestAddExpenseAppliesCategoryRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        NewExpense
+		wantCategory string
+		wantRule     int // Zero when no rule should be reported
+	}{
+		{name: "merchant rule", input: NewExpense{Description: "Ride", Merchant: "Uber BV"}, wantCategory: "Transport", wantRule: 1},
+		{name: "description rule", input: NewExpense{Description: "Flat white coffee"}, wantCategory: "Food/Coffee", wantRule: 2},
+		{name: "explicit category wins", input: NewExpense{Description: "Coffee", Category: "Gifts"}, wantCategory: "Gifts"},
+		{name: "no rule matches", input: NewExpense{Description: "Books"}, wantCategory: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServices(t, "json")
+			for _, rule := range []NewCategoryRule{
+				{Category: "Transport", Merchant: "uber"},
+				{Category: "food/coffee", Contains: "coffee"},
+			} {
+				if _, err := s.rules.AddRule(rule); err != nil {
+					t.Fatalf("AddRule failed: %v", err)
+				}
+			}
+			if _, err := s.categories.AddCategory("Food/Coffee"); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.input.Amount = models.NewMoney(3, 0)
+			added, err := s.expenses.AddExpense(tt.input)
+			if err != nil {
+				t.Fatalf("AddExpense failed: %v", err)
+			}
+			expense, err := s.expenses.GetExpenseByID(added.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expense.Category != tt.wantCategory {
+				t.Errorf("category = %q, want %q", expense.Category, tt.wantCategory)
+			}
+
+			gotRule := 0
+			if added.Rule != nil {
+				gotRule = added.Rule.ID
+			}
+			if gotRule != tt.wantRule {
+				t.Errorf("categorized by rule %d, want %d", gotRule, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		retroactive bool
+		dryRun      bool
+		want        []string // Categories of the expenses afterwards
+		wantChanges int
+	}{
+		{name: "uncategorized only", want: []string{"Transport", "Food", "Transport"}, wantChanges: 1},
+		{name: "retroactive", retroactive: true, want: []string{"Transport", "Transport", "Transport"}, wantChanges: 2},
+		{name: "dry run", retroactive: true, dryRun: true, want: []string{"", "Food", "Transport"}, wantChanges: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServices(t, "json")
+			for _, input := range []NewExpense{
+				{Description: "Taxi to airport"},
+				{Description: "Taxi snack", Category: "Food"},
+				{Description: "Taxi home", Category: "Transport"},
+			} {
+				input.Amount = models.NewMoney(20, 0)
+				if _, err := s.expenses.AddExpense(input); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := s.rules.AddRule(NewCategoryRule{Category: "Transport", Contains: "taxi"}); err != nil {
+				t.Fatal(err)
+			}
+
+			changes, err := s.rules.ApplyRules(tt.retroactive, tt.dryRun)
+			if err != nil {
+				t.Fatalf("ApplyRules failed: %v", err)
+			}
+			if len(changes) != tt.wantChanges {
+				t.Errorf("ApplyRules reported %d changes, want %d", len(changes), tt.wantChanges)
+			}
+
+			expenses, err := s.expenses.GetAllExpenses()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, expense := range expenses {
+				if expense.Category != tt.want[i] {
+					t.Errorf("expense %d has category %q, want %q", expense.ID, expense.Category, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,436 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+// seedCategories fills the catalog with Books, Food, Food/Coffee,
+// Food/Groceries and Travel, adds six expenses, moving the third to the trash,
+// and sets four budgets. Expenses 5 and 6 use a category missing from the
+// catalog, spelled "gifts" and "Gifts".
+func seedCategories(t *testing.T, s testServices) {
+	t.Helper()
+	for _, name := range []string{"Food/Coffee", "Food/Groceries", "Travel", "Books"} {
+		if _, err := s.categories.AddCategory(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	date := time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+	for _, input := range []NewExpense{
+		{Description: "Coffee", Amount: models.NewMoney(4, 50), Category: "food/coffee"},
+		{Description: "Groceries", Amount: models.NewMoney(30, 0), Category: "Food/Groceries"},
+		{Description: "Lunch", Amount: models.NewMoney(12, 0), Category: "Food"},
+		{Description: "Taxi", Amount: models.NewMoney(15, 0), Category: "Travel"},
+		{Description: "Card", Amount: models.NewMoney(5, 0), Category: "gifts"},
+		{Description: "Flowers", Amount: models.NewMoney(10, 0), Category: "Gifts"},
+	} {
+		input.Date = date
+		if _, err := s.expenses.AddExpense(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.expenses.DeleteExpense(3); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, budget := range []models.Budget{
+		{Month: time.March, Year: 2025, Category: "Food"},
+		{Month: time.March, Year: 2025, Category: "Food/Coffee"},
+		{Month: time.April, Year: 2025, Category: "Travel"},
+		{Month: time.April, Year: 2025, Category: "Food"},
+	} {
+		if err := s.budgets.SetBudget(int(budget.Month), budget.Year, budget.Category, models.NewMoney(100, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// categoriesOf returns the category of every expense, including the trash, by ID
+func categoriesOf(t *testing.T, s testServices) map[int]string {
+	t.Helper()
+	live, err := s.expenses.GetAllExpenses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trash, err := s.expenses.GetDeletedExpenses()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	categories := make(map[int]string)
+	for _, expense := range append(live, trash...) {
+		categories[expense.ID] = expense.Category
+	}
+	return categories
+}
+
+// catalogNames returns the names of the categories in the catalog, in order
+func catalogNames(t *testing.T, s testServices) []string {
+	t.Helper()
+	catalog, err := s.categories.GetCatalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, category := range catalog.Categories {
+		names = append(names, category.Name)
+	}
+	return names
+}
+
+// budgetCategories returns every budget as "category month/year", sorted
+func budgetCategories(t *testing.T, s testServices) []string {
+	t.Helper()
+	budgets, err := s.budgets.GetAllBudgets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, budget := range budgets {
+		names = append(names, fmt.Sprintf("%s %d/%d", budget.Category, budget.Month, budget.Year))
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestMoveCategories(t *testing.T) {
+	tests := []struct {
+		name           string
+		move           func(s testServices) (int, error)
+		wantChanged    int
+		wantCategories map[int]string
+		wantCatalog    []string
+		wantBudgets    []string
+		wantErr        error
+	}{
+		{
+			name:           "rename with subcategories",
+			move:           func(s testServices) (int, error) { return s.categories.RenameCategory("food", "Meals") },
+			wantChanged:    2,
+			wantCategories: map[int]string{1: "Meals/Coffee", 2: "Meals/Groceries", 3: "Meals", 4: "Travel", 5: "gifts", 6: "Gifts"},
+			wantCatalog:    []string{"Books", "Meals", "Meals/Coffee", "Meals/Groceries", "Travel"},
+			wantBudgets:    []string{"Meals 3/2025", "Meals 4/2025", "Meals/Coffee 3/2025", "Travel 4/2025"},
+		},
+		{
+			name:           "rename changing only the case",
+			move:           func(s testServices) (int, error) { return s.categories.RenameCategory("travel", "TRAVEL") },
+			wantChanged:    1,
+			wantCategories: map[int]string{1: "Food/Coffee", 2: "Food/Groceries", 3: "Food", 4: "TRAVEL", 5: "gifts", 6: "Gifts"},
+			wantCatalog:    []string{"Books", "Food", "Food/Coffee", "Food/Groceries", "TRAVEL"},
+			wantBudgets:    []string{"Food 3/2025", "Food 4/2025", "Food/Coffee 3/2025", "TRAVEL 4/2025"},
+		},
+		{
+			name:           "rename a category missing from the catalog in every spelling",
+			move:           func(s testServices) (int, error) { return s.categories.RenameCategory("GIFTS", "Presents") },
+			wantChanged:    2,
+			wantCategories: map[int]string{1: "Food/Coffee", 2: "Food/Groceries", 3: "Food", 4: "Travel", 5: "Presents", 6: "Presents"},
+			wantCatalog:    []string{"Books", "Food", "Food/Coffee", "Food/Groceries", "Presents", "Travel"},
+			wantBudgets:    []string{"Food 3/2025", "Food 4/2025", "Food/Coffee 3/2025", "Travel 4/2025"},
+		},
+		{
+			name:    "rename onto an existing category",
+			move:    func(s testServices) (int, error) { return s.categories.RenameCategory("Travel", "books") },
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "rename into its own subcategory",
+			move:    func(s testServices) (int, error) { return s.categories.RenameCategory("Food", "food/Old") },
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "rename a missing category",
+			move:    func(s testServices) (int, error) { return s.categories.RenameCategory("Gadgets", "Toys") },
+			wantErr: repository.ErrNotFound,
+		},
+		{
+			name: "merge ignoring case",
+			move: func(s testServices) (int, error) {
+				return s.categories.MergeCategories("food/COFFEE", []string{"TRAVEL"})
+			},
+			wantChanged:    1,
+			wantCategories: map[int]string{1: "Food/Coffee", 2: "Food/Groceries", 3: "Food", 4: "Food/Coffee", 5: "gifts", 6: "Gifts"},
+			wantCatalog:    []string{"Books", "Food", "Food/Coffee", "Food/Groceries"},
+			wantBudgets:    []string{"Food 3/2025", "Food 4/2025", "Food/Coffee 3/2025", "Food/Coffee 4/2025"},
+		},
+		{
+			name:           "merge every spelling into the catalog's",
+			move:           func(s testServices) (int, error) { return s.categories.MergeCategories("books", []string{"gifts"}) },
+			wantChanged:    2,
+			wantCategories: map[int]string{1: "Food/Coffee", 2: "Food/Groceries", 3: "Food", 4: "Travel", 5: "Books", 6: "Books"},
+			wantCatalog:    []string{"Books", "Food", "Food/Coffee", "Food/Groceries", "Travel"},
+			wantBudgets:    []string{"Food 3/2025", "Food 4/2025", "Food/Coffee 3/2025", "Travel 4/2025"},
+		},
+		{
+			name: "merge a subcategory onto a budget of its parent",
+			move: func(s testServices) (int, error) {
+				return s.categories.MergeCategories("Food", []string{"Food/Coffee"})
+			},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "merge onto a budget of the same month",
+			move:    func(s testServices) (int, error) { return s.categories.MergeCategories("Food", []string{"Travel"}) },
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "merge into itself",
+			move:    func(s testServices) (int, error) { return s.categories.MergeCategories("Travel", []string{"travel"}) },
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "merge a missing category",
+			move:    func(s testServices) (int, error) { return s.categories.MergeCategories("Food", []string{"Gadgets"}) },
+			wantErr: repository.ErrNotFound,
+		},
+	}
+
+	for _, kind := range []string{"json", "sqlite", "events"} {
+		for _, tt := range tests {
+			t.Run(kind+"/"+tt.name, func(t *testing.T) {
+				s := newTestServices(t, kind)
+				seedCategories(t, s)
+				before := captureState(t, s)
+				beforeCategories := categoriesOf(t, s)
+
+				changed, err := tt.move(s)
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("got error %v, want %v", err, tt.wantErr)
+					}
+					if got := captureState(t, s); got != before {
+						t.Errorf("refused move changed the state:\n%s\nwant:\n%s", got, before)
+					}
+					if got := categoriesOf(t, s); !maps.Equal(got, beforeCategories) {
+						t.Errorf("refused move changed the categories to %v", got)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("move failed: %v", err)
+				}
+				if changed != tt.wantChanged {
+					t.Errorf("changed %d expenses, want %d", changed, tt.wantChanged)
+				}
+				if got := categoriesOf(t, s); !maps.Equal(got, tt.wantCategories) {
+					t.Errorf("categories = %v, want %v", got, tt.wantCategories)
+				}
+				if got := catalogNames(t, s); !slices.Equal(got, tt.wantCatalog) {
+					t.Errorf("catalog = %v, want %v", got, tt.wantCatalog)
+				}
+				if got := budgetCategories(t, s); !slices.Equal(got, tt.wantBudgets) {
+					t.Errorf("budgets = %v, want %v", got, tt.wantBudgets)
+				}
+
+				if _, err := s.undo.Undo(1); err != nil {
+					t.Fatalf("undo failed: %v", err)
+				}
+				if got := captureState(t, s); got != before {
+					t.Errorf("state after undo:\n%s\nwant:\n%s", got, before)
+				}
+				if got := categoriesOf(t, s); !maps.Equal(got, beforeCategories) {
+					t.Errorf("categories after undo = %v, want %v", got, beforeCategories)
+				}
+			})
+		}
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	tests := []struct {
+		name        string
+		category    string
+		wantCatalog []string
+		wantErr     error
+	}{
+		{name: "unused", category: "books", wantCatalog: []string{"Food", "Food/Coffee", "Food/Groceries", "Rent", "Travel"}},
+		{name: "used by an expense", category: "Food/Coffee", wantErr: ErrInvalidInput},
+		{name: "used by an expense in a subcategory", category: "FOOD", wantErr: ErrInvalidInput},
+		{name: "used by a budget", category: "Rent", wantErr: ErrInvalidInput},
+		{name: "missing", category: "Gadgets", wantErr: repository.ErrNotFound},
+		{name: "empty", category: " ", wantErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServices(t, "json")
+			seedCategories(t, s)
+			if _, err := s.categories.AddCategory("Rent"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.budgets.SetBudget(3, 2025, "Rent", models.NewMoney(900, 0)); err != nil {
+				t.Fatal(err)
+			}
+			before := catalogNames(t, s)
+
+			err := s.categories.DeleteCategory(tt.category)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if got := catalogNames(t, s); !slices.Equal(got, before) {
+					t.Errorf("refused delete changed the catalog to %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeleteCategory failed: %v", err)
+			}
+			if got := catalogNames(t, s); !slices.Equal(got, tt.wantCatalog) {
+				t.Errorf("catalog = %v, want %v", got, tt.wantCatalog)
+			}
+
+			if _, err := s.undo.Undo(1); err != nil {
+				t.Fatalf("undo failed: %v", err)
+			}
+			if got := catalogNames(t, s); !slices.Equal(got, before) {
+				t.Errorf("catalog after undo = %v, want %v", got, before)
+			}
+		})
+	}
+}
+
+// addCategorized adds an expense in category and returns the category it was
+// stored with
+func addCategorized(s testServices, category string) (string, error) {
+	added, err := s.expenses.AddExpense(NewExpense{Description: "Purchase", Amount: models.NewMoney(3, 0), Category: category})
+	if err != nil {
+		return "", err
+	}
+	expense, err := s.expenses.GetExpenseByID(added.ID)
+	return expense.Category, err
+}
+
+func TestStrictCategories(t *testing.T) {
+	tests := []struct {
+		name         string
+		do           func(s testServices) (string, error) // Returns the category stored
+		wantCategory string
+		wantErr      bool
+	}{
+		{
+			name:         "expense in the catalog",
+			do:           func(s testServices) (string, error) { return addCategorized(s, "FOOD/coffee") },
+			wantCategory: "Food/Coffee",
+		},
+		{
+			name: "expense without a category",
+			do:   func(s testServices) (string, error) { return addCategorized(s, "") },
+		},
+		{
+			name:    "expense missing from the catalog",
+			do:      func(s testServices) (string, error) { return addCategorized(s, "Gifts") },
+			wantErr: true,
+		},
+		{
+			name: "budget in the catalog",
+			do: func(s testServices) (string, error) {
+				if err := s.budgets.SetBudget(5, 2025, "travel", models.NewMoney(50, 0)); err != nil {
+					return "", err
+				}
+				budget, err := s.budgets.GetBudget(5, 2025, "Travel")
+				return budget.Category, err
+			},
+			wantCategory: "Travel",
+		},
+		{
+			name: "budget missing from the catalog",
+			do: func(s testServices) (string, error) {
+				return "", s.budgets.SetBudget(5, 2025, "Gadgets", models.NewMoney(50, 0))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServices(t, "json")
+			seedCategories(t, s)
+			if err := s.categories.SetStrict(true); err != nil {
+				t.Fatal(err)
+			}
+
+			category, err := tt.do(s)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Fatalf("got error %v, want ErrInvalidInput", err)
+				}
+			} else if err != nil {
+				t.Fatalf("operation failed: %v", err)
+			} else if category != tt.wantCategory {
+				t.Errorf("stored category %q, want %q", category, tt.wantCategory)
+			}
+		})
+	}
+
+	// Turning strict mode off, or undoing turning it on, accepts any category again
+	s := newTestServices(t, "json")
+	seedCategories(t, s)
+	if err := s.categories.SetStrict(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.undo.Undo(1); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	if _, err := s.expenses.AddExpense(NewExpense{Description: "Card", Amount: models.NewMoney(3, 0), Category: "Gadgets"}); err != nil {
+		t.Errorf("AddExpense after undoing strict mode failed: %v", err)
+	}
+}
+
+func TestCategoryRollups(t *testing.T) {
+	s := newTestServices(t, "json")
+	seedCategories(t, s)
+	date := time.Date(2025, time.March, 5, 12, 0, 0, 0, time.UTC)
+	for _, input := range []NewExpense{
+		{Description: "Bread", Amount: models.NewMoney(3, 0), Category: "FOOD"},
+		{Description: "Beans", Amount: models.NewMoney(2, 0), Category: "Food/Coffee/Beans"},
+	} {
+		input.Date = date
+		if _, err := s.expenses.AddExpense(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The trashed lunch is left out, the two spellings of gifts are counted
+	// together and every parent includes all of its descendants
+	wantTotals := map[string]models.Money{
+		"Food":              models.NewMoney(3, 0),
+		"Food/Coffee":       models.NewMoney(4, 50),
+		"Food/Coffee/Beans": models.NewMoney(2, 0),
+		"Food/Groceries":    models.NewMoney(30, 0),
+		"Travel":            models.NewMoney(15, 0),
+		"Gifts":             models.NewMoney(15, 0),
+	}
+	wantRollups := map[string]models.Money{
+		"Food":        models.NewMoney(39, 50),
+		"Food/Coffee": models.NewMoney(6, 50),
+	}
+
+	for name, summary := range map[string]func() (models.ExpenseSummary, error){
+		"all expenses": s.expenses.GetExpenseSummary,
+		"month":        func() (models.ExpenseSummary, error) { return s.expenses.GetMonthlySummary(3, 2025) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			summary, err := summary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(summary.CategoryTotals, wantTotals) {
+				t.Errorf("CategoryTotals = %v, want %v", summary.CategoryTotals, wantTotals)
+			}
+			if !maps.Equal(summary.CategoryRollups, wantRollups) {
+				t.Errorf("CategoryRollups = %v, want %v", summary.CategoryRollups, wantRollups)
+			}
+		})
+	}
+}
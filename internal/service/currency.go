@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// referenceCurrency is the currency exchange rates are quoted against
+const referenceCurrency = "EUR"
+
+// CurrencyConverter converts amounts into a base reporting currency using
+// historical exchange rates
+type CurrencyConverter struct {
+	base  string
+	rates map[string][]models.ExchangeRate // per currency, ordered by date
+}
+
+// NewCurrencyConverter creates a converter reporting in the base currency
+func NewCurrencyConverter(base string, rates []models.ExchangeRate) (*CurrencyConverter, error) {
+	base, err := models.NormalizeCurrency(base)
+	if err != nil {
+		return nil, err
+	}
+
+	byCurrency := make(map[string][]models.ExchangeRate)
+	for _, rate := range rates {
+		byCurrency[rate.Currency] = append(byCurrency[rate.Currency], rate)
+	}
+	for _, list := range byCurrency {
+		slices.SortFunc(list, func(a, b models.ExchangeRate) int {
+			return a.Date.Compare(b.Date)
+		})
+	}
+
+	return &CurrencyConverter{
+		base:  base,
+		rates: byCurrency,
+	}, nil
+}
+
+// BaseCurrency returns the currency amounts are converted into
+func (c *CurrencyConverter) BaseCurrency() string {
+	return c.base
+}
+
+// ToBase converts an amount in the given currency into the base currency
+// using the most recent rate published on or before date
+func (c *CurrencyConverter) ToBase(amount models.Money, currency string, date time.Time) (models.Money, error) {
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if currency == c.base {
+		return amount, nil
+	}
+
+	from, err := c.rateOn(currency, date)
+	if err != nil {
+		return 0, err
+	}
+	to, err := c.rateOn(c.base, date)
+	if err != nil {
+		return 0, err
+	}
+
+	// amount * to / from, rounded half away from zero to whole minor units
+	value := new(big.Rat).SetInt64(int64(amount))
+	value.Mul(value, to)
+	value.Quo(value, from)
+
+	num, den := value.Num(), value.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(int64(num.Sign())))
+	}
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("converted amount of %s %s is out of range", amount, currency)
+	}
+
+	return models.Money(quo.Int64()), nil
+}
+
+// rateOn returns the units of currency per unit of the reference currency on date
+func (c *CurrencyConverter) rateOn(currency string, date time.Time) (*big.Rat, error) {
+	if currency == referenceCurrency {
+		return big.NewRat(1, 1), nil
+	}
+
+	// Rates are published per calendar day, so look up by the expense's date alone
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	list := c.rates[currency]
+	i, found := slices.BinarySearchFunc(list, day, func(rate models.ExchangeRate, t time.Time) int {
+		return rate.Date.Compare(t)
+	})
+	if found {
+		return list[i].Rate, nil
+	}
+	if i == 0 {
+		return nil, fmt.Errorf("no exchange rate for %s on or before %s", currency, day.Format("2006-01-02"))
+	}
+	return list[i-1].Rate, nil
+}
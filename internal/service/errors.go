@@ -0,0 +1,38 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidInput is matched with errors.Is by errors that reject the input
+// of a service call, such as an empty description or a month out of range
+var ErrInvalidInput = errors.New("invalid input")
+
+// inputError marks an error as caused by invalid input, keeping its message
+type inputError struct {
+	err error
+}
+
+// Error returns the message of the wrapped error
+func (e inputError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error and ErrInvalidInput
+func (e inputError) Unwrap() []error {
+	return []error{e.err, ErrInvalidInput}
+}
+
+// invalidInput returns an error for invalid input with a formatted message
+func invalidInput(format string, args ...any) error {
+	return inputError{fmt.Errorf(format, args...)}
+}
+
+// asInvalidInput marks err as caused by invalid input. A nil error stays nil.
+func asInvalidInput(err error) error {
+	if err == nil {
+		return nil
+	}
+	return inputError{err}
+}
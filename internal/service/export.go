@@ -4,8 +4,13 @@ package service
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
 )
 
 // ExportService handles exporting expense data
@@ -33,28 +38,60 @@ func (s *ExportService) ExportToCSV(filePath string) error {
 	}
 	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	return writeExpensesCSV(file, expenses)
+}
+
+// ExportCSV writes the expenses selected by a query to w in the format of ExportToCSV
+func (s *ExportService) ExportCSV(w io.Writer, query repository.Query) error {
+	expenses, err := s.expenseService.FindExpenses(query)
+	if err != nil {
+		return err
+	}
+
+	return writeExpensesCSV(w, expenses)
+}
+
+// writeExpensesCSV writes a header row and one record per expense
+func writeExpensesCSV(w io.Writer, expenses []models.Expense) error {
+	writer := csv.NewWriter(w)
 
 	// Write header
-	header := []string{"ID", "Date", "Description", "Amount", "Category"}
-	if err := writer.Write(header); err != nil {
+	if err := writer.Write(ExpenseCSVHeader); err != nil {
 		return fmt.Errorf("error writing header: %w", err)
 	}
 
 	// Write data
-	for _, expense := range expenses {
-		record := []string{
-			strconv.Itoa(expense.ID),
-			expense.Date.Format("2006-01-02"),
-			expense.Description,
-			fmt.Sprintf("%.2f", expense.Amount),
-			expense.Category,
-		}
+	for _, record := range ExpenseCSVRecords(expenses) {
 		if err := writer.Write(record); err != nil {
 			return fmt.Errorf("error writing record: %w", err)
 		}
 	}
 
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing records: %w", err)
+	}
+
 	return nil
 }
+
+// ExpenseCSVHeader is the header row of exported expense CSV files
+var ExpenseCSVHeader = []string{"ID", "Date", "Description", "Amount", "Category", "Currency", "Tags", "Merchant"}
+
+// ExpenseCSVRecords converts expenses into CSV records matching ExpenseCSVHeader
+func ExpenseCSVRecords(expenses []models.Expense) [][]string {
+	records := make([][]string, 0, len(expenses))
+	for _, expense := range expenses {
+		records = append(records, []string{
+			strconv.Itoa(expense.ID),
+			expense.Date.Format("2006-01-02"),
+			expense.Description,
+			expense.Amount.String(),
+			expense.Category,
+			expense.CurrencyCode(),
+			strings.Join(expense.Tags, ","),
+			expense.Merchant,
+		})
+	}
+	return records
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// Sign conventions for amounts in imported files
+const (
+	// SignPositiveExpense treats positive amounts as expenses and skips negative ones
+	SignPositiveExpense = "positive"
+	// SignNegativeExpense treats negative amounts (debits) as expenses and skips positive ones
+	SignNegativeExpense = "negative"
+)
+
+// ImportProfile describes how to read expenses from a CSV file. Columns are
+// matched by header name, case-insensitively. The zero value of each field is
+// replaced by the corresponding value of DefaultImportProfile.
+type ImportProfile struct {
+	Delimiter          string `json:"delimiter,omitempty"`
+	DateColumn         string `json:"dateColumn,omitempty"`
+	DateFormat         string `json:"dateFormat,omitempty"` // Go reference layout, e.g. 02.01.2006
+	DescriptionColumn  string `json:"descriptionColumn,omitempty"`
+	AmountColumn       string `json:"amountColumn,omitempty"`
+	CategoryColumn     string `json:"categoryColumn,omitempty"`
+	CurrencyColumn     string `json:"currencyColumn,omitempty"`
+	TagsColumn         string `json:"tagsColumn,omitempty"` // Comma-separated tags
+	MerchantColumn     string `json:"merchantColumn,omitempty"`
+	Currency           string `json:"currency,omitempty"` // Used when there is no currency column
+	DecimalSeparator   string `json:"decimalSeparator,omitempty"`
+	ThousandsSeparator string `json:"thousandsSeparator,omitempty"`
+	Sign               string `json:"sign,omitempty"` // SignPositiveExpense or SignNegativeExpense
+}
+
+// DefaultImportProfile reads files written by ExportService.ExportToCSV
+var DefaultImportProfile = ImportProfile{
+	Delimiter:         ",",
+	DateColumn:        "Date",
+	DateFormat:        "2006-01-02",
+	DescriptionColumn: "Description",
+	AmountColumn:      "Amount",
+	CategoryColumn:    "Category",
+	CurrencyColumn:    "Currency",
+	TagsColumn:        "Tags",
+	MerchantColumn:    "Merchant",
+	DecimalSeparator:  ".",
+	Sign:              SignPositiveExpense,
+}
+
+// LoadImportProfile reads an import profile from a JSON file
+func LoadImportProfile(filePath string) (ImportProfile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return ImportProfile{}, fmt.Errorf("failed to read import profile: %w", err)
+	}
+
+	var profile ImportProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return ImportProfile{}, fmt.Errorf("failed to parse import profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// withDefaults fills unset fields from DefaultImportProfile
+func (p ImportProfile) withDefaults() ImportProfile {
+	defaults := DefaultImportProfile
+	set := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	set(&p.Delimiter, defaults.Delimiter)
+	set(&p.DateColumn, defaults.DateColumn)
+	set(&p.DateFormat, defaults.DateFormat)
+	set(&p.DescriptionColumn, defaults.DescriptionColumn)
+	set(&p.AmountColumn, defaults.AmountColumn)
+	set(&p.CategoryColumn, defaults.CategoryColumn)
+	set(&p.CurrencyColumn, defaults.CurrencyColumn)
+	set(&p.TagsColumn, defaults.TagsColumn)
+	set(&p.MerchantColumn, defaults.MerchantColumn)
+	set(&p.DecimalSeparator, defaults.DecimalSeparator)
+	set(&p.Sign, defaults.Sign)
+	return p
+}
+
+// validate checks that the profile can be used to read a file
+func (p ImportProfile) validate() error {
+	if utf8.RuneCountInString(p.Delimiter) != 1 {
+		return fmt.Errorf("delimiter must be a single character, got %q", p.Delimiter)
+	}
+	if p.DecimalSeparator == p.ThousandsSeparator {
+		return errors.New("decimal and thousands separators must differ")
+	}
+	if p.Sign != SignPositiveExpense && p.Sign != SignNegativeExpense {
+		return fmt.Errorf("invalid sign convention %q, expected %q or %q", p.Sign, SignPositiveExpense, SignNegativeExpense)
+	}
+	return nil
+}
+
+// ImportRow is an expense read from one line of an imported file
+type ImportRow struct {
+	Line    int
+	Expense NewExpense
+	Rule    *models.CategoryRule // Category rule that set the category, if any
+}
+
+// ImportError describes why a line of an imported file was rejected
+type ImportError struct {
+	Line int
+	Err  error
+}
+
+// Error implements the error interface
+func (e ImportError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// ImportResult reports the outcome of reading and importing a file
+type ImportResult struct {
+	Rows      []ImportRow   // Expenses read from the file
+	Skipped   []int         // Lines skipped because of the sign convention
+	Errors    []ImportError // Lines that could not be imported
+	IDs       []int         // IDs of the stored expenses, empty for a dry run
+	Committed bool          // Whether the expenses were stored
+}
+
+// ImportService handles importing expense data
+type ImportService struct {
+	expenseService *ExpenseService
+}
+
+// NewImportService creates a new import service
+func NewImportService(expenseService *ExpenseService) *ImportService {
+	return &ImportService{
+		expenseService: expenseService,
+	}
+}
+
+// ImportCSV reads expenses from a CSV file using the given profile and stores
+// them all in one transaction. Nothing is stored if any line has an error or
+// if dryRun is set; the result then previews what would have been imported.
+func (s *ImportService) ImportCSV(filePath string, profile ImportProfile, dryRun bool) (ImportResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("could not open file: %w", err)
+	}
+	defer file.Close()
+
+	result, err := s.readCSV(file, profile)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	if dryRun || len(result.Errors) > 0 || len(result.Rows) == 0 {
+		return result, nil
+	}
+
+	inputs := make([]NewExpense, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		inputs = append(inputs, row.Expense)
+	}
+
+	description := fmt.Sprintf("import %d expenses from %s", len(inputs), filepath.Base(filePath))
+	ids, err := s.expenseService.addExpenses(inputs, description)
+	if ids == nil && err != nil {
+		return result, fmt.Errorf("import failed, nothing was imported: %w", err)
+	}
+
+	result.IDs = ids
+	result.Committed = true
+	return result, err
+}
+
+// readCSV parses and validates every line of a CSV file
+func (s *ImportService) readCSV(r io.Reader, profile ImportProfile) (ImportResult, error) {
+	profile = profile.withDefaults()
+	if err := profile.validate(); err != nil {
+		return ImportResult{}, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma, _ = utf8.DecodeRuneInString(profile.Delimiter)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	catalog, err := s.expenseService.catalog()
+	if err != nil {
+		return ImportResult{}, err
+	}
+	rules, err := s.expenseService.categoryRules()
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	column := func(name string, required bool) (int, error) {
+		if i, ok := columns[strings.ToLower(name)]; ok {
+			return i, nil
+		}
+		if required {
+			return -1, fmt.Errorf("column %q not found in header", name)
+		}
+		return -1, nil
+	}
+
+	dateCol, err := column(profile.DateColumn, true)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	descriptionCol, err := column(profile.DescriptionColumn, true)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	amountCol, err := column(profile.AmountColumn, true)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	categoryCol, _ := column(profile.CategoryColumn, false)
+	currencyCol, _ := column(profile.CurrencyColumn, false)
+	tagsCol, _ := column(profile.TagsColumn, false)
+	merchantCol, _ := column(profile.MerchantColumn, false)
+
+	var result ImportResult
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, ImportError{Line: line, Err: err})
+			continue
+		}
+
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		amount, err := parseImportAmount(field(amountCol), profile)
+		if err != nil {
+			result.Errors = append(result.Errors, ImportError{Line: line, Err: err})
+			continue
+		}
+
+		// Keep only the side of the ledger that holds expenses
+		if profile.Sign == SignNegativeExpense {
+			amount = -amount
+		}
+		if amount <= 0 {
+			result.Skipped = append(result.Skipped, line)
+			continue
+		}
+
+		date, err := time.ParseInLocation(profile.DateFormat, field(dateCol), time.Local)
+		if err != nil {
+			result.Errors = append(result.Errors, ImportError{Line: line, Err: fmt.Errorf("invalid date %q, expected format %s", field(dateCol), profile.DateFormat)})
+			continue
+		}
+
+		currency := field(currencyCol)
+		if currency == "" {
+			currency = profile.Currency
+		}
+
+		tags, err := models.ParseTags(field(tagsCol))
+		if err != nil {
+			result.Errors = append(result.Errors, ImportError{Line: line, Err: err})
+			continue
+		}
+
+		input := NewExpense{
+			Description: field(descriptionCol),
+			Amount:      amount,
+			Currency:    currency,
+			Category:    field(categoryCol),
+			Tags:        tags,
+			Merchant:    field(merchantCol),
+			Date:        date,
+		}
+		expense, err := s.expenseService.newExpense(input, catalog)
+		if err != nil {
+			result.Errors = append(result.Errors, ImportError{Line: line, Err: err})
+			continue
+		}
+
+		// Rows without a category are categorized by the first matching rule
+		rule, err := categorize(&expense, rules, catalog)
+		if err != nil {
+			return ImportResult{}, err
+		}
+		if rule != nil {
+			input.Category = expense.Category
+			input.AllowUnknownCategory = true
+		}
+
+		result.Rows = append(result.Rows, ImportRow{Line: line, Expense: input, Rule: rule})
+	}
+
+	return result, nil
+}
+
+// parseImportAmount parses an amount written with the profile's separators
+func parseImportAmount(value string, profile ImportProfile) (models.Money, error) {
+	if value == "" {
+		return 0, errors.New("amount is empty")
+	}
+
+	normalized := value
+	if profile.ThousandsSeparator != "" {
+		normalized = strings.ReplaceAll(normalized, profile.ThousandsSeparator, "")
+	}
+	if profile.DecimalSeparator != "." {
+		if strings.Contains(normalized, ".") {
+			return 0, fmt.Errorf("invalid amount %q", value)
+		}
+		normalized = strings.ReplaceAll(normalized, profile.DecimalSeparator, ".")
+	}
+	// Some banks write debits as "(12.50)" or with a trailing minus
+	if strings.HasPrefix(normalized, "(") && strings.HasSuffix(normalized, ")") {
+		normalized = "-" + strings.Trim(normalized, "()")
+	} else if strings.HasSuffix(normalized, "-") {
+		normalized = "-" + strings.TrimSuffix(normalized, "-")
+	}
+
+	amount, err := models.ParseMoney(strings.ReplaceAll(normalized, " ", ""))
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return amount, nil
+}
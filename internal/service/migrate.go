@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+// MigrationReport describes the data copied by a store migration
+type MigrationReport struct {
+	From           string                  `json:"from"`
+	To             string                  `json:"to"`
+	ExpenseCount   int                     `json:"expenseCount"`
+	TrashCount     int                     `json:"trashCount"`
+	CurrencyTotals map[string]models.Money `json:"currencyTotals"`
+}
+
+// MigrateStore copies every expense from the store described by fromSpec into
+// the store described by toSpec, which must be empty. Specs take the form
+// accepted by repository.OpenExpenseRepository.
+func MigrateStore(fromSpec, toSpec string) (MigrationReport, error) {
+	same, err := sameStore(fromSpec, toSpec)
+	if err != nil {
+		return MigrationReport{}, err
+	}
+	if same {
+		return MigrationReport{}, fmt.Errorf("source and target store are the same: %s", fromSpec)
+	}
+
+	from, err := repository.OpenExpenseRepository(fromSpec)
+	if err != nil {
+		return MigrationReport{}, fmt.Errorf("failed to open source store: %w", err)
+	}
+	if closer, ok := from.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	to, err := repository.OpenExpenseRepository(toSpec)
+	if err != nil {
+		return MigrationReport{}, fmt.Errorf("failed to open target store: %w", err)
+	}
+	if closer, ok := to.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	report, err := CopyExpenses(from, to)
+	if err != nil {
+		return MigrationReport{}, err
+	}
+
+	report.From = fromSpec
+	report.To = toSpec
+	return report, nil
+}
+
+// sameStore reports whether two specs describe the same store: the same kind
+// at the same absolute path, however the spec spells them
+func sameStore(a, b string) (bool, error) {
+	kindA, pathA, err := repository.ParseStoreSpec(a)
+	if err != nil {
+		return false, err
+	}
+	kindB, pathB, err := repository.ParseStoreSpec(b)
+	if err != nil {
+		return false, err
+	}
+	if kindA != kindB {
+		return false, nil
+	}
+
+	absA, err := filepath.Abs(pathA)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve %s: %w", pathA, err)
+	}
+	absB, err := filepath.Abs(pathB)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve %s: %w", pathB, err)
+	}
+	return absA == absB, nil
+}
+
+// CopyExpenses copies every expense, including the trash, from one repository
+// into another, empty, repository keeping their IDs, then verifies that both
+// hold the same number of expenses with the same per-currency, per-category
+// and per-tag totals
+func CopyExpenses(from, to repository.ExpenseRepository) (MigrationReport, error) {
+	existing, err := to.GetSummary()
+	if err != nil {
+		return MigrationReport{}, fmt.Errorf("failed to read target store: %w", err)
+	}
+	if existing.ExpenseCount > 0 {
+		return MigrationReport{}, fmt.Errorf("target store already contains %d expenses", existing.ExpenseCount)
+	}
+	if existingTrash, err := to.GetDeleted(); err != nil {
+		return MigrationReport{}, fmt.Errorf("failed to read target store: %w", err)
+	} else if len(existingTrash) > 0 {
+		return MigrationReport{}, fmt.Errorf("target store already contains %d expenses in the trash", len(existingTrash))
+	}
+
+	expenses, err := from.GetAll()
+	if err != nil {
+		return MigrationReport{}, fmt.Errorf("failed to read source store: %w", err)
+	}
+
+	trash, err := from.GetDeleted()
+	if err != nil {
+		return MigrationReport{}, fmt.Errorf("failed to read source trash: %w", err)
+	}
+
+	if err := to.Restore(append(expenses, trash...)); err != nil {
+		return MigrationReport{}, fmt.Errorf("failed to copy expenses: %w", err)
+	}
+
+	want, err := from.GetSummary()
+	if err != nil {
+		return MigrationReport{}, fmt.Errorf("failed to summarize source store: %w", err)
+	}
+	got, err := to.GetSummary()
+	if err != nil {
+		return MigrationReport{}, fmt.Errorf("failed to summarize target store: %w", err)
+	}
+
+	copiedTrash, err := to.GetDeleted()
+	if err != nil {
+		return MigrationReport{}, fmt.Errorf("failed to read target trash: %w", err)
+	}
+
+	switch {
+	case len(copiedTrash) != len(trash):
+		return MigrationReport{}, fmt.Errorf("verification failed: target has %d expenses in the trash, source has %d", len(copiedTrash), len(trash))
+	case got.ExpenseCount != want.ExpenseCount:
+		return MigrationReport{}, fmt.Errorf("verification failed: target has %d expenses, source has %d", got.ExpenseCount, want.ExpenseCount)
+	case !maps.Equal(got.CurrencyTotals, want.CurrencyTotals):
+		return MigrationReport{}, fmt.Errorf("verification failed: currency totals differ (target %v, source %v)", got.CurrencyTotals, want.CurrencyTotals)
+	case !maps.Equal(got.CategoryTotals, want.CategoryTotals):
+		return MigrationReport{}, fmt.Errorf("verification failed: category totals differ (target %v, source %v)", got.CategoryTotals, want.CategoryTotals)
+	case !maps.Equal(got.TagTotals, want.TagTotals):
+		return MigrationReport{}, fmt.Errorf("verification failed: tag totals differ (target %v, source %v)", got.TagTotals, want.TagTotals)
+	}
+
+	return MigrationReport{
+		ExpenseCount:   got.ExpenseCount,
+		TrashCount:     len(copiedTrash),
+		CurrencyTotals: got.CurrencyTotals,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+func TestMigrateStore(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+	}{
+		{from: "json", to: "sqlite"},
+		{from: "sqlite", to: "events"},
+		{from: "events", to: "json"},
+		{from: "json", to: "events"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			dir := t.TempDir()
+			fromSpec := storeSpec(dir, tt.from, "from")
+			toSpec := storeSpec(dir, tt.to, "to")
+
+			from, err := repository.OpenExpenseRepository(fromSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := migrationFixture()
+			if err := from.Restore(want); err != nil {
+				t.Fatalf("failed to fill source store: %v", err)
+			}
+			closeStore(from)
+
+			report, err := MigrateStore(fromSpec, toSpec)
+			if err != nil {
+				t.Fatalf("MigrateStore failed: %v", err)
+			}
+			if report.ExpenseCount != 2 || report.TrashCount != 1 {
+				t.Errorf("report counts %d expenses and %d in the trash, want 2 and 1", report.ExpenseCount, report.TrashCount)
+			}
+			if got := report.CurrencyTotals["USD"]; got != models.NewMoney(17, 25) {
+				t.Errorf("USD total = %s, want 17.25", got)
+			}
+
+			to, err := repository.OpenExpenseRepository(toSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer closeStore(to)
+
+			for _, expense := range want {
+				got, err := to.GetByID(expense.ID)
+				if expense.DeletedAt != nil {
+					if err == nil {
+						t.Errorf("expense %d in the trash was copied as a live expense", expense.ID)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("GetByID(%d) failed: %v", expense.ID, err)
+				}
+				if !sameExpense(got, expense) {
+					t.Errorf("expense %d = %+v, want %+v", expense.ID, got, expense)
+				}
+			}
+
+			trash, err := to.GetDeleted()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trash) != 1 || trash[0].ID != 3 {
+				t.Errorf("trash holds %v, want expense 3", trash)
+			}
+		})
+	}
+}
+
+func TestMigrateStoreRejects(t *testing.T) {
+	dir := t.TempDir()
+	fromSpec := storeSpec(dir, "json", "from")
+	toSpec := storeSpec(dir, "sqlite", "to")
+
+	for spec, expenses := range map[string][]models.Expense{fromSpec: migrationFixture(), toSpec: migrationFixture()[:1]} {
+		repo, err := repository.OpenExpenseRepository(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Restore(expenses); err != nil {
+			t.Fatal(err)
+		}
+		closeStore(repo)
+	}
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr string // Text the error must contain
+	}{
+		{name: "same store", from: fromSpec, to: fromSpec, wantErr: "are the same"},
+		{name: "same store without a kind", from: "data", to: "json:data", wantErr: "are the same"},
+		{name: "same store by relative path", from: "json:data", to: "./data", wantErr: "are the same"},
+		{name: "same store by absolute path", from: "./data/", to: filepath.Join(dir, "data"), wantErr: "are the same"},
+		{name: "same file", from: "sqlite:to.db", to: "sqlite:" + filepath.Join(dir, "to.db"), wantErr: "are the same"},
+		{name: "target not empty", from: fromSpec, to: toSpec, wantErr: "already contains"},
+		{name: "unknown kind", from: fromSpec, to: "csv:" + filepath.Join(dir, "x"), wantErr: "unknown kind"},
+		{name: "missing path", from: fromSpec, to: "sqlite:", wantErr: "missing path"},
+	}
+
+	// Relative paths are resolved against the working directory
+	t.Chdir(dir)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MigrateStore(tt.from, tt.to)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("MigrateStore(%q, %q) returned %v, want an error containing %q", tt.from, tt.to, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// storeSpec returns the spec of a store of the given kind inside dir
+func storeSpec(dir, kind, name string) string {
+	path := filepath.Join(dir, name)
+	if kind == "sqlite" {
+		path += ".db"
+	}
+	return kind + ":" + path
+}
+
+// closeStore closes repo if it holds resources such as a database handle
+func closeStore(repo repository.ExpenseRepository) {
+	if closer, ok := repo.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// migrationFixture returns two live expenses and one in the trash
+func migrationFixture() []models.Expense {
+	date := time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+	deleted := date.Add(time.Hour)
+	return []models.Expense{
+		{ID: 1, Description: "Coffee", Amount: models.NewMoney(4, 75), Category: "Food", Tags: []string{"work"}, Date: date},
+		{ID: 2, Description: "Taxi", Amount: models.NewMoney(12, 50), Currency: "USD", Merchant: "Uber", Date: date},
+		{ID: 3, Description: "Lunch", Amount: models.NewMoney(9, 0), Category: "Food", Date: date, DeletedAt: &deleted},
+	}
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+// RecurringService manages recurring expense rules and creates the expenses
+// they schedule
+type RecurringService struct {
+	repo           repository.RecurringRepository
+	expenseService *ExpenseService
+}
+
+// NewRecurringRule holds the fields of a recurring rule to be added
+type NewRecurringRule struct {
+	Description string
+	Amount      models.Money
+	Currency    string // Empty means the base currency
+	Category    string
+	Frequency   models.Frequency
+	Interval    int        // Zero means every period
+	Start       time.Time  // Zero means today
+	Until       *time.Time // Nil for no end date
+	Count       int        // Zero for no limit
+}
+
+// NewRecurringService creates a new recurring expense service
+func NewRecurringService(repo repository.RecurringRepository, expenseService *ExpenseService) *RecurringService {
+	return &RecurringService{
+		repo:           repo,
+		expenseService: expenseService,
+	}
+}
+
+// AddRule validates and stores a new recurring rule and returns its ID
+func (s *RecurringService) AddRule(input NewRecurringRule) (int, error) {
+	if strings.TrimSpace(input.Description) == "" {
+		return 0, invalidInput("description cannot be empty")
+	}
+	if input.Amount <= 0 {
+		return 0, invalidInput("amount must be greater than zero")
+	}
+	frequency, err := models.ParseFrequency(string(input.Frequency))
+	if err != nil {
+		return 0, asInvalidInput(err)
+	}
+	if input.Interval < 0 {
+		return 0, invalidInput("interval must be at least 1")
+	}
+	if input.Count < 0 {
+		return 0, invalidInput("count must not be negative")
+	}
+
+	start := input.Start
+	if start.IsZero() {
+		now := time.Now()
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	}
+	if input.Until != nil && input.Until.Before(start) {
+		return 0, invalidInput("end date cannot be before the start date")
+	}
+
+	currency := input.Currency
+	if currency != "" {
+		if currency, err = models.NormalizeCurrency(currency); err != nil {
+			return 0, asInvalidInput(err)
+		}
+	}
+
+	category, err := s.expenseService.ResolveCategory(input.Category)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.Add(models.RecurringRule{
+		Description: input.Description,
+		Amount:      input.Amount,
+		Currency:    currency,
+		Category:    category,
+		Frequency:   frequency,
+		Interval:    max(input.Interval, 1),
+		Start:       start,
+		Until:       input.Until,
+		Count:       input.Count,
+	})
+}
+
+// GetRules returns all recurring rules
+func (s *RecurringService) GetRules() ([]models.RecurringRule, error) {
+	return s.repo.GetAll()
+}
+
+// DeleteRule deletes a recurring rule. Expenses it already created are kept.
+func (s *RecurringService) DeleteRule(id int) error {
+	if id <= 0 {
+		return invalidInput("invalid recurring rule ID")
+	}
+	return s.repo.Delete(id)
+}
+
+// PauseRule stops a rule from creating expenses until it is resumed
+func (s *RecurringService) PauseRule(id int) error {
+	return s.updateRule(id, func(rule *models.RecurringRule) error {
+		if rule.Paused {
+			return invalidInput("recurring rule is already paused")
+		}
+
+		rule.Paused = true
+		return nil
+	})
+}
+
+// ResumeRule lets a paused rule create expenses again. Occurrences that fell
+// before today while it was paused are skipped; it returns how many.
+func (s *RecurringService) ResumeRule(id int) (int, error) {
+	skipped := 0
+
+	err := s.updateRule(id, func(rule *models.RecurringRule) error {
+		if !rule.Paused {
+			return invalidInput("recurring rule is not paused")
+		}
+
+		for {
+			date, ok := rule.Next()
+			if !ok || !isPastDay(date) {
+				break
+			}
+			rule.Generated++
+			skipped++
+		}
+		rule.Paused = false
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return skipped, nil
+}
+
+// updateRule changes the rule with the given ID in a single transaction, so
+// that a concurrent change to the rules is never lost
+func (s *RecurringService) updateRule(id int, fn func(rule *models.RecurringRule) error) error {
+	if id <= 0 {
+		return invalidInput("invalid recurring rule ID")
+	}
+
+	return s.repo.Transaction(func(rules []models.RecurringRule, _ func([]models.RecurringRule) error) ([]models.RecurringRule, error) {
+		i := slices.IndexFunc(rules, func(rule models.RecurringRule) bool { return rule.ID == id })
+		if i == -1 {
+			return nil, fmt.Errorf("recurring rule %w", repository.ErrNotFound)
+		}
+
+		if err := fn(&rules[i]); err != nil {
+			return nil, err
+		}
+		return rules, nil
+	})
+}
+
+// isPastDay reports whether date falls on a day before today
+func isPastDay(date time.Time) bool {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return date.Before(today)
+}
+
+// Materialize creates an expense for every occurrence that is due, up to and
+// including today, and has not been created yet, however long ago it fell due.
+// Running it again creates nothing new. The dates being created are saved
+// with each rule before the expenses are added, so if a run is interrupted
+// the next one only adds the occurrences that are still missing. It returns
+// the IDs of the new expenses.
+func (s *RecurringService) Materialize() ([]int, error) {
+	// Avoid rewriting the rules file on every run when nothing is due
+	rules, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if !anyDue(rules) {
+		return nil, nil
+	}
+
+	var ids []int
+	var recordErr error
+
+	err = s.repo.Transaction(func(rules []models.RecurringRule, save func([]models.RecurringRule) error) ([]models.RecurringRule, error) {
+		var inputs []NewExpense
+		for i := range rules {
+			rule := &rules[i]
+
+			// Occurrences left pending by an interrupted run may already exist
+			for _, date := range rule.Pending {
+				exists, err := s.occurrenceExists(*rule, date)
+				if err != nil {
+					return nil, err
+				}
+				if !exists {
+					inputs = append(inputs, occurrenceExpense(*rule, date))
+				}
+			}
+
+			if rule.Paused {
+				continue
+			}
+			for {
+				date, ok := rule.Next()
+				if !ok || isFutureDate(date) {
+					break
+				}
+				rule.Pending = append(rule.Pending, date)
+				inputs = append(inputs, occurrenceExpense(*rule, date))
+			}
+		}
+
+		if err := save(rules); err != nil {
+			return nil, err
+		}
+
+		if len(inputs) > 0 {
+			ids, recordErr = s.expenseService.addExpenses(inputs, fmt.Sprintf("add %d recurring expenses", len(inputs)))
+			if ids == nil && recordErr != nil {
+				return nil, recordErr
+			}
+		}
+
+		for i := range rules {
+			rules[i].Generated += len(rules[i].Pending)
+			rules[i].Pending = nil
+		}
+		return rules, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, recordErr
+}
+
+// anyDue reports whether any rule has an occurrence to create
+func anyDue(rules []models.RecurringRule) bool {
+	for _, rule := range rules {
+		if len(rule.Pending) > 0 {
+			return true
+		}
+		if date, ok := rule.Next(); ok && !rule.Paused && !isFutureDate(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// occurrenceExpense returns the expense a rule creates for an occurrence. The
+// rule's category was checked when it was added, so it is kept even if it
+// has since left the catalog.
+func occurrenceExpense(rule models.RecurringRule, date time.Time) NewExpense {
+	return NewExpense{
+		Description:          rule.Description,
+		Amount:               rule.Amount,
+		Currency:             rule.Currency,
+		Category:             rule.Category,
+		Date:                 date,
+		AllowUnknownCategory: true,
+	}
+}
+
+// occurrenceExists reports whether the expense for an occurrence has already been created
+func (s *RecurringService) occurrenceExists(rule models.RecurringRule, date time.Time) (bool, error) {
+	expenses, err := s.expenseService.FindExpenses(repository.Query{
+		From:   date,
+		To:     date.AddDate(0, 0, 1),
+		Search: rule.Description,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, expense := range expenses {
+		if expense.Description == rule.Description && expense.Amount == rule.Amount &&
+			strings.EqualFold(expense.Category, rule.Category) && expense.Date.Equal(date) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
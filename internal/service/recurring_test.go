@@ -0,0 +1,254 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+// testServices wires the services to repositories in a temporary
+// directory the way cmd/main.go does
+type testServices struct {
+	expenses   *ExpenseService
+	budgets    *BudgetService
+	recurring  *RecurringService
+	categories *CategoryService
+	rules      *CategoryRuleService
+	undo       *UndoService
+
+	expenseRepo   repository.ExpenseRepository
+	recurringRepo repository.RecurringRepository
+}
+
+// newTestServices returns services storing their data in a new temporary
+// directory, with expenses in a store of the given kind
+func newTestServices(t *testing.T, kind string) testServices {
+	t.Helper()
+	dir := t.TempDir()
+
+	expenseRepo, err := repository.OpenExpenseRepository(storeSpec(dir, kind, "expenses"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closeStore(expenseRepo) })
+	journalRepo, err := repository.NewJSONJournalRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	budgetRepo, err := repository.NewJSONBudgetRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recurringRepo, err := repository.NewJSONRecurringRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	categoryRepo, err := repository.NewJSONCategoryRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ruleRepo, err := repository.NewJSONCategoryRuleRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converter, err := NewCurrencyConverter(models.DefaultCurrency, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expenseService := NewExpenseService(expenseRepo, converter, journalRepo, categoryRepo, ruleRepo)
+	return testServices{
+		expenses:      expenseService,
+		budgets:       NewBudgetService(budgetRepo, expenseService, journalRepo),
+		recurring:     NewRecurringService(recurringRepo, expenseService),
+		categories:    NewCategoryService(categoryRepo, expenseService, budgetRepo, journalRepo),
+		rules:         NewCategoryRuleService(ruleRepo, expenseService, journalRepo),
+		undo:          NewUndoService(journalRepo, expenseRepo, budgetRepo, categoryRepo),
+		expenseRepo:   expenseRepo,
+		recurringRepo: recurringRepo,
+	}
+}
+
+// daysFromToday returns local midnight the given number of days from today
+func daysFromToday(days int) time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day()+days, 0, 0, 0, 0, time.Local)
+}
+
+func TestMaterialize(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      NewRecurringRule
+		paused    bool
+		wantFirst int
+	}{
+		{
+			name:      "daily since four days ago",
+			rule:      NewRecurringRule{Frequency: models.FrequencyDaily, Start: daysFromToday(-4)},
+			wantFirst: 5,
+		},
+		{
+			name:      "every two days since four days ago",
+			rule:      NewRecurringRule{Frequency: models.FrequencyDaily, Interval: 2, Start: daysFromToday(-4)},
+			wantFirst: 3,
+		},
+		{
+			name:      "limited by count",
+			rule:      NewRecurringRule{Frequency: models.FrequencyDaily, Start: daysFromToday(-10), Count: 2},
+			wantFirst: 2,
+		},
+		{
+			name:      "weekly since three weeks ago",
+			rule:      NewRecurringRule{Frequency: models.FrequencyWeekly, Start: daysFromToday(-21)},
+			wantFirst: 4,
+		},
+		{
+			name:      "starting tomorrow",
+			rule:      NewRecurringRule{Frequency: models.FrequencyDaily, Start: daysFromToday(1)},
+			wantFirst: 0,
+		},
+		{
+			name:      "paused",
+			rule:      NewRecurringRule{Frequency: models.FrequencyDaily, Start: daysFromToday(-4)},
+			paused:    true,
+			wantFirst: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServices(t, "json")
+			tt.rule.Description = "Rent"
+			tt.rule.Amount = models.NewMoney(500, 0)
+			id, err := s.recurring.AddRule(tt.rule)
+			if err != nil {
+				t.Fatalf("AddRule failed: %v", err)
+			}
+			if tt.paused {
+				if err := s.recurring.PauseRule(id); err != nil {
+					t.Fatalf("PauseRule failed: %v", err)
+				}
+			}
+
+			ids, err := s.recurring.Materialize()
+			if err != nil {
+				t.Fatalf("Materialize failed: %v", err)
+			}
+			if len(ids) != tt.wantFirst {
+				t.Errorf("first run created %d expenses, want %d", len(ids), tt.wantFirst)
+			}
+
+			ids, err = s.recurring.Materialize()
+			if err != nil {
+				t.Fatalf("second Materialize failed: %v", err)
+			}
+			if len(ids) != 0 {
+				t.Errorf("second run created %d expenses, want none", len(ids))
+			}
+
+			expenses, err := s.expenses.GetAllExpenses()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(expenses) != tt.wantFirst {
+				t.Errorf("store holds %d expenses, want %d", len(expenses), tt.wantFirst)
+			}
+			for _, expense := range expenses {
+				if isFutureDate(expense.Date) {
+					t.Errorf("expense dated %s is in the future", expense.Date.Format(time.DateOnly))
+				}
+			}
+		})
+	}
+}
+
+func TestMaterializeCompletesInterruptedRun(t *testing.T) {
+	s := newTestServices(t, "json")
+	start := daysFromToday(-1)
+
+	// The previous run saved both dates as pending but only added the first expense
+	if _, err := s.recurringRepo.Add(models.RecurringRule{
+		Description: "Rent",
+		Amount:      models.NewMoney(500, 0),
+		Frequency:   models.FrequencyDaily,
+		Interval:    1,
+		Start:       start,
+		Pending:     []time.Time{start, start.AddDate(0, 0, 1)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.expenses.AddExpense(NewExpense{Description: "Rent", Amount: models.NewMoney(500, 0), Date: start}); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := s.recurring.Materialize()
+	if err != nil {
+		t.Fatalf("Materialize failed: %v", err)
+	}
+	if len(ids) != 1 {
+		t.Errorf("created %d expenses, want only the missing one", len(ids))
+	}
+
+	rules, err := s.recurring.GetRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].Generated != 2 || len(rules[0].Pending) != 0 {
+		t.Errorf("rule has %d generated and %d pending occurrences, want 2 and 0", rules[0].Generated, len(rules[0].Pending))
+	}
+}
+
+func TestResumeRuleSkipsMissedOccurrences(t *testing.T) {
+	tests := []struct {
+		name        string
+		start       time.Time
+		wantSkipped int
+		wantCreated int
+	}{
+		{name: "started three days ago", start: daysFromToday(-3), wantSkipped: 3, wantCreated: 1},
+		{name: "starts today", start: daysFromToday(0), wantSkipped: 0, wantCreated: 1},
+		{name: "starts tomorrow", start: daysFromToday(1), wantSkipped: 0, wantCreated: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServices(t, "json")
+			id, err := s.recurring.AddRule(NewRecurringRule{
+				Description: "Coffee",
+				Amount:      models.NewMoney(3, 0),
+				Frequency:   models.FrequencyDaily,
+				Start:       tt.start,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.recurring.PauseRule(id); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.recurring.PauseRule(id); err == nil {
+				t.Error("pausing a paused rule succeeded, want an error")
+			}
+
+			skipped, err := s.recurring.ResumeRule(id)
+			if err != nil {
+				t.Fatalf("ResumeRule failed: %v", err)
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("skipped %d occurrences, want %d", skipped, tt.wantSkipped)
+			}
+			if _, err := s.recurring.ResumeRule(id); err == nil {
+				t.Error("resuming a running rule succeeded, want an error")
+			}
+
+			ids, err := s.recurring.Materialize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ids) != tt.wantCreated {
+				t.Errorf("created %d expenses, want %d", len(ids), tt.wantCreated)
+			}
+		})
+	}
+}
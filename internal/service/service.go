@@ -2,6 +2,10 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Businge931/expense-tracker/internal/models"
@@ -10,28 +14,313 @@ import (
 
 // ExpenseService handles business logic for expense operations
 type ExpenseService struct {
-	repo repository.ExpenseRepository
+	repo       repository.ExpenseRepository
+	converter  *CurrencyConverter
+	journal    repository.JournalRepository
+	categories repository.CategoryRepository
+	rules      repository.CategoryRuleRepository
 }
 
-// NewExpenseService creates a new expense service
-func NewExpenseService(repo repository.ExpenseRepository) *ExpenseService {
+// NewExpense holds the fields of an expense to be added
+type NewExpense struct {
+	Description string
+	Amount      models.Money
+	Currency    string // Empty means the base currency
+	Category    string
+	Tags        []string
+	Merchant    string
+	Date        time.Time // Zero means now
+	AllowFuture bool      // Permit dates after today
+
+	// AllowUnknownCategory permits a category missing from the catalog in
+	// strict mode
+	AllowUnknownCategory bool
+
+	// SuggestConfidence lets AddExpense fill in a missing category that no
+	// rule matched with the suggested category, if the suggestion is at
+	// least this confident, between 0 and 1. Nil turns suggestions off.
+	SuggestConfidence *float64
+}
+
+// AddedExpense reports the ID of an added expense and how its category was
+// chosen when it was entered without one
+type AddedExpense struct {
+	ID         int
+	Rule       *models.CategoryRule // Category rule that set the category, if any
+	Suggestion *CategorySuggestion  // Suggestion that set the category, if any
+}
+
+// ExpenseUpdate holds the fields to change on an existing expense.
+// Nil fields are left unchanged.
+type ExpenseUpdate struct {
+	Description *string
+	Amount      *models.Money
+	Currency    *string
+	Category    *string
+	Tags        *[]string // Replaces all tags of the expense
+	Merchant    *string
+	Date        *time.Time
+	AllowFuture bool // Permit moving the expense to a date after today
+}
+
+// NewExpenseService creates a new expense service that reports totals in
+// the converter's base currency. Changes are recorded in the journal for
+// undo, unless it is nil. Categories are matched against the catalog in
+// categories, and expenses added without a category are categorized by the
+// rules in rules; either may be nil.
+func NewExpenseService(repo repository.ExpenseRepository, converter *CurrencyConverter, journal repository.JournalRepository, categories repository.CategoryRepository, rules repository.CategoryRuleRepository) *ExpenseService {
 	return &ExpenseService{
-		repo: repo,
+		repo:       repo,
+		converter:  converter,
+		journal:    journal,
+		categories: categories,
+		rules:      rules,
 	}
 }
 
-// AddExpense adds a new expense
-func (s *ExpenseService) AddExpense(description string, amount float64, category string) (int, error) {
-	// Validate inputs
-	if description == "" {
-		return 0, errors.New("description cannot be empty")
+// BaseCurrency returns the currency summaries and budgets are reported in
+func (s *ExpenseService) BaseCurrency() string {
+	return s.converter.BaseCurrency()
+}
+
+// AddExpense adds a new expense. An expense without a category is
+// categorized by the first matching category rule or, failing that, by a
+// confident enough suggestion.
+func (s *ExpenseService) AddExpense(input NewExpense) (AddedExpense, error) {
+	if input.SuggestConfidence != nil && (*input.SuggestConfidence < 0 || *input.SuggestConfidence > 1) {
+		return AddedExpense{}, invalidInput("minimum confidence must be between 0 and 1")
 	}
-	if amount <= 0 {
-		return 0, errors.New("amount must be greater than zero")
+
+	catalog, err := s.catalog()
+	if err != nil {
+		return AddedExpense{}, err
+	}
+	rules, err := s.categoryRules()
+	if err != nil {
+		return AddedExpense{}, err
+	}
+
+	expense, err := s.newExpense(input, catalog)
+	if err != nil {
+		return AddedExpense{}, err
+	}
+
+	var added AddedExpense
+	if added.Rule, err = categorize(&expense, rules, catalog); err != nil {
+		return AddedExpense{}, err
+	}
+	if expense.Category == "" && input.SuggestConfidence != nil {
+		if added.Suggestion, err = s.suggestCategory(expense.Description, *input.SuggestConfidence); err != nil {
+			return AddedExpense{}, err
+		}
+		if added.Suggestion != nil {
+			expense.Category = added.Suggestion.Category
+		}
 	}
 
 	// Add expense to repository
-	return s.repo.Add(description, amount, category)
+	if added.ID, err = s.repo.Add(expense); err != nil {
+		return AddedExpense{}, err
+	}
+
+	expense.ID = added.ID
+	op := models.Operation{
+		Description: fmt.Sprintf("add expense %d (%s)", added.ID, expense.Description),
+		Expenses:    []models.ExpenseChange{{After: &expense}},
+	}
+	if err := recordOperation(s.journal, op); err != nil {
+		return added, err
+	}
+
+	return added, nil
+}
+
+// AddExpenses validates and adds several expenses at once. Either all of them
+// are stored or, if any is invalid or storing fails, none are.
+func (s *ExpenseService) AddExpenses(inputs []NewExpense) ([]int, error) {
+	return s.addExpenses(inputs, fmt.Sprintf("add %d expenses", len(inputs)))
+}
+
+// addExpenses adds several expenses at once and records them in the journal
+// as a single operation with the given description
+func (s *ExpenseService) addExpenses(inputs []NewExpense, description string) ([]int, error) {
+	catalog, err := s.catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	expenses := make([]models.Expense, 0, len(inputs))
+	for i, input := range inputs {
+		expense, err := s.newExpense(input, catalog)
+		if err != nil {
+			return nil, fmt.Errorf("expense %d: %w", i+1, err)
+		}
+		expenses = append(expenses, expense)
+	}
+
+	ids, err := s.repo.AddMany(expenses)
+	if err != nil {
+		return nil, err
+	}
+
+	op := models.Operation{Description: description}
+	for i := range expenses {
+		expenses[i].ID = ids[i]
+		op.Expenses = append(op.Expenses, models.ExpenseChange{After: &expenses[i]})
+	}
+	if err := recordOperation(s.journal, op); err != nil {
+		return ids, err
+	}
+
+	return ids, nil
+}
+
+// recordOperation adds an operation to the journal, if there is one
+func recordOperation(journal repository.JournalRepository, op models.Operation) error {
+	if journal == nil {
+		return nil
+	}
+
+	op.Time = time.Now()
+	if err := journal.Record(op); err != nil {
+		return fmt.Errorf("the change was saved but could not be recorded for undo: %w", err)
+	}
+
+	return nil
+}
+
+// newExpense validates the input for a new expense against the category
+// catalog and fills in defaults
+func (s *ExpenseService) newExpense(input NewExpense, catalog models.CategoryCatalog) (models.Expense, error) {
+	// Validate inputs
+	if input.Description == "" {
+		return models.Expense{}, invalidInput("description cannot be empty")
+	}
+	if input.Amount <= 0 {
+		return models.Expense{}, invalidInput("amount must be greater than zero")
+	}
+
+	date := input.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	if !input.AllowFuture && isFutureDate(date) {
+		return models.Expense{}, invalidInput("date cannot be in the future")
+	}
+
+	currency := s.BaseCurrency()
+	if input.Currency != "" {
+		var err error
+		if currency, err = models.NormalizeCurrency(input.Currency); err != nil {
+			return models.Expense{}, asInvalidInput(err)
+		}
+	}
+
+	category, err := resolveCategory(input.Category, catalog, catalog.Strict && !input.AllowUnknownCategory)
+	if err != nil {
+		return models.Expense{}, err
+	}
+
+	tags, err := models.NormalizeTags(input.Tags)
+	if err != nil {
+		return models.Expense{}, asInvalidInput(err)
+	}
+
+	return models.Expense{
+		Description: input.Description,
+		Amount:      input.Amount,
+		Currency:    currency,
+		Category:    category,
+		Tags:        tags,
+		Merchant:    strings.TrimSpace(input.Merchant),
+		Date:        date,
+	}, nil
+}
+
+// catalog returns the category catalog, which is empty when there is none
+func (s *ExpenseService) catalog() (models.CategoryCatalog, error) {
+	if s.categories == nil {
+		return models.CategoryCatalog{}, nil
+	}
+	return s.categories.Load()
+}
+
+// categoryRules returns the category rules ready for matching, which are
+// empty when there are none
+func (s *ExpenseService) categoryRules() (models.CategoryRules, error) {
+	if s.rules == nil {
+		return models.CategoryRules{}, nil
+	}
+	rules, err := s.rules.GetAll()
+	if err != nil {
+		return models.CategoryRules{}, err
+	}
+	return models.NewCategoryRules(rules)
+}
+
+// categorize sets the category of an expense that has none from the first
+// matching rule and returns that rule, or nil when no rule matches
+func categorize(expense *models.Expense, rules models.CategoryRules, catalog models.CategoryCatalog) (*models.CategoryRule, error) {
+	if expense.Category != "" {
+		return nil, nil
+	}
+	rule, ok := rules.Match(*expense)
+	if !ok {
+		return nil, nil
+	}
+
+	// The category was checked against the catalog when the rule was added
+	category, err := resolveCategory(rule.Category, catalog, false)
+	if err != nil {
+		return nil, fmt.Errorf("category rule %d: %w", rule.ID, err)
+	}
+	expense.Category = category
+	return &rule, nil
+}
+
+// ResolveCategory tidies the spacing of a category and returns the catalog's
+// spelling of it. In strict mode a category missing from the catalog is
+// rejected.
+func (s *ExpenseService) ResolveCategory(name string) (string, error) {
+	catalog, err := s.catalog()
+	if err != nil {
+		return "", err
+	}
+	return resolveCategory(name, catalog, catalog.Strict)
+}
+
+// canonicalCategory is like ResolveCategory but never rejects a category, for
+// looking up existing records
+func (s *ExpenseService) canonicalCategory(name string) (string, error) {
+	catalog, err := s.catalog()
+	if err != nil {
+		return "", err
+	}
+	return resolveCategory(name, catalog, false)
+}
+
+// resolveCategory normalizes a category and matches it against the catalog,
+// rejecting unknown categories if strict is set
+func resolveCategory(name string, catalog models.CategoryCatalog, strict bool) (string, error) {
+	normalized, err := models.NormalizeCategory(name)
+	if err != nil || normalized == "" {
+		return normalized, asInvalidInput(err)
+	}
+
+	if known, ok := catalog.Lookup(normalized); ok {
+		return known, nil
+	}
+	if strict {
+		return "", invalidInput("unknown category %q; add it to the category catalog first", normalized)
+	}
+	return normalized, nil
+}
+
+// isFutureDate reports whether date falls on a day after today
+func isFutureDate(date time.Time) bool {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return !date.Before(today.AddDate(0, 0, 1))
 }
 
 // GetAllExpenses returns all expenses
@@ -39,34 +328,310 @@ func (s *ExpenseService) GetAllExpenses() ([]models.Expense, error) {
 	return s.repo.GetAll()
 }
 
+// FindExpenses returns the expenses selected by a query
+func (s *ExpenseService) FindExpenses(query repository.Query) ([]models.Expense, error) {
+	if err := query.Validate(); err != nil {
+		return nil, asInvalidInput(err)
+	}
+	return s.repo.Find(query)
+}
+
+// FindExpensesAsOf returns the expenses selected by a query as they stood at
+// the given time. Only stores that keep a history of changes support it.
+func (s *ExpenseService) FindExpensesAsOf(asOf time.Time, query repository.Query) ([]models.Expense, error) {
+	if err := query.Validate(); err != nil {
+		return nil, asInvalidInput(err)
+	}
+
+	history, ok := s.repo.(repository.HistoryRepository)
+	if !ok {
+		return nil, errors.New("the expense store does not keep a history of changes; use an events store to view past states")
+	}
+
+	expenses, err := history.AsOf(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	return query.Apply(expenses), nil
+}
+
 // GetExpenseByID returns an expense with the given ID
 func (s *ExpenseService) GetExpenseByID(id int) (models.Expense, error) {
 	if id <= 0 {
-		return models.Expense{}, errors.New("invalid expense ID")
+		return models.Expense{}, invalidInput("invalid expense ID")
 	}
 	return s.repo.GetByID(id)
 }
 
-// DeleteExpense deletes an expense with the given ID
+// UpdateExpense changes the supplied fields of the expense with the given ID
+// and returns the updated expense
+func (s *ExpenseService) UpdateExpense(id int, update ExpenseUpdate) (models.Expense, error) {
+	expense, err := s.GetExpenseByID(id)
+	if err != nil {
+		return models.Expense{}, err
+	}
+	before := expense
+
+	if update.Description != nil {
+		expense.Description = *update.Description
+	}
+	if update.Amount != nil {
+		expense.Amount = *update.Amount
+	}
+	if update.Currency != nil {
+		// As for new expenses, an empty currency means the base currency
+		currency := s.BaseCurrency()
+		if *update.Currency != "" {
+			if currency, err = models.NormalizeCurrency(*update.Currency); err != nil {
+				return models.Expense{}, asInvalidInput(err)
+			}
+		}
+		expense.Currency = currency
+	}
+	if update.Category != nil {
+		category, err := s.ResolveCategory(*update.Category)
+		if err != nil {
+			return models.Expense{}, err
+		}
+		expense.Category = category
+	}
+	if update.Tags != nil {
+		tags, err := models.NormalizeTags(*update.Tags)
+		if err != nil {
+			return models.Expense{}, asInvalidInput(err)
+		}
+		expense.Tags = tags
+	}
+	if update.Merchant != nil {
+		expense.Merchant = strings.TrimSpace(*update.Merchant)
+	}
+	if update.Date != nil {
+		expense.Date = *update.Date
+	}
+
+	// Validate the result with the same rules as AddExpense
+	if expense.Description == "" {
+		return models.Expense{}, invalidInput("description cannot be empty")
+	}
+	if expense.Amount <= 0 {
+		return models.Expense{}, invalidInput("amount must be greater than zero")
+	}
+	if update.Date != nil && !update.AllowFuture && isFutureDate(expense.Date) {
+		return models.Expense{}, invalidInput("date cannot be in the future")
+	}
+
+	if err := s.repo.Update(expense); err != nil {
+		return models.Expense{}, err
+	}
+
+	op := models.Operation{
+		Description: fmt.Sprintf("update expense %d (%s)", id, expense.Description),
+		Expenses:    []models.ExpenseChange{{Before: &before, After: &expense}},
+	}
+	if err := recordOperation(s.journal, op); err != nil {
+		return expense, err
+	}
+
+	return expense, nil
+}
+
+// DeleteExpense moves the expense with the given ID to the trash
 func (s *ExpenseService) DeleteExpense(id int) error {
+	expense, err := s.GetExpenseByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	return recordOperation(s.journal, models.Operation{
+		Description: fmt.Sprintf("delete expense %d (%s)", id, expense.Description),
+		Expenses:    []models.ExpenseChange{{Before: &expense}},
+	})
+}
+
+// GetDeletedExpenses returns the expenses in the trash, most recently deleted first
+func (s *ExpenseService) GetDeletedExpenses() ([]models.Expense, error) {
+	return s.repo.GetDeleted()
+}
+
+// RestoreExpense takes an expense out of the trash and returns it
+func (s *ExpenseService) RestoreExpense(id int) (models.Expense, error) {
 	if id <= 0 {
-		return errors.New("invalid expense ID")
+		return models.Expense{}, invalidInput("invalid expense ID")
+	}
+
+	if err := s.repo.Undelete(id); err != nil {
+		return models.Expense{}, err
+	}
+
+	expense, err := s.repo.GetByID(id)
+	if err != nil {
+		return models.Expense{}, err
+	}
+
+	op := models.Operation{
+		Description: fmt.Sprintf("restore expense %d (%s) from trash", id, expense.Description),
+		Expenses:    []models.ExpenseChange{{After: &expense}},
 	}
-	return s.repo.Delete(id)
+	if err := recordOperation(s.journal, op); err != nil {
+		return expense, err
+	}
+
+	return expense, nil
+}
+
+// EmptyTrash permanently removes the expenses that have been in the trash for
+// longer than olderThan and returns how many were removed. This cannot be undone.
+func (s *ExpenseService) EmptyTrash(olderThan time.Duration) (int, error) {
+	if olderThan < 0 {
+		return 0, invalidInput("age must not be negative")
+	}
+	return s.repo.Purge(time.Now().Add(-olderThan))
 }
 
-// GetExpenseSummary returns a summary of all expenses
+// GetExpenseSummary returns a summary of all expenses in the base currency
 func (s *ExpenseService) GetExpenseSummary() (models.ExpenseSummary, error) {
-	return s.repo.GetSummary()
+	summary, err := s.repo.GetSummary()
+	if err != nil {
+		return models.ExpenseSummary{}, err
+	}
+
+	if !s.needsConversion(summary) {
+		summary.Currency = s.BaseCurrency()
+		return s.groupCategories(summary)
+	}
+
+	expenses, err := s.repo.GetAll()
+	if err != nil {
+		return models.ExpenseSummary{}, err
+	}
+
+	if summary, err = s.summarize(expenses, summary); err != nil {
+		return models.ExpenseSummary{}, err
+	}
+	return s.groupCategories(summary)
 }
 
-// GetMonthlySummary returns a summary of expenses for a specific month
-func (s *ExpenseService) GetMonthlySummary(month int) (models.ExpenseSummary, error) {
+// GetMonthlySummary returns a summary of expenses for a specific month and year
+// in the base currency
+func (s *ExpenseService) GetMonthlySummary(month int, year int) (models.ExpenseSummary, error) {
 	if month < 1 || month > 12 {
-		return models.ExpenseSummary{}, errors.New("month must be between 1 and 12")
+		return models.ExpenseSummary{}, invalidInput("month must be between 1 and 12")
+	}
+	if year < 0 {
+		return models.ExpenseSummary{}, invalidInput("year must be a positive number")
+	}
+
+	// If year is not specified (0), use current year
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	summary, err := s.repo.GetMonthlySummary(time.Month(month), year)
+	if err != nil {
+		return models.ExpenseSummary{}, err
+	}
+
+	if !s.needsConversion(summary) {
+		summary.Currency = s.BaseCurrency()
+		return s.groupCategories(summary)
+	}
+
+	expenses, err := s.repo.GetByMonth(time.Month(month), year)
+	if err != nil {
+		return models.ExpenseSummary{}, err
+	}
+
+	if summary, err = s.summarize(expenses, summary); err != nil {
+		return models.ExpenseSummary{}, err
+	}
+	return s.groupCategories(summary)
+}
+
+// needsConversion reports whether a repository summary includes amounts in
+// currencies other than the base currency
+func (s *ExpenseService) needsConversion(summary models.ExpenseSummary) bool {
+	for currency := range summary.CurrencyTotals {
+		if currency != s.BaseCurrency() {
+			return true
+		}
+	}
+	return false
+}
+
+// summarize recomputes the totals of a repository summary from its expenses,
+// converting each amount into the base currency at the rate on its date
+func (s *ExpenseService) summarize(expenses []models.Expense, summary models.ExpenseSummary) (models.ExpenseSummary, error) {
+	summary.TotalAmount = 0
+	summary.Currency = s.BaseCurrency()
+	summary.CategoryTotals = make(map[string]models.Money)
+	summary.TagTotals = make(map[string]models.Money)
+
+	for _, expense := range expenses {
+		amount, err := s.converter.ToBase(expense.Amount, expense.CurrencyCode(), expense.Date)
+		if err != nil {
+			return models.ExpenseSummary{}, fmt.Errorf("failed to convert expense %d: %w", expense.ID, err)
+		}
+
+		summary.TotalAmount += amount
+		if expense.Category != "" {
+			summary.CategoryTotals[expense.Category] += amount
+		}
+		for _, tag := range expense.Tags {
+			summary.TagTotals[tag] += amount
+		}
+	}
+
+	return summary, nil
+}
+
+// groupCategories merges the totals of categories whose names differ only in
+// case or spacing under the catalog's spelling, or else the first spelling in
+// sorted order, and totals every parent category including its subcategories
+func (s *ExpenseService) groupCategories(summary models.ExpenseSummary) (models.ExpenseSummary, error) {
+	catalog, err := s.catalog()
+	if err != nil {
+		return models.ExpenseSummary{}, err
+	}
+
+	spellings := make(map[string]string)
+	for _, category := range catalog.Categories {
+		spellings[strings.ToLower(category.Name)] = category.Name
+	}
+	spell := func(name string) string {
+		key := strings.ToLower(name)
+		if spelling, ok := spellings[key]; ok {
+			return spelling
+		}
+		spellings[key] = name
+		return name
+	}
+
+	totals := make(map[string]models.Money, len(summary.CategoryTotals))
+	for _, name := range slices.Sorted(maps.Keys(summary.CategoryTotals)) {
+		normalized, err := models.NormalizeCategory(name)
+		if err != nil {
+			normalized = name
+		}
+		totals[spell(normalized)] += summary.CategoryTotals[name]
+	}
+
+	rollups := make(map[string]models.Money)
+	for _, name := range slices.Sorted(maps.Keys(totals)) {
+		amount := totals[name]
+		for parent := models.CategoryParent(name); parent != ""; parent = models.CategoryParent(parent) {
+			rollups[spell(parent)] += amount
+		}
+	}
+	for parent := range rollups {
+		rollups[parent] += totals[parent]
 	}
 
-	// Use the current year if not specified
-	currentYear := time.Now().Year()
-	return s.repo.GetMonthlySummary(time.Month(month), currentYear)
+	summary.CategoryTotals = totals
+	summary.CategoryRollups = rollups
+	return summary, nil
 }
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+// DefaultMinConfidence is the confidence a suggestion needs before it is used
+// to fill in the category of a new expense
+const DefaultMinConfidence = 0.8
+
+// CategorySuggestion is a category proposed for a description, with the
+// estimated probability that it is the right one
+type CategorySuggestion struct {
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+}
+
+// SuggestionEvaluation reports how well suggestions would have predicted the
+// categories of existing expenses. Each expense is predicted from all the
+// others, so an expense never contributes to its own prediction.
+type SuggestionEvaluation struct {
+	Expenses         int                  `json:"expenses"`         // Categorized expenses evaluated
+	Correct          int                  `json:"correct"`          // Expenses whose top suggestion was right
+	MinConfidence    float64              `json:"minConfidence"`    // Threshold for filling in a category
+	Confident        int                  `json:"confident"`        // Expenses whose top suggestion reached the threshold
+	ConfidentCorrect int                  `json:"confidentCorrect"` // Confident suggestions that were right
+	Categories       []CategoryEvaluation `json:"categories"`
+}
+
+// CategoryEvaluation reports the accuracy of suggestions for one category
+type CategoryEvaluation struct {
+	Category string `json:"category"`
+	Expenses int    `json:"expenses"`
+	Correct  int    `json:"correct"`
+}
+
+// SuggestCategories suggests categories for a description from the
+// categorized expenses recorded so far, most likely first. It returns
+// nothing when none of the description's words have been seen before.
+func (s *ExpenseService) SuggestCategories(description string) ([]CategorySuggestion, error) {
+	if strings.TrimSpace(description) == "" {
+		return nil, invalidInput("description cannot be empty")
+	}
+
+	model, err := s.trainCategoryModel()
+	if err != nil {
+		return nil, err
+	}
+	return model.suggest(description), nil
+}
+
+// EvaluateSuggestions measures the accuracy of suggestions on the existing
+// categorized expenses, predicting each of them from all the others
+func (s *ExpenseService) EvaluateSuggestions(minConfidence float64) (SuggestionEvaluation, error) {
+	if minConfidence < 0 || minConfidence > 1 {
+		return SuggestionEvaluation{}, invalidInput("minimum confidence must be between 0 and 1")
+	}
+
+	model, err := s.trainCategoryModel()
+	if err != nil {
+		return SuggestionEvaluation{}, err
+	}
+
+	evaluation := SuggestionEvaluation{MinConfidence: minConfidence}
+	perCategory := make(map[string]*CategoryEvaluation)
+	for _, example := range model.examples {
+		key := strings.ToLower(example.category)
+		if perCategory[key] == nil {
+			perCategory[key] = &CategoryEvaluation{Category: model.names[key]}
+		}
+		perCategory[key].Expenses++
+		evaluation.Expenses++
+
+		// Leave the expense out while predicting it
+		model.add(example, -1)
+		suggestions := model.suggest(example.description)
+		model.add(example, 1)
+
+		if len(suggestions) == 0 {
+			continue
+		}
+		correct := strings.EqualFold(suggestions[0].Category, example.category)
+		if correct {
+			evaluation.Correct++
+			perCategory[key].Correct++
+		}
+		if suggestions[0].Confidence >= minConfidence {
+			evaluation.Confident++
+			if correct {
+				evaluation.ConfidentCorrect++
+			}
+		}
+	}
+
+	for _, category := range perCategory {
+		evaluation.Categories = append(evaluation.Categories, *category)
+	}
+	slices.SortFunc(evaluation.Categories, func(a, b CategoryEvaluation) int {
+		return models.CompareCategories(a.Category, b.Category)
+	})
+
+	return evaluation, nil
+}
+
+// suggestCategory returns the most likely category for a description when
+// its confidence reaches minConfidence
+func (s *ExpenseService) suggestCategory(description string, minConfidence float64) (*CategorySuggestion, error) {
+	suggestions, err := s.SuggestCategories(description)
+	if err != nil || len(suggestions) == 0 || suggestions[0].Confidence < minConfidence {
+		return nil, err
+	}
+	return &suggestions[0], nil
+}
+
+// trainCategoryModel learns from every categorized expense that is not in
+// the trash. Categories are grouped ignoring case, under the catalog's
+// spelling where there is one.
+func (s *ExpenseService) trainCategoryModel() (*categoryModel, error) {
+	catalog, err := s.catalog()
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	model := newCategoryModel()
+	for _, expense := range expenses {
+		category, err := resolveCategory(expense.Category, catalog, false)
+		if err != nil || category == "" {
+			continue
+		}
+		example := trainingExample{description: expense.Description, category: category}
+		model.examples = append(model.examples, example)
+		model.add(example, 1)
+	}
+
+	return model, nil
+}
+
+// trainingExample is a description with its known category
+type trainingExample struct {
+	description string
+	category    string
+}
+
+// categoryModel is a multinomial naive Bayes classifier from the words of a
+// description to a category. Categories are keyed in lowercase.
+type categoryModel struct {
+	examples   []trainingExample
+	names      map[string]string         // Spelling of each category
+	documents  map[string]int            // Number of examples per category
+	words      map[string]map[string]int // Occurrences of each word per category
+	wordTotals map[string]int            // Number of words per category
+	vocabulary map[string]int            // Occurrences of each word overall
+	total      int                       // Number of examples
+}
+
+// newCategoryModel creates an untrained model
+func newCategoryModel() *categoryModel {
+	return &categoryModel{
+		names:      make(map[string]string),
+		documents:  make(map[string]int),
+		words:      make(map[string]map[string]int),
+		wordTotals: make(map[string]int),
+		vocabulary: make(map[string]int),
+	}
+}
+
+// add adds an example to the model, or removes it when delta is -1
+func (m *categoryModel) add(example trainingExample, delta int) {
+	key := strings.ToLower(example.category)
+	if _, ok := m.names[key]; !ok {
+		m.names[key] = example.category
+		m.words[key] = make(map[string]int)
+	}
+
+	m.total += delta
+	m.documents[key] += delta
+	for _, word := range descriptionWords(example.description) {
+		m.words[key][word] += delta
+		m.wordTotals[key] += delta
+		m.vocabulary[word] += delta
+		if m.vocabulary[word] == 0 {
+			delete(m.vocabulary, word)
+		}
+	}
+}
+
+// suggest returns the categories ordered by their probability for the
+// description. Words never seen in training are ignored, and nothing is
+// returned when no word of the description is known.
+func (m *categoryModel) suggest(description string) []CategorySuggestion {
+	var known []string
+	for _, word := range descriptionWords(description) {
+		if m.vocabulary[word] > 0 {
+			known = append(known, word)
+		}
+	}
+	if len(known) == 0 || m.total == 0 {
+		return nil
+	}
+
+	// Log probabilities with add-one smoothing, normalized below
+	scores := make(map[string]float64)
+	best := math.Inf(-1)
+	for key, documents := range m.documents {
+		if documents <= 0 {
+			continue
+		}
+		score := math.Log(float64(documents) / float64(m.total))
+		denominator := float64(m.wordTotals[key] + len(m.vocabulary))
+		for _, word := range known {
+			score += math.Log(float64(m.words[key][word]+1) / denominator)
+		}
+		scores[key] = score
+		best = max(best, score)
+	}
+
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - best)
+	}
+
+	suggestions := make([]CategorySuggestion, 0, len(scores))
+	for key, score := range scores {
+		suggestions = append(suggestions, CategorySuggestion{
+			Category:   m.names[key],
+			Confidence: math.Exp(score-best) / sum,
+		})
+	}
+	slices.SortFunc(suggestions, func(a, b CategorySuggestion) int {
+		if c := cmp.Compare(b.Confidence, a.Confidence); c != 0 {
+			return c
+		}
+		return models.CompareCategories(a.Category, b.Category)
+	})
+
+	return suggestions
+}
+
+// stopWords are common words that say nothing about the category
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "at": true, "by": true, "for": true, "from": true,
+	"in": true, "of": true, "on": true, "the": true, "to": true, "with": true,
+}
+
+// descriptionWords splits a description into lowercase words, leaving out
+// numbers and stop words
+func descriptionWords(description string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := fields[:0]
+	for _, field := range fields {
+		if stopWords[field] || strings.IndexFunc(field, unicode.IsLetter) == -1 {
+			continue
+		}
+		words = append(words, field)
+	}
+	return words
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+)
+
+func TestDescriptionWords(t *testing.T) {
+	tests := []struct {
+		description string
+		want        []string
+	}{
+		{description: "Coffee at the Station", want: []string{"coffee", "station"}},
+		{description: "Lunch for 2 on 12.50", want: []string{"lunch"}},
+		{description: "7-Eleven snacks", want: []string{"eleven", "snacks"}},
+		{description: "4G SIM top-up", want: []string{"4g", "sim", "top", "up"}},
+		{description: "Café crème", want: []string{"café", "crème"}},
+		{description: "A and THE with", want: nil},
+		{description: "2024 #3", want: nil},
+		{description: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := descriptionWords(tt.description); !slices.Equal(got, tt.want) {
+			t.Errorf("descriptionWords(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+// trainedModel returns a model trained on the examples, given as
+// description and category pairs
+func trainedModel(examples ...[2]string) *categoryModel {
+	model := newCategoryModel()
+	for _, pair := range examples {
+		example := trainingExample{description: pair[0], category: pair[1]}
+		model.examples = append(model.examples, example)
+		model.add(example, 1)
+	}
+	return model
+}
+
+func TestCategoryModelSuggest(t *testing.T) {
+	model := trainedModel(
+		[2]string{"Coffee", "Food"},
+		[2]string{"Coffee beans", "food"},
+		[2]string{"Lunch", "Food"},
+		[2]string{"Taxi", "Transport"},
+		[2]string{"Bus ticket", "Transport"},
+		[2]string{"Novel", "Books"},
+		[2]string{"Novel", "Gifts"},
+	)
+
+	// With add-one smoothing over the 7 known words, coffee scores
+	// 3/7 * 3/11 for Food, 2/7 * 1/10 for Transport and 1/7 * 1/8 for Books
+	// and Gifts each
+	food, transport, other := 3.0/7*3/11, 2.0/7*1/10, 1.0/7*1/8
+	sum := food + transport + 2*other
+
+	tests := []struct {
+		name        string
+		description string
+		want        []CategorySuggestion
+	}{
+		{
+			name:        "most likely first",
+			description: "Morning coffee",
+			want: []CategorySuggestion{
+				{Category: "Food", Confidence: food / sum},
+				{Category: "Transport", Confidence: transport / sum},
+				{Category: "Books", Confidence: other / sum},
+				{Category: "Gifts", Confidence: other / sum},
+			},
+		},
+		{
+			name:        "ties in category order",
+			description: "novel",
+			want: []CategorySuggestion{
+				{Category: "Food"}, // A larger share of the examples outweighs an unseen word
+				{Category: "Books"},
+				{Category: "Gifts"},
+				{Category: "Transport"},
+			},
+		},
+		{name: "unknown words", description: "Parking garage"},
+		{name: "only stop words", description: "the and of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := model.suggest(tt.description)
+			if len(got) != len(tt.want) {
+				t.Fatalf("suggest(%q) = %v, want %v", tt.description, got, tt.want)
+			}
+
+			var total float64
+			for i, suggestion := range got {
+				if suggestion.Category != tt.want[i].Category {
+					t.Errorf("suggestion %d is %s, want %s", i, suggestion.Category, tt.want[i].Category)
+				}
+				if tt.want[i].Confidence != 0 && math.Abs(suggestion.Confidence-tt.want[i].Confidence) > 1e-9 {
+					t.Errorf("confidence of %s = %v, want %v", suggestion.Category, suggestion.Confidence, tt.want[i].Confidence)
+				}
+				if i > 0 && suggestion.Confidence > got[i-1].Confidence {
+					t.Errorf("suggestion %d is more confident than the one before it", i)
+				}
+				total += suggestion.Confidence
+			}
+			if len(got) > 0 && math.Abs(total-1) > 1e-9 {
+				t.Errorf("confidences add up to %v, want 1", total)
+			}
+		})
+	}
+
+	// An empty model suggests nothing
+	if got := newCategoryModel().suggest("coffee"); got != nil {
+		t.Errorf("untrained model suggested %v", got)
+	}
+}
+
+func TestCategoryModelRemovesExamples(t *testing.T) {
+	model := trainedModel([2]string{"Coffee", "Food"}, [2]string{"Taxi", "Transport"})
+	before := model.suggest("coffee taxi")
+
+	example := trainingExample{description: "Birthday card", category: "Gifts"}
+	model.add(example, 1)
+	model.add(example, -1)
+
+	// Words only the removed example used are forgotten, and its category is
+	// no longer suggested
+	if got := model.suggest("birthday"); got != nil {
+		t.Errorf("suggest after removing the only example = %v, want nothing", got)
+	}
+	if got := model.suggest("coffee taxi"); !slices.Equal(got, before) {
+		t.Errorf("suggest after adding and removing an example = %v, want %v", got, before)
+	}
+}
+
+func TestEvaluateSuggestions(t *testing.T) {
+	s := newTestServices(t, "json")
+	date := time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+	for _, input := range []NewExpense{
+		{Description: "Coffee", Category: "Food"},
+		{Description: "Coffee beans", Category: "food"},
+		{Description: "Coffee shop", Category: "Food"},
+		{Description: "Taxi", Category: "Transport"},
+		{Description: "Taxi home", Category: "Transport"},
+		{Description: "Taxi to the airport", Category: "Transport"},
+		{Description: "Birthday card", Category: "Gifts"}, // The only example of its category
+		{Description: "Parking"},                          // Uncategorized, so not evaluated
+		{Description: "Coffee", Category: "Transport"},    // In the trash, so not evaluated
+	} {
+		input.Amount = models.NewMoney(5, 0)
+		input.Date = date
+		if _, err := s.expenses.AddExpense(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.expenses.DeleteExpense(9); err != nil {
+		t.Fatal(err)
+	}
+
+	wantCategories := []CategoryEvaluation{
+		{Category: "Food", Expenses: 3, Correct: 3},
+		{Category: "Gifts", Expenses: 1, Correct: 0},
+		{Category: "Transport", Expenses: 3, Correct: 3},
+	}
+
+	tests := []struct {
+		minConfidence float64
+		wantConfident int
+	}{
+		// The gifts expense has no suggestion once it is left out, as none of
+		// its words appear elsewhere
+		{minConfidence: 0, wantConfident: 6},
+		{minConfidence: 1, wantConfident: 0},
+	}
+
+	for _, tt := range tests {
+		evaluation, err := s.expenses.EvaluateSuggestions(tt.minConfidence)
+		if err != nil {
+			t.Fatalf("EvaluateSuggestions(%v) failed: %v", tt.minConfidence, err)
+		}
+		if evaluation.Expenses != 7 || evaluation.Correct != 6 {
+			t.Errorf("evaluated %d expenses with %d correct, want 7 with 6 correct", evaluation.Expenses, evaluation.Correct)
+		}
+		if evaluation.Confident != tt.wantConfident || evaluation.ConfidentCorrect != tt.wantConfident {
+			t.Errorf("at %v, %d confident and %d confident correct, want %d of each",
+				tt.minConfidence, evaluation.Confident, evaluation.ConfidentCorrect, tt.wantConfident)
+		}
+		if !slices.Equal(evaluation.Categories, wantCategories) {
+			t.Errorf("categories = %v, want %v", evaluation.Categories, wantCategories)
+		}
+	}
+
+	if _, err := s.expenses.EvaluateSuggestions(1.5); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("EvaluateSuggestions(1.5) returned %v, want ErrInvalidInput", err)
+	}
+	if _, err := s.expenses.SuggestCategories("  "); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("SuggestCategories of a blank description returned %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+// TagUsage reports how many expenses carry a tag
+type TagUsage struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// GetTags returns every tag in use with the number of expenses carrying it,
+// ordered by tag
+func (s *ExpenseService) GetTags() ([]TagUsage, error) {
+	expenses, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, expense := range expenses {
+		for _, tag := range expense.Tags {
+			counts[tag]++
+		}
+	}
+
+	usage := make([]TagUsage, 0, len(counts))
+	for tag, count := range counts {
+		usage = append(usage, TagUsage{Tag: tag, Count: count})
+	}
+	slices.SortFunc(usage, func(a, b TagUsage) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+
+	return usage, nil
+}
+
+// RenameTag replaces a tag with a new name on every expense carrying it,
+// including those in the trash, and returns how many expenses outside the
+// trash were changed. It refuses to rename onto a tag that is already in use;
+// use MergeTags to combine tags.
+func (s *ExpenseService) RenameTag(from, to string) (int, error) {
+	from, err := models.NormalizeTag(from)
+	if err != nil {
+		return 0, asInvalidInput(err)
+	}
+	to, err = models.NormalizeTag(to)
+	if err != nil {
+		return 0, asInvalidInput(err)
+	}
+	if from == to {
+		return 0, invalidInput("the new tag name is the same as the old one")
+	}
+
+	return s.retag([]string{from}, to, true, fmt.Sprintf("rename tag %s to %s", from, to))
+}
+
+// MergeTags replaces each of the source tags with the target tag on every
+// expense carrying them, including those in the trash, and returns how many
+// expenses outside the trash were changed. An expense that ends up with the
+// target tag twice keeps it once.
+func (s *ExpenseService) MergeTags(into string, sources []string) (int, error) {
+	into, err := models.NormalizeTag(into)
+	if err != nil {
+		return 0, asInvalidInput(err)
+	}
+	if len(sources) == 0 {
+		return 0, invalidInput("at least one tag to merge is required")
+	}
+
+	normalized, err := models.NormalizeTags(sources)
+	if err != nil {
+		return 0, asInvalidInput(err)
+	}
+	if slices.Contains(normalized, into) {
+		return 0, invalidInput("cannot merge tag %q into itself", into)
+	}
+
+	return s.retag(normalized, into, false, fmt.Sprintf("merge tags %s into %s", strings.Join(normalized, ", "), into))
+}
+
+// retag rewrites every expense carrying any of the source tags, including
+// those in the trash, to carry the target tag instead, and records it as one
+// operation. The expenses are checked and rewritten in a single transaction,
+// so a concurrent change is never overwritten. With unused set, a target tag
+// already carried by an expense is refused. It returns how many expenses
+// outside the trash were changed.
+func (s *ExpenseService) retag(sources []string, target string, unused bool, description string) (int, error) {
+	op := models.Operation{Description: description}
+	changed := 0
+
+	err := s.repo.Transaction(func(expenses []models.Expense) ([]models.Expense, error) {
+		if unused && slices.ContainsFunc(expenses, func(e models.Expense) bool { return e.HasTag(target) }) {
+			return nil, invalidInput("tag %q is already in use; merge the tags instead", target)
+		}
+
+		var updated []models.Expense
+		for _, expense := range expenses {
+			if !slices.ContainsFunc(sources, expense.HasTag) {
+				continue
+			}
+
+			before, after := expense, expense
+			var tags []string
+			for _, tag := range expense.Tags {
+				if slices.Contains(sources, tag) {
+					tag = target
+				}
+				if !slices.Contains(tags, tag) {
+					tags = append(tags, tag)
+				}
+			}
+			after.Tags = tags
+
+			updated = append(updated, after)
+			op.Expenses = append(op.Expenses, models.ExpenseChange{Before: &before, After: &after})
+			if expense.DeletedAt == nil {
+				changed++
+			}
+		}
+
+		if len(updated) == 0 {
+			return nil, fmt.Errorf("expenses tagged %s %w", strings.Join(sources, " or "), repository.ErrNotFound)
+		}
+		return updated, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := recordOperation(s.journal, op); err != nil {
+		return changed, err
+	}
+
+	return changed, nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+// seedTags adds three expenses with tags and moves the third to the trash
+func seedTags(t *testing.T, s testServices) {
+	t.Helper()
+	date := time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+	for _, input := range []NewExpense{
+		{Description: "Coffee", Tags: []string{"work"}},
+		{Description: "Taxi", Tags: []string{"travel", "work"}},
+		{Description: "Hotel", Tags: []string{"trip"}},
+	} {
+		input.Amount = models.NewMoney(10, 0)
+		input.Date = date
+		if _, err := s.expenses.AddExpense(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.expenses.DeleteExpense(3); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tagsOf returns the tags of every expense, including the trash, by ID
+func tagsOf(t *testing.T, s testServices) map[int][]string {
+	t.Helper()
+	live, err := s.expenses.GetAllExpenses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trash, err := s.expenses.GetDeletedExpenses()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags := make(map[int][]string)
+	for _, expense := range append(live, trash...) {
+		tags[expense.ID] = expense.Tags
+	}
+	return tags
+}
+
+func TestRetag(t *testing.T) {
+	tests := []struct {
+		name        string
+		retag       func(s testServices) (int, error)
+		wantChanged int
+		wantTags    map[int][]string
+		wantErr     error
+	}{
+		{
+			name:        "rename",
+			retag:       func(s testServices) (int, error) { return s.expenses.RenameTag("Work", "office") },
+			wantChanged: 2,
+			wantTags:    map[int][]string{1: {"office"}, 2: {"travel", "office"}, 3: {"trip"}},
+		},
+		{
+			name:        "rename a tag only in the trash",
+			retag:       func(s testServices) (int, error) { return s.expenses.RenameTag("trip", "journey") },
+			wantChanged: 0,
+			wantTags:    map[int][]string{1: {"work"}, 2: {"travel", "work"}, 3: {"journey"}},
+		},
+		{
+			name:    "rename onto a tag in use",
+			retag:   func(s testServices) (int, error) { return s.expenses.RenameTag("work", "travel") },
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "rename onto a tag in use in the trash",
+			retag:   func(s testServices) (int, error) { return s.expenses.RenameTag("work", "trip") },
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "rename a missing tag",
+			retag:   func(s testServices) (int, error) { return s.expenses.RenameTag("gifts", "presents") },
+			wantErr: repository.ErrNotFound,
+		},
+		{
+			name:        "merge",
+			retag:       func(s testServices) (int, error) { return s.expenses.MergeTags("travel", []string{"work", "TRIP"}) },
+			wantChanged: 2,
+			wantTags:    map[int][]string{1: {"travel"}, 2: {"travel"}, 3: {"travel"}},
+		},
+		{
+			name:    "merge into itself",
+			retag:   func(s testServices) (int, error) { return s.expenses.MergeTags("work", []string{"work"}) },
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "merge missing tags",
+			retag:   func(s testServices) (int, error) { return s.expenses.MergeTags("work", []string{"gifts"}) },
+			wantErr: repository.ErrNotFound,
+		},
+	}
+
+	for _, kind := range []string{"json", "sqlite", "events"} {
+		for _, tt := range tests {
+			t.Run(kind+"/"+tt.name, func(t *testing.T) {
+				s := newTestServices(t, kind)
+				seedTags(t, s)
+				before := tagsOf(t, s)
+
+				changed, err := tt.retag(s)
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("got error %v, want %v", err, tt.wantErr)
+					}
+					assertTags(t, tagsOf(t, s), before)
+					return
+				}
+				if err != nil {
+					t.Fatalf("retag failed: %v", err)
+				}
+				if changed != tt.wantChanged {
+					t.Errorf("changed %d expenses, want %d", changed, tt.wantChanged)
+				}
+				assertTags(t, tagsOf(t, s), tt.wantTags)
+
+				// The trashed expense keeps the new tags when it is restored
+				if _, err := s.expenses.RestoreExpense(3); err != nil {
+					t.Fatal(err)
+				}
+				assertTags(t, tagsOf(t, s), tt.wantTags)
+				if _, err := s.undo.Undo(2); err != nil {
+					t.Fatalf("undo failed: %v", err)
+				}
+				assertTags(t, tagsOf(t, s), before)
+			})
+		}
+	}
+}
+
+func TestFindExpensesByTag(t *testing.T) {
+	tests := []struct {
+		tags []string
+		want []string
+	}{
+		{tags: []string{"work"}, want: []string{"Coffee", "Taxi"}},
+		{tags: []string{"Travel"}, want: []string{"Taxi"}},
+		{tags: []string{"work", "travel"}, want: []string{"Taxi"}},
+		{tags: []string{"trip"}, want: nil},
+	}
+
+	for _, kind := range []string{"json", "sqlite", "events"} {
+		s := newTestServices(t, kind)
+		seedTags(t, s)
+
+		for _, tt := range tests {
+			expenses, err := s.expenses.FindExpenses(repository.Query{Tags: tt.tags})
+			if err != nil {
+				t.Fatalf("%s: FindExpenses failed: %v", kind, err)
+			}
+			var got []string
+			for _, expense := range expenses {
+				got = append(got, expense.Description)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s: expenses tagged %v = %v, want %v", kind, tt.tags, got, tt.want)
+			}
+		}
+
+		usage, err := s.expenses.GetTags()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []TagUsage{{Tag: "travel", Count: 1}, {Tag: "work", Count: 2}}
+		if !slices.Equal(usage, want) {
+			t.Errorf("%s: GetTags = %v, want %v", kind, usage, want)
+		}
+	}
+}
+
+// assertTags fails the test unless every expense carries the wanted tags
+func assertTags(t *testing.T, got, want map[int][]string) {
+	t.Helper()
+	for id, tags := range want {
+		if !slices.Equal(got[id], tags) {
+			t.Errorf("expense %d has tags %v, want %v", id, got[id], tags)
+		}
+	}
+}
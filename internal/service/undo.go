@@ -0,0 +1,279 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+// UndoService reverses and repeats the operations recorded in the journal
+type UndoService struct {
+	journal    repository.JournalRepository
+	expenses   repository.ExpenseRepository
+	budgets    repository.BudgetRepository
+	categories repository.CategoryRepository
+}
+
+// NewUndoService creates a new undo service
+func NewUndoService(journal repository.JournalRepository, expenses repository.ExpenseRepository, budgets repository.BudgetRepository, categories repository.CategoryRepository) *UndoService {
+	return &UndoService{
+		journal:    journal,
+		expenses:   expenses,
+		budgets:    budgets,
+		categories: categories,
+	}
+}
+
+// Undo reverses up to steps of the most recent operations, newest first, and
+// returns the operations it reversed
+func (s *UndoService) Undo(steps int) ([]models.Operation, error) {
+	return s.repeat(steps, repository.ErrNothingToUndo, func() (models.Operation, error) {
+		return s.journal.Undo(func(op models.Operation) error {
+			return s.apply(op, true)
+		})
+	})
+}
+
+// Redo performs up to steps of the most recently undone operations again and
+// returns the operations it redid
+func (s *UndoService) Redo(steps int) ([]models.Operation, error) {
+	return s.repeat(steps, repository.ErrNothingToRedo, func() (models.Operation, error) {
+		return s.journal.Redo(func(op models.Operation) error {
+			return s.apply(op, false)
+		})
+	})
+}
+
+// History returns the operations that can be undone and redone, each newest first
+func (s *UndoService) History() (done []models.Operation, undone []models.Operation, err error) {
+	return s.journal.List()
+}
+
+// repeat runs step up to steps times, stopping early without an error once
+// the journal runs out of operations after at least one step succeeded
+func (s *UndoService) repeat(steps int, exhausted error, step func() (models.Operation, error)) ([]models.Operation, error) {
+	if steps < 1 {
+		return nil, invalidInput("number of steps must be at least 1")
+	}
+
+	var ops []models.Operation
+	for range steps {
+		op, err := step()
+		if errors.Is(err, exhausted) && len(ops) > 0 {
+			break
+		}
+		if err != nil {
+			return ops, err
+		}
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
+
+// apply moves every expense, budget and category catalog touched by op from
+// one side of its changes to the other: back to Before when undoing, forward
+// to After when redoing. The catalog, the budgets and the expenses are each
+// changed in a single write, with the transactions nested so that every
+// record is checked before anything is saved. An operation whose records have
+// since been changed some other way is refused rather than half applied.
+func (s *UndoService) apply(op models.Operation, undo bool) error {
+	verb := "redo"
+	expenses := op.Expenses
+	budgets := op.Budgets
+	if undo {
+		verb = "undo"
+		expenses = slices.Clone(expenses)
+		slices.Reverse(expenses)
+		budgets = slices.Clone(budgets)
+		slices.Reverse(budgets)
+	}
+
+	err := s.applyCatalog(op.Catalog, undo, func() error {
+		return s.applyBudgets(budgets, undo, func() error {
+			return s.applyExpenses(expenses, undo)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("cannot %s %q: %w", verb, op.Description, err)
+	}
+
+	return nil
+}
+
+// applyCatalog moves the category catalog to the other side of change, if
+// there is one, and runs next before saving it
+func (s *UndoService) applyCatalog(change *models.CatalogChange, undo bool, next func() error) error {
+	if change == nil {
+		return next()
+	}
+
+	from, to := catalogSides(*change, undo)
+	return s.categories.Update(func(current models.CategoryCatalog) (models.CategoryCatalog, error) {
+		if !current.Equal(from) {
+			return current, errors.New("the category catalog has been changed since")
+		}
+		if err := next(); err != nil {
+			return current, err
+		}
+		return to, nil
+	})
+}
+
+// applyBudgets moves the budgets to the other side of their changes and runs
+// next before saving them
+func (s *UndoService) applyBudgets(changes []models.BudgetChange, undo bool, next func() error) error {
+	if len(changes) == 0 {
+		return next()
+	}
+
+	return s.budgets.Update(func(budgets []models.Budget) ([]models.Budget, error) {
+		for _, change := range changes {
+			from, to := budgetSides(change, undo)
+			key := to
+			if from != nil {
+				key = from
+			}
+
+			i := slices.IndexFunc(budgets, func(b models.Budget) bool {
+				return b.Month == key.Month && b.Year == key.Year && b.Category == key.Category
+			})
+			if err := checkBudget(budgets, i, from, to); err != nil {
+				return nil, err
+			}
+
+			switch {
+			case to == nil:
+				budgets = slices.Delete(budgets, i, i+1)
+			case i >= 0:
+				budgets[i] = *to
+			default:
+				budgets = append(budgets, *to)
+			}
+		}
+
+		if err := next(); err != nil {
+			return nil, err
+		}
+		return budgets, nil
+	})
+}
+
+// applyExpenses moves the expenses to the other side of their changes in a
+// single write. Expenses that go away are moved to the trash; expenses that
+// come back are taken out of the trash or, if it has been emptied since,
+// restored under their IDs. Changes to expenses in the trash leave them there.
+func (s *UndoService) applyExpenses(changes []models.ExpenseChange, undo bool) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return s.expenses.Transaction(func(expenses []models.Expense) ([]models.Expense, error) {
+		current := make(map[int]models.Expense, len(expenses))
+		for _, expense := range expenses {
+			current[expense.ID] = expense
+		}
+
+		now := time.Now()
+		var changed []models.Expense
+		for _, change := range changes {
+			from, to := expenseSides(change, undo)
+			if err := checkExpense(current, from, to); err != nil {
+				return nil, err
+			}
+
+			var expense models.Expense
+			if to == nil {
+				expense = current[from.ID]
+				expense.DeletedAt = &now
+			} else {
+				expense = *to
+			}
+			current[expense.ID] = expense
+			changed = append(changed, expense)
+		}
+		return changed, nil
+	})
+}
+
+// expenseSides returns the state an expense is expected to be in now and the
+// state to move it to
+func expenseSides(change models.ExpenseChange, undo bool) (from, to *models.Expense) {
+	if undo {
+		return change.After, change.Before
+	}
+	return change.Before, change.After
+}
+
+// budgetSides returns the state a budget is expected to be in now and the
+// state to move it to
+func budgetSides(change models.BudgetChange, undo bool) (from, to *models.Budget) {
+	if undo {
+		return change.After, change.Before
+	}
+	return change.Before, change.After
+}
+
+// catalogSides returns the state the category catalog is expected to be in
+// now and the state to move it to
+func catalogSides(change models.CatalogChange, undo bool) (from, to models.CategoryCatalog) {
+	if undo {
+		return change.After, change.Before
+	}
+	return change.Before, change.After
+}
+
+// checkExpense verifies that an expense is still in the state from, in or out
+// of the trash, where nil means it must not exist outside the trash
+func checkExpense(current map[int]models.Expense, from, to *models.Expense) error {
+	if from == nil {
+		if expense, ok := current[to.ID]; ok && expense.DeletedAt == nil {
+			return fmt.Errorf("expense %d exists again", to.ID)
+		}
+		return nil
+	}
+
+	expense, ok := current[from.ID]
+	if !ok || (expense.DeletedAt != nil && from.DeletedAt == nil) {
+		return fmt.Errorf("expense %d no longer exists", from.ID)
+	}
+	if !sameExpense(expense, *from) || (expense.DeletedAt == nil) != (from.DeletedAt == nil) {
+		return fmt.Errorf("expense %d has been changed since", from.ID)
+	}
+	return nil
+}
+
+// checkBudget verifies that the budget at index i of budgets, -1 when there
+// is none, is still in the state from, where nil means it must not exist
+func checkBudget(budgets []models.Budget, i int, from, to *models.Budget) error {
+	if from == nil {
+		if i >= 0 {
+			return fmt.Errorf("the %s exists again", budgetDescription(*to))
+		}
+		return nil
+	}
+
+	if i == -1 {
+		return fmt.Errorf("the %s no longer exists", budgetDescription(*from))
+	}
+	if budgets[i] != *from {
+		return fmt.Errorf("the %s has been changed since", budgetDescription(*from))
+	}
+	return nil
+}
+
+// sameExpense reports whether two expenses hold the same data
+func sameExpense(a, b models.Expense) bool {
+	return a.ID == b.ID &&
+		a.Description == b.Description &&
+		a.Amount == b.Amount &&
+		a.CurrencyCode() == b.CurrencyCode() &&
+		a.Category == b.Category &&
+		slices.Equal(a.Tags, b.Tags) &&
+		a.Merchant == b.Merchant &&
+		a.Date.Equal(b.Date)
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+)
+
+// undoState is everything an operation can change that undo must restore
+type undoState struct {
+	Expenses []models.Expense
+	Budgets  []models.Budget
+	Catalog  models.CategoryCatalog
+}
+
+// captureState returns the live expenses, budgets and category catalog as
+// JSON so states can be compared
+func captureState(t *testing.T, s testServices) string {
+	t.Helper()
+	var state undoState
+	var err error
+	if state.Expenses, err = s.expenses.GetAllExpenses(); err != nil {
+		t.Fatal(err)
+	}
+	if state.Budgets, err = s.budgets.GetAllBudgets(); err != nil {
+		t.Fatal(err)
+	}
+	if state.Catalog, err = s.categories.GetCatalog(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range state.Expenses {
+		state.Expenses[i].Date = state.Expenses[i].Date.UTC()
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// seedUndoData adds two expenses, a budget and a category for the operations to change
+func seedUndoData(t *testing.T, s testServices) {
+	t.Helper()
+	date := time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+	if _, err := s.categories.AddCategory("Food"); err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range []NewExpense{
+		{Description: "Coffee", Amount: models.NewMoney(4, 50), Category: "Food", Tags: []string{"work"}, Date: date},
+		{Description: "Taxi", Amount: models.NewMoney(12, 0), Tags: []string{"work", "travel"}, Date: date},
+	} {
+		if _, err := s.expenses.AddExpense(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.budgets.SetBudget(3, 2025, "Food", models.NewMoney(100, 0)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUndoRedoRoundTrip(t *testing.T) {
+	description := "Latte"
+	amount := models.NewMoney(5, 25)
+
+	tests := []struct {
+		name  string
+		steps int
+		do    func(s testServices) error
+	}{
+		{
+			name:  "add expense",
+			steps: 1,
+			do: func(s testServices) error {
+				_, err := s.expenses.AddExpense(NewExpense{Description: "Lunch", Amount: models.NewMoney(9, 0)})
+				return err
+			},
+		},
+		{
+			name:  "add expenses in one batch",
+			steps: 1,
+			do: func(s testServices) error {
+				_, err := s.expenses.AddExpenses([]NewExpense{
+					{Description: "Lunch", Amount: models.NewMoney(9, 0)},
+					{Description: "Dinner", Amount: models.NewMoney(20, 0)},
+				})
+				return err
+			},
+		},
+		{
+			name:  "update expense",
+			steps: 1,
+			do: func(s testServices) error {
+				_, err := s.expenses.UpdateExpense(1, ExpenseUpdate{Description: &description, Amount: &amount})
+				return err
+			},
+		},
+		{
+			name:  "delete expense",
+			steps: 1,
+			do:    func(s testServices) error { return s.expenses.DeleteExpense(2) },
+		},
+		{
+			name:  "set new budget",
+			steps: 1,
+			do:    func(s testServices) error { return s.budgets.SetBudget(4, 2025, "", models.NewMoney(300, 0)) },
+		},
+		{
+			name:  "change budget",
+			steps: 1,
+			do:    func(s testServices) error { return s.budgets.SetBudget(3, 2025, "Food", models.NewMoney(80, 0)) },
+		},
+		{
+			name:  "delete budget",
+			steps: 1,
+			do:    func(s testServices) error { return s.budgets.DeleteBudget(3, 2025, "Food") },
+		},
+		{
+			name:  "rename tag",
+			steps: 1,
+			do: func(s testServices) error {
+				_, err := s.expenses.RenameTag("work", "office")
+				return err
+			},
+		},
+		{
+			name:  "rename category with expenses and budgets",
+			steps: 1,
+			do: func(s testServices) error {
+				_, err := s.categories.RenameCategory("Food", "Meals")
+				return err
+			},
+		},
+		{
+			name:  "rename category with an expense in the trash",
+			steps: 2,
+			do: func(s testServices) error {
+				if err := s.expenses.DeleteExpense(1); err != nil {
+					return err
+				}
+				_, err := s.categories.RenameCategory("Food", "Meals")
+				return err
+			},
+		},
+		{
+			name:  "several operations",
+			steps: 3,
+			do: func(s testServices) error {
+				if _, err := s.expenses.UpdateExpense(1, ExpenseUpdate{Amount: &amount}); err != nil {
+					return err
+				}
+				if err := s.expenses.DeleteExpense(1); err != nil {
+					return err
+				}
+				return s.budgets.DeleteBudget(3, 2025, "Food")
+			},
+		},
+	}
+
+	for _, kind := range []string{"json", "sqlite", "events"} {
+		for _, tt := range tests {
+			t.Run(kind+"/"+tt.name, func(t *testing.T) {
+				s := newTestServices(t, kind)
+				seedUndoData(t, s)
+
+				before := captureState(t, s)
+				if err := tt.do(s); err != nil {
+					t.Fatalf("operation failed: %v", err)
+				}
+				after := captureState(t, s)
+				if after == before {
+					t.Fatal("operation changed nothing")
+				}
+
+				for round := 1; round <= 2; round++ {
+					ops, err := s.undo.Undo(tt.steps)
+					if err != nil {
+						t.Fatalf("undo %d failed: %v", round, err)
+					}
+					if len(ops) != tt.steps {
+						t.Errorf("undo %d reversed %d operations, want %d", round, len(ops), tt.steps)
+					}
+					if got := captureState(t, s); got != before {
+						t.Fatalf("state after undo %d:\n%s\nwant:\n%s", round, got, before)
+					}
+
+					if _, err := s.undo.Redo(tt.steps); err != nil {
+						t.Fatalf("redo %d failed: %v", round, err)
+					}
+					if got := captureState(t, s); got != after {
+						t.Fatalf("state after redo %d:\n%s\nwant:\n%s", round, got, after)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestUndoRefusesChangedRecords(t *testing.T) {
+	amount := models.NewMoney(7, 0)
+	otherAmount := models.NewMoney(8, 0)
+
+	tests := []struct {
+		name   string
+		do     func(s testServices) error
+		change func(s testServices) error // Made behind the journal's back
+	}{
+		{
+			name: "expense changed since",
+			do: func(s testServices) error {
+				_, err := s.expenses.UpdateExpense(1, ExpenseUpdate{Amount: &amount})
+				return err
+			},
+			change: func(s testServices) error {
+				expense, err := s.expenseRepo.GetByID(1)
+				if err != nil {
+					return err
+				}
+				expense.Amount = otherAmount
+				return s.expenseRepo.Update(expense)
+			},
+		},
+		{
+			name: "expense deleted since",
+			do: func(s testServices) error {
+				_, err := s.expenses.UpdateExpense(1, ExpenseUpdate{Amount: &amount})
+				return err
+			},
+			change: func(s testServices) error { return s.expenseRepo.Delete(1) },
+		},
+		{
+			name: "expense restored since",
+			do:   func(s testServices) error { return s.expenses.DeleteExpense(2) },
+			change: func(s testServices) error {
+				return s.expenseRepo.Undelete(2)
+			},
+		},
+	}
+
+	for _, kind := range []string{"json", "sqlite", "events"} {
+		for _, tt := range tests {
+			t.Run(kind+"/"+tt.name, func(t *testing.T) {
+				s := newTestServices(t, kind)
+				seedUndoData(t, s)
+
+				if err := tt.do(s); err != nil {
+					t.Fatalf("operation failed: %v", err)
+				}
+				if err := tt.change(s); err != nil {
+					t.Fatalf("change failed: %v", err)
+				}
+				changed := captureState(t, s)
+
+				if _, err := s.undo.Undo(1); err == nil {
+					t.Fatal("undo succeeded, want an error")
+				}
+				if got := captureState(t, s); got != changed {
+					t.Errorf("refused undo changed the state:\n%s\nwant:\n%s", got, changed)
+				}
+			})
+		}
+	}
+}
+
+func TestUndoSteps(t *testing.T) {
+	s := newTestServices(t, "json")
+
+	if _, err := s.undo.Undo(1); !errors.Is(err, repository.ErrNothingToUndo) {
+		t.Errorf("undo with an empty journal returned %v, want ErrNothingToUndo", err)
+	}
+	if _, err := s.undo.Undo(0); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("undo of zero steps returned %v, want ErrInvalidInput", err)
+	}
+
+	seedUndoData(t, s)
+
+	// The seed recorded four operations; asking for more undoes all of them
+	ops, err := s.undo.Undo(10)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if len(ops) != 4 {
+		t.Errorf("undid %d operations, want 4", len(ops))
+	}
+	if _, err := s.undo.Redo(2); err != nil {
+		t.Fatalf("Redo failed: %v", err)
+	}
+
+	done, undone, err := s.undo.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 2 || len(undone) != 2 {
+		t.Errorf("history has %d done and %d undone operations, want 2 and 2", len(done), len(undone))
+	}
+
+	// A new operation discards the operations that could be redone
+	if err := s.expenses.DeleteExpense(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.undo.Redo(1); !errors.Is(err, repository.ErrNothingToRedo) {
+		t.Errorf("redo after a new operation returned %v, want ErrNothingToRedo", err)
+	}
+}
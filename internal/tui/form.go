@@ -0,0 +1,221 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// Indexes of the form fields
+const (
+	fieldDescription = iota
+	fieldAmount
+	fieldCurrency
+	fieldCategory
+	fieldTags
+	fieldMerchant
+	fieldDate
+)
+
+// fieldLabels are the labels of the form fields, in order
+var fieldLabels = []string{"Description", "Amount", "Currency", "Category", "Tags", "Merchant", "Date"}
+
+// dateLayout is the format dates are entered in
+const dateLayout = "2006-01-02"
+
+// form holds the fields of an expense being added or edited
+type form struct {
+	editing    *models.Expense // Expense being edited, nil when adding
+	values     []string
+	focus      int
+	categories []string // Known categories to complete from
+}
+
+// newAddForm creates an empty form for a new expense. Without a date, the
+// expense is dated now.
+func newAddForm(categories []string) *form {
+	return &form{values: make([]string, len(fieldLabels)), categories: categories}
+}
+
+// newEditForm creates a form filled in with the fields of an expense
+func newEditForm(expense models.Expense, categories []string) *form {
+	values := make([]string, len(fieldLabels))
+	values[fieldDescription] = expense.Description
+	values[fieldAmount] = expense.Amount.String()
+	values[fieldCurrency] = expense.Currency
+	values[fieldCategory] = expense.Category
+	values[fieldTags] = strings.Join(expense.Tags, ", ")
+	values[fieldMerchant] = expense.Merchant
+	values[fieldDate] = expense.Date.Local().Format(dateLayout)
+	return &form{editing: &expense, values: values, categories: categories}
+}
+
+// title returns the heading of the form
+func (f *form) title() string {
+	if f.editing != nil {
+		return fmt.Sprintf("Edit expense %d", f.editing.ID)
+	}
+	return "Add expense"
+}
+
+// typeRune appends a character to the focused field
+func (f *form) typeRune(r rune) {
+	f.values[f.focus] += string(r)
+}
+
+// backspace removes the last character of the focused field
+func (f *form) backspace() {
+	runes := []rune(f.values[f.focus])
+	if len(runes) > 0 {
+		f.values[f.focus] = string(runes[:len(runes)-1])
+	}
+}
+
+// clear empties the focused field
+func (f *form) clear() {
+	f.values[f.focus] = ""
+}
+
+// move focuses the field delta positions away, wrapping around
+func (f *form) move(delta int) {
+	f.focus = (f.focus + delta + len(f.values)) % len(f.values)
+}
+
+// matches returns the known categories starting with the category typed so
+// far, ignoring case
+func (f *form) matches() []string {
+	prefix := strings.ToLower(strings.TrimSpace(f.values[fieldCategory]))
+	if prefix == "" {
+		return nil
+	}
+
+	var matches []string
+	for _, category := range f.categories {
+		if strings.HasPrefix(strings.ToLower(category), prefix) {
+			matches = append(matches, category)
+		}
+	}
+	return matches
+}
+
+// completion returns the first known category extending what was typed in
+// the focused category field, or "" when there is nothing to complete
+func (f *form) completion() string {
+	if f.focus != fieldCategory {
+		return ""
+	}
+	typed := f.values[fieldCategory]
+	for _, match := range f.matches() {
+		if len(match) > len(typed) {
+			return match
+		}
+	}
+	return ""
+}
+
+// tab completes the category when there is a completion, and otherwise
+// moves to the next field
+func (f *form) tab() {
+	if completion := f.completion(); completion != "" {
+		f.values[fieldCategory] = completion
+		return
+	}
+	f.move(1)
+}
+
+// newExpense reads the form as a new expense
+func (f *form) newExpense() (service.NewExpense, error) {
+	amount, err := models.ParseMoney(f.values[fieldAmount])
+	if err != nil {
+		return service.NewExpense{}, err
+	}
+	date, err := f.date()
+	if err != nil {
+		return service.NewExpense{}, err
+	}
+
+	minConfidence := service.DefaultMinConfidence
+	return service.NewExpense{
+		Description:       f.values[fieldDescription],
+		Amount:            amount,
+		Currency:          f.values[fieldCurrency],
+		Category:          f.values[fieldCategory],
+		Tags:              splitTags(f.values[fieldTags]),
+		Merchant:          f.values[fieldMerchant],
+		Date:              date,
+		SuggestConfidence: &minConfidence,
+	}, nil
+}
+
+// update reads the fields changed on the edited expense. It returns false
+// when nothing changed.
+func (f *form) update() (service.ExpenseUpdate, bool, error) {
+	expense := f.editing
+	var update service.ExpenseUpdate
+	changed := false
+
+	setString := func(target **string, value, current string) {
+		if value != current {
+			*target = &value
+			changed = true
+		}
+	}
+	setString(&update.Description, f.values[fieldDescription], expense.Description)
+	setString(&update.Currency, f.values[fieldCurrency], expense.Currency)
+	setString(&update.Category, f.values[fieldCategory], expense.Category)
+	setString(&update.Merchant, f.values[fieldMerchant], expense.Merchant)
+
+	amount, err := models.ParseMoney(f.values[fieldAmount])
+	if err != nil {
+		return update, false, err
+	}
+	if amount != expense.Amount {
+		update.Amount = &amount
+		changed = true
+	}
+
+	if tags := splitTags(f.values[fieldTags]); !slices.Equal(tags, expense.Tags) {
+		update.Tags = &tags
+		changed = true
+	}
+
+	// Keep the time of day unless the date changed
+	if value := strings.TrimSpace(f.values[fieldDate]); value != "" && value != expense.Date.Local().Format(dateLayout) {
+		date, err := f.date()
+		if err != nil {
+			return update, false, err
+		}
+		update.Date = &date
+		changed = true
+	}
+
+	return update, changed, nil
+}
+
+// date parses the date field. An empty date is now.
+func (f *form) date() (time.Time, error) {
+	value := strings.TrimSpace(f.values[fieldDate])
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+// splitTags splits comma-separated tags, leaving out empty ones
+func splitTags(value string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// key is a key press: either a named key such as "up" or "ctrl+c", or a
+// printable character with an empty name
+type key struct {
+	name string
+	r    rune
+}
+
+// csiKeys maps the final byte of CSI escape sequences to key names
+var csiKeys = map[byte]string{
+	'A': "up",
+	'B': "down",
+	'C': "right",
+	'D': "left",
+	'H': "home",
+	'F': "end",
+	'Z': "backtab",
+}
+
+// tildeKeys maps the parameter of CSI sequences ending in '~' to key names
+var tildeKeys = map[int]string{
+	1: "home",
+	3: "delete",
+	4: "end",
+	5: "pgup",
+	6: "pgdn",
+	7: "home",
+	8: "end",
+}
+
+// controlKeys maps control characters to key names
+var controlKeys = map[byte]string{
+	0x01: "ctrl+a",
+	0x03: "ctrl+c",
+	0x04: "ctrl+d",
+	0x08: "backspace",
+	0x09: "tab",
+	0x0a: "enter",
+	0x0d: "enter",
+	0x13: "ctrl+s",
+	0x15: "ctrl+u",
+	0x17: "ctrl+w",
+	0x7f: "backspace",
+}
+
+// decodeKeys splits what the terminal sent in one read into key presses.
+// An escape byte at the end of the input is the Esc key itself, since
+// terminals send escape sequences in a single write.
+func decodeKeys(input []byte) []key {
+	var keys []key
+	for len(input) > 0 {
+		b := input[0]
+		switch {
+		case b == 0x1b:
+			k, n := decodeEscape(input)
+			if k.name != "" {
+				keys = append(keys, k)
+			}
+			input = input[n:]
+		case b < 0x20 || b == 0x7f:
+			if name, ok := controlKeys[b]; ok {
+				keys = append(keys, key{name: name})
+			}
+			input = input[1:]
+		default:
+			r, n := utf8.DecodeRune(input)
+			if r != utf8.RuneError {
+				keys = append(keys, key{r: r})
+			}
+			input = input[n:]
+		}
+	}
+	return keys
+}
+
+// decodeEscape decodes the escape sequence at the start of input and returns
+// the key with the number of bytes it took. Unknown sequences are consumed
+// and returned as a key without a name.
+func decodeEscape(input []byte) (key, int) {
+	if len(input) == 1 {
+		return key{name: "esc"}, 1
+	}
+	if input[1] != '[' && input[1] != 'O' {
+		// Alt with another key, or Esc typed before it; treat it as Esc
+		return key{name: "esc"}, 1
+	}
+
+	// Parameters run until a final byte in the range 0x40-0x7e
+	for i := 2; i < len(input); i++ {
+		final := input[i]
+		if final < 0x40 || final > 0x7e {
+			continue
+		}
+		if final == '~' {
+			param, _, _ := strings.Cut(string(input[2:i]), ";")
+			n, _ := strconv.Atoi(param)
+			return key{name: tildeKeys[n]}, i + 1
+		}
+		return key{name: csiKeys[final]}, i + 1
+	}
+	return key{}, len(input)
+}
@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []key
+	}{
+		{name: "printable characters", input: "ab1", want: []key{{r: 'a'}, {r: 'b'}, {r: '1'}}},
+		{name: "multi-byte UTF-8", input: "é€🙂", want: []key{{r: 'é'}, {r: '€'}, {r: '🙂'}}},
+		{name: "invalid UTF-8 is dropped", input: "a\xffb", want: []key{{r: 'a'}, {r: 'b'}}},
+		{name: "CSI arrows", input: "\x1b[A\x1b[B\x1b[C\x1b[D", want: []key{{name: "up"}, {name: "down"}, {name: "right"}, {name: "left"}}},
+		{name: "SS3 arrows", input: "\x1bOA\x1bOD", want: []key{{name: "up"}, {name: "left"}}},
+		{name: "arrow with modifier", input: "\x1b[1;5C", want: []key{{name: "right"}}},
+		{name: "home, end and backtab", input: "\x1b[H\x1b[F\x1b[Z", want: []key{{name: "home"}, {name: "end"}, {name: "backtab"}}},
+		{
+			name:  "tilde sequences",
+			input: "\x1b[1~\x1b[3~\x1b[4~\x1b[5~\x1b[6~\x1b[7~\x1b[8~",
+			want:  []key{{name: "home"}, {name: "delete"}, {name: "end"}, {name: "pgup"}, {name: "pgdn"}, {name: "home"}, {name: "end"}},
+		},
+		{name: "tilde sequence with modifier", input: "\x1b[3;2~", want: []key{{name: "delete"}}},
+		{name: "unknown sequences are dropped", input: "\x1b[99~x\x1b[5Py", want: []key{{r: 'x'}, {r: 'y'}}},
+		{name: "trailing Esc", input: "a\x1b", want: []key{{r: 'a'}, {name: "esc"}}},
+		{name: "Esc alone", input: "\x1b", want: []key{{name: "esc"}}},
+		{name: "Esc before a character", input: "\x1bq", want: []key{{name: "esc"}, {r: 'q'}}},
+		{name: "incomplete sequence", input: "\x1b[1;", want: nil},
+		{
+			name:  "control keys",
+			input: "\x01\x03\x04\x08\t\n\r\x13\x15\x17\x7f",
+			want: []key{
+				{name: "ctrl+a"}, {name: "ctrl+c"}, {name: "ctrl+d"}, {name: "backspace"}, {name: "tab"}, {name: "enter"},
+				{name: "enter"}, {name: "ctrl+s"}, {name: "ctrl+u"}, {name: "ctrl+w"}, {name: "backspace"},
+			},
+		},
+		{name: "unknown control keys are dropped", input: "\x02x\x1f", want: []key{{r: 'x'}}},
+		{name: "mixed", input: "hi\x1b[A\r", want: []key{{r: 'h'}, {r: 'i'}, {name: "up"}, {name: "enter"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeKeys([]byte(tt.input)); !slices.Equal(got, tt.want) {
+				t.Errorf("decodeKeys(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
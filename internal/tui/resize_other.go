@@ -0,0 +1,12 @@
+//go:build !unix
+
+package tui
+
+import "os"
+
+// notifyResize does nothing where resizes are not signalled; the screen is
+// redrawn at the new size on the next key press
+func notifyResize(ch chan<- os.Signal) {}
+
+// stopResize does nothing where resizes are not signalled
+func stopResize(ch chan<- os.Signal) {}
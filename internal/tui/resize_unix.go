@@ -0,0 +1,19 @@
+//go:build unix
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyResize sends to ch when the terminal window is resized
+func notifyResize(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
+
+// stopResize stops sending resize notifications to ch
+func stopResize(ch chan<- os.Signal) {
+	signal.Stop(ch)
+}
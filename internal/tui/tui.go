@@ -0,0 +1,431 @@
+// Package tui is a full-screen terminal interface for entering and reviewing
+// expenses one month at a time
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/Businge931/expense-tracker/internal/models"
+	"github.com/Businge931/expense-tracker/internal/repository"
+	"github.com/Businge931/expense-tracker/internal/service"
+)
+
+// App is the state of the terminal interface
+type App struct {
+	expenseService *service.ExpenseService
+	budgetService  *service.BudgetService
+	undoService    *service.UndoService
+
+	month time.Month
+	year  int
+
+	expenses   []models.Expense // Expenses of the month, newest first
+	visible    []models.Expense // Expenses matching the filter
+	summary    models.ExpenseSummary
+	budget     *models.BudgetReport // Nil when the month has no budgets
+	categories []string             // Categories to complete in forms
+
+	filter    string
+	filtering bool // Keys are typed into the filter
+	cursor    int  // Index of the selected expense in visible
+	offset    int  // Index of the first visible row
+
+	form     *form           // Form being filled in, nil when showing the table
+	deleting *models.Expense // Expense waiting for delete confirmation
+	message  string
+	isError  bool
+
+	width, height int
+	quit          bool
+}
+
+// NewApp creates a terminal interface showing the current month
+func NewApp(expenseService *service.ExpenseService, budgetService *service.BudgetService, undoService *service.UndoService) *App {
+	now := time.Now()
+	return &App{
+		expenseService: expenseService,
+		budgetService:  budgetService,
+		undoService:    undoService,
+		month:          now.Month(),
+		year:           now.Year(),
+		width:          80,
+		height:         24,
+	}
+}
+
+// Run takes over the terminal until the user quits
+func (a *App) Run() error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return errors.New("tui requires an interactive terminal")
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return fmt.Errorf("failed to switch the terminal to raw mode: %w", err)
+	}
+	defer term.Restore(in, state)
+
+	// Use the alternate screen so the shell is restored on exit
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan []key)
+	go readKeys(os.Stdin, keys)
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+	defer stopResize(resized)
+
+	a.reload()
+	for !a.quit {
+		if width, height, err := term.GetSize(out); err == nil {
+			a.width, a.height = width, height
+		}
+		a.scroll()
+		if _, err := os.Stdout.WriteString(a.render()); err != nil {
+			return fmt.Errorf("failed to draw the screen: %w", err)
+		}
+
+		select {
+		case pressed, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, k := range pressed {
+				a.handleKey(k)
+			}
+		case <-resized:
+		}
+	}
+
+	return nil
+}
+
+// readKeys sends the keys pressed on the terminal until reading fails
+func readKeys(in *os.File, keys chan<- []key) {
+	defer close(keys)
+	buf := make([]byte, 256)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			keys <- decodeKeys(buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// reload reads the expenses, summary and budgets of the month and the
+// categories to complete
+func (a *App) reload() {
+	from := time.Date(a.year, a.month, 1, 0, 0, 0, 0, time.Local)
+	expenses, err := a.expenseService.FindExpenses(repository.Query{
+		From:   from,
+		To:     from.AddDate(0, 1, 0),
+		SortBy: repository.SortByDate,
+		Desc:   true,
+	})
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.expenses = expenses
+
+	summary, err := a.expenseService.GetMonthlySummary(int(a.month), a.year)
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.summary = summary
+
+	a.budget = nil
+	report, err := a.budgetService.CheckBudget(int(a.month), a.year)
+	switch {
+	case err == nil:
+		a.budget = &report
+	case !errors.Is(err, repository.ErrNotFound):
+		a.setError(err)
+		return
+	}
+
+	overall, err := a.expenseService.GetExpenseSummary()
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.categories = a.categories[:0]
+	for category := range overall.CategoryTotals {
+		if category != "" {
+			a.categories = append(a.categories, category)
+		}
+	}
+	slices.SortFunc(a.categories, models.CompareCategories)
+
+	a.applyFilter()
+}
+
+// applyFilter selects the expenses matching every word of the filter
+func (a *App) applyFilter() {
+	words := strings.Fields(strings.ToLower(a.filter))
+	a.visible = a.visible[:0]
+	for _, expense := range a.expenses {
+		text := strings.ToLower(strings.Join([]string{
+			expense.Description, expense.Category, expense.Merchant, strings.Join(expense.Tags, " "), expense.Amount.String(),
+		}, " "))
+		if !slices.ContainsFunc(words, func(word string) bool { return !strings.Contains(text, word) }) {
+			a.visible = append(a.visible, expense)
+		}
+	}
+	a.cursor = min(a.cursor, max(len(a.visible)-1, 0))
+}
+
+// selected returns the expense under the cursor, or nil when none is shown
+func (a *App) selected() *models.Expense {
+	if a.cursor < 0 || a.cursor >= len(a.visible) {
+		return nil
+	}
+	return &a.visible[a.cursor]
+}
+
+// selectExpense moves the cursor to the expense with the given ID, if shown
+func (a *App) selectExpense(id int) {
+	if i := slices.IndexFunc(a.visible, func(e models.Expense) bool { return e.ID == id }); i >= 0 {
+		a.cursor = i
+	}
+}
+
+// switchMonth shows the month delta months away
+func (a *App) switchMonth(delta int) {
+	date := time.Date(a.year, a.month+time.Month(delta), 1, 0, 0, 0, 0, time.Local)
+	a.month, a.year = date.Month(), date.Year()
+	a.cursor, a.offset = 0, 0
+	a.reload()
+}
+
+// setMessage shows an informational message in the status line
+func (a *App) setMessage(format string, args ...any) {
+	a.message = fmt.Sprintf(format, args...)
+	a.isError = false
+}
+
+// setError shows an error in the status line
+func (a *App) setError(err error) {
+	a.message = err.Error()
+	a.isError = true
+}
+
+// handleKey dispatches a key press to the active part of the interface
+func (a *App) handleKey(k key) {
+	if k.name == "ctrl+c" {
+		a.quit = true
+		return
+	}
+
+	switch {
+	case a.form != nil:
+		a.handleFormKey(k)
+	case a.deleting != nil:
+		a.handleDeleteKey(k)
+	case a.filtering:
+		a.handleFilterKey(k)
+	default:
+		a.handleTableKey(k)
+	}
+}
+
+// handleTableKey handles keys while browsing the expense table
+func (a *App) handleTableKey(k key) {
+	a.message = ""
+	switch {
+	case k.name == "up" || k.r == 'k':
+		a.cursor = max(a.cursor-1, 0)
+	case k.name == "down" || k.r == 'j':
+		a.cursor = min(a.cursor+1, max(len(a.visible)-1, 0))
+	case k.name == "pgup":
+		a.cursor = max(a.cursor-a.tableHeight(), 0)
+	case k.name == "pgdn":
+		a.cursor = min(a.cursor+a.tableHeight(), max(len(a.visible)-1, 0))
+	case k.name == "home" || k.r == 'g':
+		a.cursor = 0
+	case k.name == "end" || k.r == 'G':
+		a.cursor = max(len(a.visible)-1, 0)
+	case k.name == "left" || k.r == 'h' || k.r == '[':
+		a.switchMonth(-1)
+	case k.name == "right" || k.r == 'l' || k.r == ']':
+		a.switchMonth(1)
+	case k.r == 't':
+		now := time.Now()
+		a.switchMonth(int(now.Month()) - int(a.month) + 12*(now.Year()-a.year))
+	case k.r == '/':
+		a.filtering = true
+	case k.name == "esc":
+		a.filter = ""
+		a.applyFilter()
+	case k.r == 'a':
+		a.form = newAddForm(a.categories)
+	case k.name == "enter" || k.r == 'e':
+		if expense := a.selected(); expense != nil {
+			a.form = newEditForm(*expense, a.categories)
+		}
+	case k.name == "delete" || k.r == 'd':
+		if expense := a.selected(); expense != nil {
+			deleting := *expense
+			a.deleting = &deleting
+		}
+	case k.r == 'u':
+		a.undo()
+	case k.r == 'r':
+		a.redo()
+	case k.r == 'q':
+		a.quit = true
+	}
+}
+
+// handleFilterKey handles keys typed into the filter
+func (a *App) handleFilterKey(k key) {
+	switch k.name {
+	case "":
+		a.filter += string(k.r)
+	case "backspace":
+		if runes := []rune(a.filter); len(runes) > 0 {
+			a.filter = string(runes[:len(runes)-1])
+		}
+	case "ctrl+u":
+		a.filter = ""
+	case "esc":
+		a.filter = ""
+		a.filtering = false
+	case "enter", "down", "up", "tab":
+		a.filtering = false
+	}
+	a.cursor, a.offset = 0, 0
+	a.applyFilter()
+}
+
+// handleDeleteKey handles the answer to the delete confirmation
+func (a *App) handleDeleteKey(k key) {
+	expense := a.deleting
+	a.deleting = nil
+	if k.r != 'y' && k.r != 'Y' {
+		a.setMessage("Delete cancelled")
+		return
+	}
+
+	if err := a.expenseService.DeleteExpense(expense.ID); err != nil {
+		a.setError(err)
+		return
+	}
+	a.reload()
+	a.setMessage("Moved expense %d to the trash, press u to undo", expense.ID)
+}
+
+// handleFormKey handles keys while filling in a form
+func (a *App) handleFormKey(k key) {
+	f := a.form
+	switch k.name {
+	case "":
+		f.typeRune(k.r)
+	case "backspace":
+		f.backspace()
+	case "ctrl+u":
+		f.clear()
+	case "tab":
+		f.tab()
+	case "down":
+		f.move(1)
+	case "backtab", "up":
+		f.move(-1)
+	case "esc":
+		a.form = nil
+		a.setMessage("Cancelled")
+	case "enter", "ctrl+s":
+		a.save()
+	}
+}
+
+// save adds or updates the expense of the form, then shows it in its month
+func (a *App) save() {
+	f := a.form
+
+	var expense models.Expense
+	if f.editing == nil {
+		input, err := f.newExpense()
+		if err != nil {
+			a.setError(err)
+			return
+		}
+		added, err := a.expenseService.AddExpense(input)
+		if err != nil {
+			a.setError(err)
+			return
+		}
+		if expense, err = a.expenseService.GetExpenseByID(added.ID); err != nil {
+			a.setError(err)
+			return
+		}
+		switch {
+		case added.Rule != nil:
+			a.setMessage("Added expense %d, categorized as %s by rule %d", added.ID, expense.Category, added.Rule.ID)
+		case added.Suggestion != nil:
+			a.setMessage("Added expense %d, categorized as %s from past expenses", added.ID, expense.Category)
+		default:
+			a.setMessage("Added expense %d", added.ID)
+		}
+	} else {
+		update, changed, err := f.update()
+		if err != nil {
+			a.setError(err)
+			return
+		}
+		if !changed {
+			a.form = nil
+			a.setMessage("No changes")
+			return
+		}
+		if expense, err = a.expenseService.UpdateExpense(f.editing.ID, update); err != nil {
+			a.setError(err)
+			return
+		}
+		a.setMessage("Updated expense %d", expense.ID)
+	}
+
+	a.form = nil
+	message := a.message
+	a.month, a.year = expense.Date.Local().Month(), expense.Date.Local().Year()
+	a.filter = ""
+	a.reload()
+	a.selectExpense(expense.ID)
+	if !a.isError {
+		a.setMessage("%s", message)
+	}
+}
+
+// undo reverts the last change
+func (a *App) undo() {
+	ops, err := a.undoService.Undo(1)
+	a.reload()
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.setMessage("Undone: %s", ops[0].Description)
+}
+
+// redo reapplies the last undone change
+func (a *App) redo() {
+	ops, err := a.undoService.Redo(1)
+	a.reload()
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.setMessage("Redone: %s", ops[0].Description)
+}
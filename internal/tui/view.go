@@ -0,0 +1,278 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escape sequences for styling text
+const (
+	styleReset   = "\x1b[0m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReverse = "\x1b[7m"
+	styleRed     = "\x1b[31m"
+	styleGreen   = "\x1b[32m"
+)
+
+// Lines taken by the title, summary, filter and column headings above the
+// table, and by the status and help lines below it
+const (
+	headerLines = 4
+	footerLines = 2
+)
+
+// Widths of the fixed table columns
+const (
+	dateWidth     = 10
+	categoryWidth = 18
+	tagsWidth     = 14
+	amountWidth   = 14
+)
+
+// tableHeight returns the number of expense rows that fit on the screen
+func (a *App) tableHeight() int {
+	return max(a.height-headerLines-footerLines, 1)
+}
+
+// scroll keeps the cursor within the rows shown
+func (a *App) scroll() {
+	height := a.tableHeight()
+	if a.cursor < a.offset {
+		a.offset = a.cursor
+	}
+	if a.cursor >= a.offset+height {
+		a.offset = a.cursor - height + 1
+	}
+	a.offset = max(min(a.offset, len(a.visible)-height), 0)
+}
+
+// render draws the whole screen
+func (a *App) render() string {
+	lines := []string{
+		styleReverse + styleBold + fit(fmt.Sprintf(" Expenses · %s %d", a.month, a.year), a.width) + styleReset,
+		a.summaryLine(),
+		a.filterLine(),
+	}
+
+	if a.form != nil {
+		lines = append(lines, a.formLines()...)
+	} else {
+		lines = append(lines, a.tableLines()...)
+	}
+
+	// Pad the body so the status and help lines stay at the bottom
+	for len(lines) < a.height-footerLines {
+		lines = append(lines, "")
+	}
+	lines = lines[:max(a.height-footerLines, 0)]
+	lines = append(lines, a.statusLine(), styleDim+fit(a.helpText(), a.width)+styleReset)
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	return b.String()
+}
+
+// summaryLine shows the total of the month and how much of its budget is left
+func (a *App) summaryLine() string {
+	noun := "expenses"
+	if a.summary.ExpenseCount == 1 {
+		noun = "expense"
+	}
+	text := fmt.Sprintf(" Total %s · %d %s", a.summary.TotalAmount.Format(a.summary.Currency), a.summary.ExpenseCount, noun)
+	if a.budget == nil {
+		return fit(text+" · no budget", a.width)
+	}
+
+	style := styleGreen
+	if overall := a.budget.Overall; overall != nil {
+		if overall.Exceeded {
+			text += fmt.Sprintf(" · budget %s, over by %s", overall.Budget.Format(a.summary.Currency), (-overall.Remaining).Format(a.summary.Currency))
+		} else {
+			text += fmt.Sprintf(" · budget %s, %s left", overall.Budget.Format(a.summary.Currency), overall.Remaining.Format(a.summary.Currency))
+		}
+	}
+
+	exceeded := 0
+	for _, status := range a.budget.Categories {
+		if status.Exceeded {
+			exceeded++
+		}
+	}
+	if len(a.budget.Categories) > 0 {
+		text += fmt.Sprintf(" · %d of %d category budgets exceeded", exceeded, len(a.budget.Categories))
+	}
+	if a.budget.Exceeded() {
+		style = styleRed
+	}
+
+	return style + fit(text, a.width) + styleReset
+}
+
+// filterLine shows the filter being typed or applied
+func (a *App) filterLine() string {
+	switch {
+	case a.filtering:
+		return " Filter: " + tail(a.filter, a.width-11) + styleReverse + " " + styleReset
+	case a.filter != "":
+		return fit(fmt.Sprintf(" Filter: %s (%d of %d shown, Esc to clear)", a.filter, len(a.visible), len(a.expenses)), a.width)
+	default:
+		return ""
+	}
+}
+
+// tableLines draws the column headings and the visible expenses
+func (a *App) tableLines() []string {
+	descriptionWidth := max(a.width-dateWidth-categoryWidth-tagsWidth-amountWidth-6, 10)
+	row := func(date, description, category, tags, amount string) string {
+		return " " + fit(date, dateWidth) + " " + fit(description, descriptionWidth) + " " +
+			fit(category, categoryWidth) + " " + fit(tags, tagsWidth) + " " + fitRight(amount, amountWidth)
+	}
+
+	lines := []string{styleBold + fit(row("Date", "Description", "Category", "Tags", "Amount"), a.width) + styleReset}
+	if len(a.visible) == 0 {
+		message := " No expenses this month, press a to add one"
+		if a.filter != "" {
+			message = " No expenses match the filter"
+		}
+		return append(lines, styleDim+fit(message, a.width)+styleReset)
+	}
+
+	end := min(a.offset+a.tableHeight(), len(a.visible))
+	for i := a.offset; i < end; i++ {
+		expense := a.visible[i]
+		line := fit(row(
+			expense.Date.Local().Format(dateLayout),
+			expense.Description,
+			expense.Category,
+			strings.Join(expense.Tags, ","),
+			expense.Amount.Format(expense.CurrencyCode()),
+		), a.width)
+		if i == a.cursor {
+			line = styleReverse + line + styleReset
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// formLines draws the form being filled in, with category completions
+func (a *App) formLines() []string {
+	f := a.form
+	lines := []string{styleBold + fit(" "+f.title(), a.width) + styleReset}
+
+	for i, label := range fieldLabels {
+		prefix := fmt.Sprintf("   %-12s ", label)
+		value := f.values[i]
+		if i != f.focus {
+			if value == "" && i == fieldDate && f.editing == nil {
+				lines = append(lines, prefix+styleDim+"today"+styleReset)
+				continue
+			}
+			lines = append(lines, fit(prefix+value, a.width))
+			continue
+		}
+
+		available := a.width - len(prefix) - 1
+		line := styleBold + " > " + fmt.Sprintf("%-12s ", label) + styleReset + tail(value, available)
+		completion := f.completion()
+		if rest := completion[min(len(value), len(completion)):]; rest != "" && utf8.RuneCountInString(value+rest) <= available {
+			first, size := utf8.DecodeRuneInString(rest)
+			line += styleReverse + string(first) + styleReset + styleDim + rest[size:] + styleReset
+		} else {
+			line += styleReverse + " " + styleReset
+		}
+		lines = append(lines, line)
+	}
+
+	if f.focus == fieldCategory {
+		if matches := f.matches(); len(matches) > 0 {
+			lines = append(lines, "", styleDim+fit("   Matching categories: "+strings.Join(matches, ", "), a.width)+styleReset)
+		}
+	}
+
+	return lines
+}
+
+// statusLine shows the last message or the delete confirmation
+func (a *App) statusLine() string {
+	switch {
+	case a.deleting != nil:
+		return styleBold + fit(fmt.Sprintf(" Move %q (%s) to the trash? y/n", a.deleting.Description, a.deleting.Amount.Format(a.deleting.CurrencyCode())), a.width) + styleReset
+	case a.isError:
+		return styleRed + fit(" Error: "+a.message, a.width) + styleReset
+	case a.message != "":
+		return fit(" "+a.message, a.width)
+	default:
+		return ""
+	}
+}
+
+// helpText lists the keys of the active part of the interface
+func (a *App) helpText() string {
+	switch {
+	case a.form != nil:
+		return " Enter save  Esc cancel  Tab complete or next field  ↑↓ field  Ctrl+U clear"
+	case a.filtering:
+		return " Type to filter  Enter done  Esc clear"
+	default:
+		return " ←→ month  t today  ↑↓ move  / filter  a add  e edit  d delete  u undo  r redo  q quit"
+	}
+}
+
+// fit truncates or pads s to exactly width characters
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	s = strings.Map(printable, s)
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		runes := []rune(s)
+		if width == 1 {
+			return "…"
+		}
+		return string(runes[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+// tail returns the end of s that fits in width characters, so that the end
+// of a value being typed stays visible
+func tail(s string, width int) string {
+	s = strings.Map(printable, s)
+	runes := []rune(s)
+	if width <= 0 {
+		return ""
+	}
+	if len(runes) <= width {
+		return s
+	}
+	return "…" + string(runes[len(runes)-width+1:])
+}
+
+// fitRight is fit with the text aligned to the right
+func fitRight(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
+		return fit(s, width)
+	}
+	return strings.Repeat(" ", width-n) + s
+}
+
+// printable replaces control characters, which would disturb the screen,
+// with spaces
+func printable(r rune) rune {
+	if r < 0x20 || r == 0x7f {
+		return ' '
+	}
+	return r
+}